@@ -0,0 +1,262 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command hpa-simulate computes the replica count a HorizontalPodAutoscaler
+// would scale to, querying the metrics APIs of a cluster as the HPA
+// controller does.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/hpa"
+)
+
+type options struct {
+	kubeconfig      string
+	context         string
+	namespace       string
+	output          string
+	filename        string
+	currentReplicas int32
+	selector        string
+	listPods        bool
+	tolerance       float64
+	out             io.Writer
+}
+
+// newRESTMapper returns the mapper of the kinds of the objects described
+// by Object metrics.
+var newRESTMapper = discoveryRESTMapper
+
+func main() {
+	cmd := newCommand(os.Stdout, os.Stderr)
+	if err := cmd.ExecuteContext(genericapiserver.SetupSignalContext()); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newCommand(out, errOut io.Writer) *cobra.Command {
+	o := &options{out: out}
+	cmd := &cobra.Command{
+		Use:   "hpa-simulate -f HPA_FILE",
+		Short: "Compute the replica count a HorizontalPodAutoscaler would scale to",
+		Long: "Compute the replica count a HorizontalPodAutoscaler would scale to, from the current values of its Object, Pods and External metrics.\n" +
+			"Scaling policies are applied as if no scaling happened recently, and stabilization windows are ignored.",
+		Example: "  hpa-simulate -f hpa.yaml --current-replicas 4 --selector app=frontend",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.run(cmd.Context(), cmd.Flags().Changed("current-replicas"))
+		},
+		SilenceUsage: true,
+	}
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use, instead of the default loading rules.")
+	flags.StringVar(&o.context, "context", "", "Name of the kubeconfig context to use.")
+	flags.StringVarP(&o.namespace, "namespace", "n", "", "Namespace of the HPA, if not set in its manifest, defaulting to the one of the kubeconfig context.")
+	flags.StringVarP(&o.output, "output", "o", "table", "Output format, either table or json.")
+	flags.StringVarP(&o.filename, "filename", "f", "", "Path to the manifest of the HPA, in YAML or JSON, or - for the standard input.")
+	flags.Int32Var(&o.currentReplicas, "current-replicas", 0, "Replica count of the scale target, defaulting to the one in the status of the HPA.")
+	flags.StringVarP(&o.selector, "selector", "l", "", "Label selector of the pods of the scale target, required for Pods metrics.")
+	flags.BoolVar(&o.listPods, "list-pods", true, "List the pods matching --selector, to account for the unready pods and the pods without metrics as the HPA does.")
+	flags.Float64Var(&o.tolerance, "tolerance", hpa.DefaultTolerance, "Tolerance of the HPAs which don't configure one, as set on the controller manager.")
+	_ = cmd.MarkFlagRequired("filename")
+	return cmd
+}
+
+func (o *options) run(ctx context.Context, currentReplicasSet bool) error {
+	if o.output != "table" && o.output != "json" {
+		return fmt.Errorf("unsupported output format %q", o.output)
+	}
+	autoscaler, err := o.readHPA()
+	if err != nil {
+		return err
+	}
+
+	input := hpa.Input{HPA: autoscaler, CurrentReplicas: o.currentReplicas}
+	if !currentReplicasSet {
+		if autoscaler.Status.CurrentReplicas == 0 {
+			return errors.New("--current-replicas is required when the HPA has no status")
+		}
+		input.CurrentReplicas = autoscaler.Status.CurrentReplicas
+	}
+	if o.selector != "" {
+		if input.Selector, err = labels.Parse(o.selector); err != nil {
+			return fmt.Errorf("invalid selector: %v", err)
+		}
+	}
+
+	config, err := o.clientConfig(autoscaler)
+	if err != nil {
+		return err
+	}
+	if input.Selector != nil && o.listPods {
+		if input.Pods, err = listPods(ctx, config, autoscaler.Namespace, input.Selector); err != nil {
+			return err
+		}
+	}
+	mapper, err := newRESTMapper(config)
+	if err != nil {
+		return err
+	}
+	simulator, err := hpa.NewForConfig(config, mapper)
+	if err != nil {
+		return err
+	}
+	simulator.Tolerance = o.tolerance
+
+	result, simulationErr := simulator.Simulate(input)
+	if err := o.print(result); err != nil {
+		return err
+	}
+	return simulationErr
+}
+
+func (o *options) readHPA() (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	var data []byte
+	var err error
+	if o.filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(o.filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the HPA: %v", err)
+	}
+	autoscaler := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := yaml.UnmarshalStrict(data, autoscaler); err != nil {
+		return nil, fmt.Errorf("unable to decode the HPA: %v", err)
+	}
+	if autoscaler.APIVersion != autoscalingv2.SchemeGroupVersion.String() || autoscaler.Kind != "HorizontalPodAutoscaler" {
+		return nil, fmt.Errorf("expected a %s HorizontalPodAutoscaler, got %s %s", autoscalingv2.SchemeGroupVersion, autoscaler.APIVersion, autoscaler.Kind)
+	}
+	return autoscaler, nil
+}
+
+// clientConfig returns the configuration of the selected kubeconfig
+// context, and defaults the namespace of the HPA.
+func (o *options) clientConfig(autoscaler *autoscalingv2.HorizontalPodAutoscaler) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: o.context}
+	overrides.Context.Namespace = o.namespace
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if autoscaler.Namespace == "" {
+		if autoscaler.Namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+func discoveryRESTMapper(config *rest.Config) (apimeta.RESTMapper, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to construct discovery client: %v", err)
+	}
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)), nil
+}
+
+func listPods(ctx context.Context, config *rest.Config, namespace string, selector labels.Selector) ([]corev1.Pod, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to construct kubernetes client: %v", err)
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the pods: %v", err)
+	}
+	return pods.Items, nil
+}
+
+func (o *options) print(result *hpa.Result) error {
+	if result == nil {
+		return nil
+	}
+	if o.output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(o.out, string(data))
+		return err
+	}
+
+	w := tabwriter.NewWriter(o.out, 0, 8, 2, ' ', 0)
+	if len(result.Metrics) > 0 {
+		fmt.Fprintln(w, "TYPE\tNAME\tTARGET\tCURRENT\tREPLICAS\tERROR")
+		for _, metric := range result.Metrics {
+			if metric.Error != "" {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t<invalid>\t%s\n", metric.Type, metric.Name, target(metric), quantity(metric.Current), metric.Error)
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", metric.Type, metric.Name, target(metric), quantity(metric.Current), metric.Replicas)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Current replicas:\t%d\n", result.CurrentReplicas)
+	fmt.Fprintf(w, "Proposed replicas:\t%d\n", result.ProposedReplicas)
+	fmt.Fprintf(w, "Desired replicas:\t%d\n", result.DesiredReplicas)
+	if result.Reason != "" {
+		fmt.Fprintf(w, "Reason:\t%s\n", result.Reason)
+	}
+	return w.Flush()
+}
+
+func target(metric hpa.MetricResult) string {
+	if metric.TargetType == "" {
+		return "<none>"
+	}
+	return fmt.Sprintf("%s %s", quantity(metric.Target), metric.TargetType)
+}
+
+func quantity(q *resource.Quantity) string {
+	if q == nil {
+		return "<unknown>"
+	}
+	return q.String()
+}
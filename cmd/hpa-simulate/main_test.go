@@ -0,0 +1,210 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	apiservertesting "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/testing"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/defaults"
+)
+
+// testProvider serves 150 for every pod, and 60 for every external metric.
+type testProvider struct {
+	defaults.DefaultCustomMetricsProvider
+	defaults.DefaultExternalMetricsProvider
+}
+
+func (testProvider) GetMetricByName(_ context.Context, name types.NamespacedName, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValue, error) {
+	return nil, provider.NewMetricNotFoundForError(info.GroupResource, info.Metric, name.Name)
+}
+
+func (testProvider) GetMetricBySelector(_ context.Context, namespace string, _ labels.Selector, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValueList, error) {
+	list := &custom_metrics.MetricValueList{}
+	for _, name := range []string{"frontend-0", "frontend-1"} {
+		list.Items = append(list.Items, custom_metrics.MetricValue{
+			DescribedObject: custom_metrics.ObjectReference{Kind: "Pod", Namespace: namespace, Name: name},
+			Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+			Value:           resource.MustParse("150"),
+		})
+	}
+	return list, nil
+}
+
+func (testProvider) GetExternalMetric(_ context.Context, _ string, _ labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	return &external_metrics.ExternalMetricValueList{Items: []external_metrics.ExternalMetricValue{{
+		MetricName: info.Metric,
+		Value:      resource.MustParse("60"),
+	}}}, nil
+}
+
+const testHPA = `apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: frontend
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: frontend
+  maxReplicas: 10
+  metrics:
+  - type: Pods
+    pods:
+      metric:
+        name: http_requests
+      target:
+        type: AverageValue
+        averageValue: "100"
+  - type: External
+    external:
+      metric:
+        name: queue_length
+      target:
+        type: AverageValue
+        averageValue: "20"
+status:
+  currentReplicas: 2
+  desiredReplicas: 2
+`
+
+// writeKubeconfig writes a kubeconfig pointing at an in-process server.
+func writeKubeconfig(t *testing.T, dir string) string {
+	server := apiservertesting.StartTestServer(t, testProvider{}, testProvider{})
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters["test"] = &clientcmdapi.Cluster{Server: server.ClientConfig.Host}
+	config.AuthInfos["test"] = &clientcmdapi.AuthInfo{}
+	config.Contexts["test"] = &clientcmdapi.Context{Cluster: "test", AuthInfo: "test", Namespace: "team-a"}
+	config.CurrentContext = "test"
+
+	path := filepath.Join(dir, "kubeconfig")
+	require.NoError(t, clientcmd.WriteToFile(*config, path))
+	return path
+}
+
+// podRESTMapper maps pods, as the test server doesn't serve the discovery
+// of the core group.
+func podRESTMapper(*rest.Config) (apimeta.RESTMapper, error) {
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), apimeta.RESTScopeNamespace)
+	return mapper, nil
+}
+
+func TestHPASimulate(t *testing.T) {
+	newRESTMapper = podRESTMapper
+	defer func() { newRESTMapper = discoveryRESTMapper }()
+
+	dir := t.TempDir()
+	kubeconfig := writeKubeconfig(t, dir)
+	hpaFile := filepath.Join(dir, "hpa.yaml")
+	require.NoError(t, os.WriteFile(hpaFile, []byte(testHPA), 0o600))
+
+	cases := map[string]struct {
+		args     []string
+		expected string
+		err      bool
+	}{
+		"status replicas": {
+			args: []string{"--selector", "app=frontend"},
+			expected: "" +
+				"TYPE      NAME           TARGET            CURRENT  REPLICAS  ERROR\n" +
+				"Pods      http_requests  100 AverageValue  150      3\n" +
+				"External  queue_length   20 AverageValue   30       3\n" +
+				"\n" +
+				"Current replicas:   2\n" +
+				"Proposed replicas:  3\n" +
+				"Desired replicas:   3\n" +
+				"Reason:             DesiredWithinRange\n",
+		},
+		"current replicas": {
+			args: []string{"--selector", "app=frontend", "--current-replicas", "4", "-o", "json"},
+			expected: `{
+  "currentReplicas": 4,
+  "metrics": [
+    {
+      "type": "Pods",
+      "name": "http_requests",
+      "targetType": "AverageValue",
+      "target": "100",
+      "current": "150",
+      "replicas": 3
+    },
+    {
+      "type": "External",
+      "name": "queue_length",
+      "targetType": "AverageValue",
+      "target": "20",
+      "current": "15",
+      "replicas": 3
+    }
+  ],
+  "proposedReplicas": 3,
+  "desiredReplicas": 3,
+  "reason": "DesiredWithinRange"
+}
+`,
+		},
+		"pods metric without selector": {
+			args: []string{"--current-replicas", "4"},
+			expected: "" +
+				"TYPE      NAME           TARGET            CURRENT    REPLICAS   ERROR\n" +
+				"Pods      http_requests  100 AverageValue  <unknown>  <invalid>  the selector of the pods of the scale target is required for pods metrics\n" +
+				"External  queue_length   20 AverageValue   15         3\n" +
+				"\n" +
+				"Current replicas:   4\n" +
+				"Proposed replicas:  4\n" +
+				"Desired replicas:   4\n",
+			err: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd := newCommand(out, errOut)
+			cmd.SetArgs(append(tc.args, "-f", hpaFile, "--kubeconfig", kubeconfig, "--list-pods=false"))
+			err := cmd.Execute()
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err, errOut.String())
+			}
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command metrics-query fetches metric values from the custom and external
+// metrics APIs of a cluster, as the horizontal pod autoscaler does.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/query"
+)
+
+type options struct {
+	kubeconfig string
+	context    string
+	namespace  string
+	output     string
+	watch      bool
+	interval   time.Duration
+	out        io.Writer
+	errOut     io.Writer
+}
+
+// newRESTMapper returns the mapper used to look up the scope of the
+// resources described by custom metrics.
+var newRESTMapper = newDiscoveryRESTMapper
+
+func main() {
+	cmd := newCommand(os.Stdout, os.Stderr)
+	if err := cmd.ExecuteContext(genericapiserver.SetupSignalContext()); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newCommand(out, errOut io.Writer) *cobra.Command {
+	o := &options{out: out, errOut: errOut}
+	cmd := &cobra.Command{
+		Use:          "metrics-query",
+		Short:        "Query the custom and external metrics APIs",
+		SilenceUsage: true,
+	}
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use, instead of the default loading rules.")
+	flags.StringVar(&o.context, "context", "", "Name of the kubeconfig context to use.")
+	flags.StringVarP(&o.namespace, "namespace", "n", "", "Namespace of the metrics, defaulting to the one of the kubeconfig context.")
+	flags.StringVarP(&o.output, "output", "o", string(query.FormatTable), fmt.Sprintf("Output format, one of %v.", query.Formats))
+	flags.BoolVarP(&o.watch, "watch", "w", false, "Repeat the query at every interval until interrupted.")
+	flags.DurationVar(&o.interval, "interval", 15*time.Second, "Interval between the queries when watching, the HPA sync period by default.")
+
+	cmd.AddCommand(o.customCommand(), o.externalCommand(), o.listCommand())
+	return cmd
+}
+
+func (o *options) customCommand() *cobra.Command {
+	var req query.CustomMetricsRequest
+	var clusterScoped bool
+	cmd := &cobra.Command{
+		Use:   "custom RESOURCE[/NAME] METRIC",
+		Short: "Fetch the values of a custom metric describing objects",
+		Long: "Fetch the values of a custom metric describing an object, or all the objects of a resource matching --selector.\n" +
+			"Metrics of cluster-scoped resources are fetched without a namespace; the scope of the resource is looked up\n" +
+			"with the discovery information of the cluster, unless --cluster-scoped is set.",
+		Example: "  metrics-query custom pods http_requests_per_second --selector app=frontend\n" +
+			"  metrics-query custom deployments.apps/frontend queue_length\n" +
+			"  metrics-query custom nodes/node-1 temperature --cluster-scoped",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resource, name, _ := strings.Cut(args[0], "/")
+			req.Resource = schema.ParseGroupResource(resource)
+			req.Name = name
+			req.Metric = args[1]
+
+			config, namespace, err := o.clientConfig()
+			if err != nil {
+				return err
+			}
+			client, err := query.NewForConfig(config)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("cluster-scoped") {
+				clusterScoped = o.isClusterScoped(config, req.Resource)
+			}
+			if !clusterScoped {
+				req.Namespace = namespace
+			}
+
+			return o.run(cmd.Context(), func(ctx context.Context, printer *query.Printer) error {
+				list, err := client.CustomMetrics(ctx, req)
+				if err != nil {
+					return err
+				}
+				return printer.PrintCustomMetrics(list)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&req.Selector, "selector", "l", "", "Label selector of the described objects.")
+	cmd.Flags().StringVar(&req.MetricSelector, "metric-selector", "", "Label selector of the metric values.")
+	cmd.Flags().StringVar(&req.FieldSelector, "field-selector", "", "Field selector filtering the metric values.")
+	cmd.Flags().BoolVar(&clusterScoped, "cluster-scoped", false,
+		"Whether the resource is cluster-scoped, skipping the lookup of its scope in the discovery information of the cluster.")
+	return cmd
+}
+
+func (o *options) externalCommand() *cobra.Command {
+	var req query.ExternalMetricsRequest
+	cmd := &cobra.Command{
+		Use:     "external METRIC",
+		Short:   "Fetch the values of an external metric",
+		Example: "  metrics-query external queue_messages_ready --metric-selector queue=worker_tasks",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.Metric = args[0]
+
+			client, namespace, err := o.client()
+			if err != nil {
+				return err
+			}
+			req.Namespace = namespace
+
+			return o.run(cmd.Context(), func(ctx context.Context, printer *query.Printer) error {
+				list, err := client.ExternalMetrics(ctx, req)
+				if err != nil {
+					return err
+				}
+				return printer.PrintExternalMetrics(list)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&req.MetricSelector, "metric-selector", "l", "", "Label selector of the metric values.")
+	return cmd
+}
+
+func (o *options) listCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:       "list [custom|external]",
+		Short:     "List the metrics advertised by the adapters",
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"custom", "external"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, _, err := o.client()
+			if err != nil {
+				return err
+			}
+			api := ""
+			if len(args) > 0 {
+				api = args[0]
+			}
+
+			return o.run(cmd.Context(), func(ctx context.Context, printer *query.Printer) error {
+				listing := &query.MetricsListing{}
+				var err error
+				if api == "" || api == "custom" {
+					if listing.CustomMetrics, err = client.ListCustomMetrics(ctx); err != nil {
+						return err
+					}
+				}
+				if api == "" || api == "external" {
+					if listing.ExternalMetrics, err = client.ListExternalMetrics(ctx); err != nil {
+						return err
+					}
+				}
+				return printer.PrintMetricsListing(listing)
+			})
+		},
+	}
+}
+
+// client returns a client for the selected kubeconfig context, along with
+// the namespace to query.
+func (o *options) client() (*query.Client, string, error) {
+	config, namespace, err := o.clientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	client, err := query.NewForConfig(config)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, namespace, nil
+}
+
+// clientConfig returns the configuration of the selected kubeconfig context,
+// along with the namespace to query.
+func (o *options) clientConfig() (*rest.Config, string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: o.context}
+	overrides.Context.Namespace = o.namespace
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+	return config, namespace, nil
+}
+
+// isClusterScoped returns whether the given resource is cluster-scoped.  If
+// its scope can't be looked up, the resource is assumed to be namespaced, as
+// most resources described by custom metrics are.
+func (o *options) isClusterScoped(config *rest.Config, resource schema.GroupResource) bool {
+	scope, err := resourceScope(config, resource)
+	if err != nil {
+		fmt.Fprintf(o.errOut, "warning: assuming %s is namespaced, as its scope couldn't be looked up "+
+			"(set --cluster-scoped to skip the lookup): %v\n", resource, err)
+		return false
+	}
+	return scope == meta.RESTScopeNameRoot
+}
+
+// resourceScope looks up the scope of the given resource with the RESTMapper.
+func resourceScope(config *rest.Config, resource schema.GroupResource) (meta.RESTScopeName, error) {
+	mapper, err := newRESTMapper(config)
+	if err != nil {
+		return "", err
+	}
+	gvk, err := mapper.KindFor(resource.WithVersion(""))
+	if err != nil {
+		return "", err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return "", err
+	}
+	return mapping.Scope.Name(), nil
+}
+
+// newDiscoveryRESTMapper returns a RESTMapper populated lazily with the
+// discovery information of the cluster.
+func newDiscoveryRESTMapper(config *rest.Config) (meta.RESTMapper, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)), nil
+}
+
+// run runs the query once, or at every interval when watching.
+func (o *options) run(ctx context.Context, queryFn func(ctx context.Context, printer *query.Printer) error) error {
+	printer, err := query.NewPrinter(o.out, query.Format(o.output))
+	if err != nil {
+		return err
+	}
+	if !o.watch {
+		return queryFn(ctx, printer)
+	}
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		if err := queryFn(ctx, printer); err != nil {
+			// keep watching through transient errors, as the HPA does
+			fmt.Fprintf(o.errOut, "error: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
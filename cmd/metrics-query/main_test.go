@@ -0,0 +1,218 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	apiservertesting "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/testing"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// testProvider serves a single value per query, describing the requested
+// object in the requested namespace.
+type testProvider struct{}
+
+func (testProvider) GetMetricByName(_ context.Context, name types.NamespacedName, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValue, error) {
+	return &custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{Kind: "Node", Namespace: name.Namespace, Name: name.Name},
+		Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+		Value:           resource.MustParse("40"),
+	}, nil
+}
+
+func (testProvider) GetMetricBySelector(_ context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValueList, error) {
+	return &custom_metrics.MetricValueList{Items: []custom_metrics.MetricValue{{
+		DescribedObject: custom_metrics.ObjectReference{Kind: "Pod", Namespace: namespace, Name: selector.String()},
+		Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+		Value:           resource.MustParse("2"),
+	}}}, nil
+}
+
+func (testProvider) ListAllMetrics() []provider.CustomMetricInfo {
+	return []provider.CustomMetricInfo{
+		{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"},
+		{GroupResource: schema.GroupResource{Resource: "nodes"}, Metric: "temperature"},
+	}
+}
+
+func (testProvider) GetExternalMetric(_ context.Context, namespace string, _ labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	return &external_metrics.ExternalMetricValueList{Items: []external_metrics.ExternalMetricValue{{
+		MetricName:   info.Metric,
+		MetricLabels: map[string]string{"namespace": namespace},
+		Value:        resource.MustParse("7"),
+	}}}, nil
+}
+
+func (testProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	return []provider.ExternalMetricInfo{{Metric: "queue_length"}}
+}
+
+// writeKubeconfig writes a kubeconfig pointing at an in-process server, with
+// the given default namespace.
+func writeKubeconfig(t *testing.T, namespace string) string {
+	server := apiservertesting.StartTestServer(t, testProvider{}, testProvider{})
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters["test"] = &clientcmdapi.Cluster{Server: server.ClientConfig.Host}
+	config.AuthInfos["test"] = &clientcmdapi.AuthInfo{}
+	config.Contexts["test"] = &clientcmdapi.Context{Cluster: "test", AuthInfo: "test", Namespace: namespace}
+	config.CurrentContext = "test"
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, clientcmd.WriteToFile(*config, path))
+	return path
+}
+
+// useStaticRESTMapper makes the commands look up the scope of pods and
+// nodes only, as the test server doesn't serve their APIs.
+func useStaticRESTMapper(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, meta.RESTScopeRoot)
+	previous := newRESTMapper
+	newRESTMapper = func(*rest.Config) (meta.RESTMapper, error) { return mapper, nil }
+	t.Cleanup(func() { newRESTMapper = previous })
+}
+
+func TestMetricsQuery(t *testing.T) {
+	kubeconfig := writeKubeconfig(t, "team-a")
+	useStaticRESTMapper(t)
+
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"selected pods": {
+			args: []string{"custom", "pods", "http_requests", "-l", "app=frontend"},
+			expected: "" +
+				"NAMESPACE   OBJECT             METRIC          LABELS   VALUE   TIMESTAMP   WINDOW\n" +
+				"team-a      pod/app=frontend   http_requests   <none>   2       <none>      <none>\n",
+		},
+		"namespace flag": {
+			args: []string{"custom", "pods", "http_requests", "-n", "team-b"},
+			expected: "" +
+				"NAMESPACE   OBJECT   METRIC          LABELS   VALUE   TIMESTAMP   WINDOW\n" +
+				"team-b      pod/     http_requests   <none>   2       <none>      <none>\n",
+		},
+		"cluster-scoped object": {
+			args: []string{"custom", "nodes/node-1", "temperature"},
+			expected: "" +
+				"NAMESPACE   OBJECT        METRIC        LABELS   VALUE   TIMESTAMP   WINDOW\n" +
+				"<none>      node/node-1   temperature   <none>   40      <none>      <none>\n",
+		},
+		"cluster-scoped flag": {
+			args: []string{"custom", "widgets/widget-1", "temperature", "--cluster-scoped"},
+			expected: "" +
+				"NAMESPACE   OBJECT          METRIC        LABELS   VALUE   TIMESTAMP   WINDOW\n" +
+				"<none>      node/widget-1   temperature   <none>   40      <none>      <none>\n",
+		},
+		"unknown scope": {
+			args: []string{"custom", "widgets/widget-1", "temperature"},
+			expected: "" +
+				"NAMESPACE   OBJECT          METRIC        LABELS   VALUE   TIMESTAMP   WINDOW\n" +
+				"team-a      node/widget-1   temperature   <none>   40      <none>      <none>\n",
+		},
+		"external metric": {
+			args: []string{"external", "queue_length"},
+			expected: "" +
+				"METRIC         LABELS             VALUE   TIMESTAMP   WINDOW\n" +
+				"queue_length   namespace=team-a   7       <none>      <none>\n",
+		},
+		"list": {
+			args: []string{"list"},
+			expected: "" +
+				"API                       RESOURCE   METRIC          NAMESPACED\n" +
+				"custom.metrics.k8s.io     nodes      temperature     false\n" +
+				"custom.metrics.k8s.io     pods       http_requests   true\n" +
+				"external.metrics.k8s.io   <none>     queue_length    true\n",
+		},
+		"list external as json": {
+			args:     []string{"list", "external", "-o", "json"},
+			expected: "{\n  \"externalMetrics\": [\n    {\n      \"Metric\": \"queue_length\"\n    }\n  ]\n}\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd := newCommand(out, errOut)
+			cmd.SetArgs(append(tc.args, "--kubeconfig", kubeconfig))
+			require.NoError(t, cmd.Execute(), errOut.String())
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+// syncBuffer is a buffer which can be read while a command writes to it.
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestMetricsQueryWatch(t *testing.T) {
+	kubeconfig := writeKubeconfig(t, "team-a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := &syncBuffer{}
+	cmd := newCommand(out, &bytes.Buffer{})
+	cmd.SetArgs([]string{"external", "queue_length", "--watch", "--interval", "1ms", "--kubeconfig", kubeconfig})
+	done := make(chan error)
+	go func() {
+		done <- cmd.ExecuteContext(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return strings.Count(out.String(), "queue_length") >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, strings.Count(out.String(), "METRIC"), "the header should only be printed once")
+}
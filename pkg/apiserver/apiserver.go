@@ -17,6 +17,8 @@ limitations under the License.
 package apiserver
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -29,11 +31,17 @@ import (
 	eminstall "k8s.io/metrics/pkg/apis/external_metrics/install"
 
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/installer"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/inventory"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metadata"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/registry/staleness"
 )
 
 var (
 	Scheme = runtime.NewScheme()
+	// Codecs serializes the metrics APIs as JSON, YAML or protobuf
+	// (application/vnd.kubernetes.protobuf), as negotiated with clients.
 	Codecs = serializer.NewCodecFactory(Scheme)
 )
 
@@ -61,6 +69,25 @@ func init() {
 
 type Config struct {
 	GenericConfig *genericapiserver.Config
+
+	// StalenessPolicy limits the age of the metric values served by the
+	// metrics APIs.  If nil, values of any age are served.
+	StalenessPolicy *staleness.Policy
+
+	// MetricLabelGuard bounds the cardinality of the per-metric instrumentation.
+	// If nil, a default limit is used.
+	MetricLabelGuard *metrics.LabelGuard
+
+	// HealthCheckCacheDuration is how long a successful provider health check
+	// is cached for, when the providers implement provider.HealthCheckingProvider.
+	// Failed checks are cached for a tenth of it.  It defaults to
+	// DefaultHealthCheckCacheDuration.
+	HealthCheckCacheDuration time.Duration
+
+	// MetricsListingInterval is the interval at which the metrics listed by
+	// the providers are refreshed in the cache serving discovery.  If zero,
+	// the providers are listed on each discovery request.
+	MetricsListingInterval time.Duration
 }
 
 // CustomMetricsAdapterServer contains state for a Kubernetes cluster master/api server.
@@ -68,16 +95,34 @@ type CustomMetricsAdapterServer struct {
 	GenericAPIServer        *genericapiserver.GenericAPIServer
 	customMetricsProvider   provider.CustomMetricsProvider
 	externalMetricsProvider provider.ExternalMetricsProvider
+	stalenessPolicy         *staleness.Policy
+	metricLabelGuard        *metrics.LabelGuard
+	customMetricQueries     *inventory.Tracker
+	externalMetricQueries   *inventory.Tracker
+	aggregatedDiscovery     *aggregatedDiscovery
+	customMetricsListing    *provider.ListingCache[provider.CustomMetricInfo]
+	externalMetricsListing  *provider.ListingCache[provider.ExternalMetricInfo]
 }
 
 type CompletedConfig struct {
 	genericapiserver.CompletedConfig
+
+	StalenessPolicy          *staleness.Policy
+	MetricLabelGuard         *metrics.LabelGuard
+	HealthCheckCacheDuration time.Duration
+	MetricsListingInterval   time.Duration
 }
 
 // Complete fills in any fields not set that are required to have valid data. It's mutating the receiver.
 func (c *Config) Complete(informers informers.SharedInformerFactory) CompletedConfig {
 	c.GenericConfig.EffectiveVersion = compatibility.DefaultBuildEffectiveVersion()
-	return CompletedConfig{c.GenericConfig.Complete(informers)}
+	return CompletedConfig{
+		CompletedConfig:          c.GenericConfig.Complete(informers),
+		StalenessPolicy:          c.StalenessPolicy,
+		MetricLabelGuard:         c.MetricLabelGuard,
+		HealthCheckCacheDuration: c.HealthCheckCacheDuration,
+		MetricsListingInterval:   c.MetricsListingInterval,
+	}
 }
 
 // New returns a new instance of CustomMetricsAdapterServer from the given config.
@@ -94,6 +139,21 @@ func (c CompletedConfig) New(name string, customMetricsProvider provider.CustomM
 		GenericAPIServer:        genericServer,
 		customMetricsProvider:   customMetricsProvider,
 		externalMetricsProvider: externalMetricsProvider,
+		stalenessPolicy:         c.StalenessPolicy,
+		metricLabelGuard:        c.MetricLabelGuard,
+		customMetricQueries:     inventory.NewTracker(inventory.DefaultMaxTrackedMetrics),
+		externalMetricQueries:   inventory.NewTracker(inventory.DefaultMaxTrackedMetrics),
+		aggregatedDiscovery:     newAggregatedDiscovery(genericServer.AggregatedDiscoveryGroupManager),
+	}
+	genericServer.Handler.GoRestfulContainer.Filter(s.aggregatedDiscovery.filter)
+
+	// the server is only ready to serve metrics while the providers are able to
+	if err := genericServer.AddReadyzChecks(providerHealthCheckers(c.HealthCheckCacheDuration, customMetricsProvider, externalMetricsProvider)...); err != nil {
+		return nil, err
+	}
+
+	if err := s.listMetrics(c.MetricsListingInterval); err != nil {
+		return nil, err
 	}
 
 	if customMetricsProvider != nil {
@@ -109,3 +169,39 @@ func (c CompletedConfig) New(name string, customMetricsProvider provider.CustomM
 
 	return s, nil
 }
+
+// MetricsInventory returns a collector of the inventory of the metrics served
+// by the server, including the queries made to the providers so far.  Its
+// RESTMapper, or the function constructing it, is left for the caller to set.
+func (s *CustomMetricsAdapterServer) MetricsInventory() *inventory.Collector {
+	collector := &inventory.Collector{
+		CustomMetrics:         s.customMetricsProvider,
+		ExternalMetrics:       s.externalMetricsProvider,
+		CustomMetricQueries:   s.customMetricQueries,
+		ExternalMetricQueries: s.externalMetricQueries,
+	}
+	// the listings are only set for the APIs which are served, to keep nil
+	// pointers out of the interfaces
+	if s.customMetricsListing != nil {
+		collector.CustomMetricsListing = s.customMetricsListing
+	}
+	if s.externalMetricsListing != nil {
+		collector.ExternalMetricsListing = s.externalMetricsListing
+	}
+	return collector
+}
+
+// MetricsMetadata returns a handler serving the metadata of the metrics listed
+// by the providers, as they are served by discovery.
+func (s *CustomMetricsAdapterServer) MetricsMetadata() *metadata.Handler {
+	handler := &metadata.Handler{}
+	if s.customMetricsListing != nil {
+		handler.CustomMetrics = s.customMetricsListing.List
+		handler.CustomMetricsMetadata, _ = provider.As[provider.MetricMetadataProvider](s.customMetricsProvider)
+	}
+	if s.externalMetricsListing != nil {
+		handler.ExternalMetrics = s.externalMetricsListing.List
+		handler.ExternalMetricsMetadata, _ = provider.As[provider.MetricMetadataProvider](s.externalMetricsProvider)
+	}
+	return handler
+}
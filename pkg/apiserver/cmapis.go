@@ -27,7 +27,7 @@ import (
 	"k8s.io/metrics/pkg/apis/custom_metrics"
 
 	specificapi "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/installer"
-	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
 	metricstorage "sigs.k8s.io/custom-metrics-apiserver/pkg/registry/custom_metrics"
 )
 
@@ -61,11 +61,17 @@ func (s *CustomMetricsAdapterServer) InstallCustomMetricsAPI() error {
 			container.Add(discovery.NewAPIGroupHandler(s.GenericAPIServer.Serializer, apiGroup).WebService())
 		}
 	}
+	s.aggregatedDiscovery.addGroupVersions(groupInfo.PrioritizedVersions, s.customMetricsLister())
 	return nil
 }
 
 func (s *CustomMetricsAdapterServer) cmAPI(groupInfo *genericapiserver.APIGroupInfo, groupVersion schema.GroupVersion) *specificapi.MetricsAPIGroupVersion {
-	resourceStorage := metricstorage.NewREST(s.customMetricsProvider)
+	resourceStorage := metricstorage.NewREST(s.customMetricsProvider).
+		WithStalenessPolicy(s.stalenessPolicy).
+		WithProviderObserver(metrics.ProviderObservers{
+			metrics.NewProviderObserver(custom_metrics.GroupName, s.metricLabelGuard),
+			s.customMetricQueries,
+		})
 
 	return &specificapi.MetricsAPIGroupVersion{
 		DynamicStorage: resourceStorage,
@@ -83,7 +89,7 @@ func (s *CustomMetricsAdapterServer) cmAPI(groupInfo *genericapiserver.APIGroupI
 			Namer:           runtime.Namer(meta.NewAccessor()),
 		},
 
-		ResourceLister: provider.NewCustomMetricResourceLister(s.customMetricsProvider),
+		ResourceLister: s.customMetricsLister(),
 		Handlers:       &specificapi.CMHandlers{},
 	}
 }
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/emicklei/go-restful/v3"
+
+	apidiscoveryv2 "k8s.io/api/apidiscovery/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	genericapi "k8s.io/apiserver/pkg/endpoints"
+	"k8s.io/apiserver/pkg/endpoints/discovery"
+	discoveryendpoint "k8s.io/apiserver/pkg/endpoints/discovery/aggregated"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/klog/v2"
+)
+
+// aggregatedDiscovery keeps the aggregated discovery document served at
+// /apis (apidiscovery.k8s.io/v2) up to date with the metrics listed by the
+// providers.  The document, and its ETag, only change when the listed
+// metrics do, so that clients only fetch it again then.
+type aggregatedDiscovery struct {
+	manager discoveryendpoint.ResourceManager
+
+	lock   sync.Mutex
+	groups []discoveryGroup
+}
+
+// discoveryGroup is a group of the metrics APIs, its versions, and the
+// lister of their resources, which the versions share.
+type discoveryGroup struct {
+	versions []schema.GroupVersion
+	lister   discovery.APIResourceLister
+}
+
+func newAggregatedDiscovery(manager discoveryendpoint.ResourceManager) *aggregatedDiscovery {
+	return &aggregatedDiscovery{manager: manager}
+}
+
+// addGroupVersions adds the versions of a group, by decreasing priority, and
+// the lister of their resources.
+func (d *aggregatedDiscovery) addGroupVersions(versions []schema.GroupVersion, lister discovery.APIResourceLister) {
+	if d.manager == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	group := discoveryGroup{versions: versions, lister: lister}
+	d.groups = append(d.groups, group)
+	for i, groupVersion := range versions {
+		d.manager.SetGroupVersionPriority(metav1.GroupVersion(groupVersion), 1000, len(versions)-i)
+	}
+	d.refreshLocked(group)
+}
+
+// refresh lists the resources of every group again.  The manager only
+// regenerates the document when they changed.
+func (d *aggregatedDiscovery) refresh() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, group := range d.groups {
+		d.refreshLocked(group)
+	}
+}
+
+// refreshLocked lists the resources of a group once, and updates all its
+// versions with them.
+func (d *aggregatedDiscovery) refreshLocked(group discoveryGroup) {
+	resources := group.lister.ListAPIResources()
+	// providers don't necessarily list their metrics in a stable order
+	slices.SortFunc(resources, func(a, b metav1.APIResource) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	discoveryResources, err := genericapi.ConvertGroupVersionIntoToDiscovery(resources)
+	if err != nil {
+		klog.ErrorS(err, "Unable to build the aggregated discovery of the metrics, keeping the previous one", "group", group.versions[0].Group)
+		return
+	}
+	for _, groupVersion := range group.versions {
+		d.manager.AddGroupVersion(groupVersion.Group, apidiscoveryv2.APIVersionDiscovery{
+			Version:   groupVersion.Version,
+			Resources: discoveryResources,
+			Freshness: apidiscoveryv2.DiscoveryFreshnessCurrent,
+		})
+	}
+}
+
+// filter refreshes the aggregated discovery document before serving it.
+// Requests for the unaggregated group list, which doesn't list resources,
+// are served without listing the metrics.
+func (d *aggregatedDiscovery) filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	if strings.TrimSuffix(req.Request.URL.Path, "/") == genericapiserver.APIGroupPrefix &&
+		strings.Contains(req.Request.Header.Get("Accept"), apidiscoveryv2.SchemeGroupVersion.Group) {
+		d.refresh()
+	}
+	chain.ProcessFilter(req, resp)
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apidiscoveryv2 "k8s.io/api/apidiscovery/v2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/fake"
+)
+
+const aggregatedDiscoveryAccept = "application/json;g=apidiscovery.k8s.io;v=v2;as=APIGroupDiscoveryList"
+
+// listingProvider lists the metrics it is given, counts the listings of the
+// custom metrics, and notifies the changes of the metrics.
+type listingProvider struct {
+	provider.MetricsProvider
+
+	lock            sync.Mutex
+	customMetrics   []provider.CustomMetricInfo
+	externalMetrics []provider.ExternalMetricInfo
+	listings        int
+	listeners       []func()
+}
+
+func (p *listingProvider) setMetrics(customMetrics []provider.CustomMetricInfo, externalMetrics ...provider.ExternalMetricInfo) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.customMetrics = customMetrics
+	p.externalMetrics = externalMetrics
+	for _, notify := range p.listeners {
+		notify()
+	}
+}
+
+func (p *listingProvider) OnMetricsChange(notify func()) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.listeners = append(p.listeners, notify)
+}
+
+func (p *listingProvider) ListAllMetrics() []provider.CustomMetricInfo {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.listings++
+	return slices.Clone(p.customMetrics)
+}
+
+func (p *listingProvider) customMetricListings() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.listings
+}
+
+func (p *listingProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return slices.Clone(p.externalMetrics)
+}
+
+// newServer creates a server of the metrics APIs of prov, with the given
+// changes to the default configuration.
+func newServer(t *testing.T, prov provider.MetricsProvider, configure ...func(*Config)) *CustomMetricsAdapterServer {
+	t.Helper()
+	serverConfig := genericapiserver.NewRecommendedConfig(Codecs)
+	serverConfig.LoopbackClientConfig = &rest.Config{}
+	serverConfig.ExternalAddress = "127.0.0.1:443"
+	config := &Config{GenericConfig: &serverConfig.Config}
+	for _, f := range configure {
+		f(config)
+	}
+
+	server, err := config.Complete(nil).New(t.Name(), prov, prov)
+	require.NoError(t, err)
+	return server
+}
+
+// startServer serves the metrics APIs of prov over plain HTTP, like the test
+// server of pkg/apiserver/testing.
+func startServer(t *testing.T, prov provider.MetricsProvider, configure ...func(*Config)) *rest.Config {
+	t.Helper()
+	server := newServer(t, prov, configure...)
+	httpServer := httptest.NewServer(server.GenericAPIServer.Handler)
+	t.Cleanup(httpServer.Close)
+	return &rest.Config{Host: httpServer.URL}
+}
+
+// fetchAggregatedDiscovery fetches the aggregated discovery document, unless
+// it matches etag.
+func fetchAggregatedDiscovery(t *testing.T, config *rest.Config, etag string) (*http.Response, *apidiscoveryv2.APIGroupDiscoveryList) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, config.Host+"/apis", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", aggregatedDiscoveryAccept)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	assert.Equal(t, aggregatedDiscoveryAccept, resp.Header.Get("Content-Type"))
+	list := &apidiscoveryv2.APIGroupDiscoveryList{}
+	require.NoError(t, json.Unmarshal(body, list))
+	return resp, list
+}
+
+// discoveredResources returns the resources of the group versions of the
+// aggregated discovery document, with their subresources.
+func discoveredResources(list *apidiscoveryv2.APIGroupDiscoveryList) map[string][]string {
+	resources := map[string][]string{}
+	for _, group := range list.Items {
+		for _, version := range group.Versions {
+			groupVersion := schema.GroupVersion{Group: group.Name, Version: version.Version}.String()
+			resources[groupVersion] = []string{}
+			for _, resource := range version.Resources {
+				resources[groupVersion] = append(resources[groupVersion], resource.Resource)
+				for _, subresource := range resource.Subresources {
+					resources[groupVersion] = append(resources[groupVersion], resource.Resource+"/"+subresource.Subresource)
+				}
+			}
+		}
+	}
+	return resources
+}
+
+func TestAggregatedDiscovery(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	prov := &listingProvider{MetricsProvider: fake.NewProvider()}
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: pods, Namespaced: true, Metric: "http_requests"},
+		{GroupResource: deployments, Namespaced: true, Metric: "replicas"},
+	}, provider.ExternalMetricInfo{Metric: "queue_length"})
+	config := startServer(t, prov)
+
+	resp, list := fetchAggregatedDiscovery(t, config, "")
+	require.NotNil(t, list)
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, map[string][]string{
+		"custom.metrics.k8s.io/v1beta2":   {"deployments.apps", "deployments.apps/replicas", "pods", "pods/http_requests"},
+		"custom.metrics.k8s.io/v1beta1":   {"deployments.apps", "deployments.apps/replicas", "pods", "pods/http_requests"},
+		"external.metrics.k8s.io/v1beta1": {"queue_length"},
+	}, discoveredResources(list))
+	for _, group := range list.Items {
+		if group.Name == "custom.metrics.k8s.io" {
+			assert.Equal(t, "v1beta1", group.Versions[0].Version, "the preferred version should come first")
+		}
+	}
+
+	// unchanged metrics, even listed in another order, keep the document
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: deployments, Namespaced: true, Metric: "replicas"},
+		{GroupResource: pods, Namespaced: true, Metric: "http_requests"},
+	}, provider.ExternalMetricInfo{Metric: "queue_length"})
+	resp, _ = fetchAggregatedDiscovery(t, config, etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	// new metrics are discovered
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: pods, Namespaced: true, Metric: "http_requests"},
+	}, provider.ExternalMetricInfo{Metric: "queue_length"}, provider.ExternalMetricInfo{Metric: "queue_age"})
+	resp, list = fetchAggregatedDiscovery(t, config, etag)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+	assert.Equal(t, map[string][]string{
+		"custom.metrics.k8s.io/v1beta2":   {"pods", "pods/http_requests"},
+		"custom.metrics.k8s.io/v1beta1":   {"pods", "pods/http_requests"},
+		"external.metrics.k8s.io/v1beta1": {"queue_age", "queue_length"},
+	}, discoveredResources(list))
+}
+
+func TestAggregatedDiscoveryClient(t *testing.T) {
+	prov := &listingProvider{MetricsProvider: fake.NewProvider()}
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"},
+		{GroupResource: schema.GroupResource{Resource: "nodes"}, Metric: "temperature"},
+	}, provider.ExternalMetricInfo{Metric: "queue_length"})
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(startServer(t, prov))
+	require.NoError(t, err)
+
+	_, resourceLists, err := discoveryClient.ServerGroupsAndResources()
+	require.NoError(t, err)
+	resources := map[string]map[string]bool{}
+	for _, resourceList := range resourceLists {
+		resources[resourceList.GroupVersion] = map[string]bool{}
+		for _, resource := range resourceList.APIResources {
+			resources[resourceList.GroupVersion][resource.Name] = resource.Namespaced
+		}
+	}
+	// the parents of the metrics only exist in the aggregated document, which
+	// clients convert back to the unaggregated resources
+	assert.Equal(t, map[string]bool{"pods/http_requests": true, "nodes/temperature": false}, resources["custom.metrics.k8s.io/v1beta2"])
+	assert.Equal(t, map[string]bool{"queue_length": true}, resources["external.metrics.k8s.io/v1beta1"])
+}
+
+func TestAggregatedDiscoveryListings(t *testing.T) {
+	prov := &listingProvider{MetricsProvider: fake.NewProvider()}
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"},
+	})
+	config := startServer(t, prov)
+
+	listings := prov.customMetricListings()
+	_, list := fetchAggregatedDiscovery(t, config, "")
+	require.NotNil(t, list)
+	assert.Equal(t, listings+1, prov.customMetricListings(), "both custom metrics versions should be refreshed with a single listing")
+
+	resp, err := http.Get(config.Host + "/apis")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, listings+1, prov.customMetricListings(), "the unaggregated group list shouldn't list the metrics")
+}
@@ -27,7 +27,7 @@ import (
 	"k8s.io/metrics/pkg/apis/external_metrics"
 
 	specificapi "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/installer"
-	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
 	metricstorage "sigs.k8s.io/custom-metrics-apiserver/pkg/registry/external_metrics"
 )
 
@@ -57,12 +57,18 @@ func (s *CustomMetricsAdapterServer) InstallExternalMetricsAPI() error {
 
 	s.GenericAPIServer.DiscoveryGroupManager.AddGroup(apiGroup)
 	s.GenericAPIServer.Handler.GoRestfulContainer.Add(discovery.NewAPIGroupHandler(s.GenericAPIServer.Serializer, apiGroup).WebService())
+	s.aggregatedDiscovery.addGroupVersions([]schema.GroupVersion{mainGroupVer}, s.externalMetricsLister())
 
 	return nil
 }
 
 func (s *CustomMetricsAdapterServer) emAPI(groupInfo *genericapiserver.APIGroupInfo, groupVersion schema.GroupVersion) *specificapi.MetricsAPIGroupVersion {
-	resourceStorage := metricstorage.NewREST(s.externalMetricsProvider)
+	resourceStorage := metricstorage.NewREST(s.externalMetricsProvider).
+		WithStalenessPolicy(s.stalenessPolicy).
+		WithProviderObserver(metrics.ProviderObservers{
+			metrics.NewProviderObserver(external_metrics.GroupName, s.metricLabelGuard),
+			s.externalMetricQueries,
+		})
 
 	return &specificapi.MetricsAPIGroupVersion{
 		DynamicStorage: resourceStorage,
@@ -79,7 +85,7 @@ func (s *CustomMetricsAdapterServer) emAPI(groupInfo *genericapiserver.APIGroupI
 			Typer:           groupInfo.Scheme,
 			Namer:           runtime.Namer(meta.NewAccessor()),
 		},
-		ResourceLister: provider.NewExternalMetricResourceLister(s.externalMetricsProvider),
+		ResourceLister: s.externalMetricsLister(),
 		Handlers:       &specificapi.EMHandlers{},
 	}
 }
@@ -22,28 +22,36 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metainternalversionscheme "k8s.io/apimachinery/pkg/apis/meta/internalversion/scheme"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apiserver/pkg/endpoints/handlers"
 	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
-	utiltrace "k8s.io/utils/trace"
+	"k8s.io/component-base/tracing"
 
 	cm_rest "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/registry/rest"
 )
 
 func ListResourceWithOptions(r cm_rest.ListerWithOptions, scope handlers.RequestScope) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
 		// For performance tracking purposes.
-		trace := utiltrace.New("List " + req.URL.Path)
+		ctx, span := tracing.Start(ctx, "List", attribute.String("url.path", req.URL.Path))
+		// Log only long List requests (ignore Watch).
+		defer span.End(500 * time.Millisecond)
+		req = req.WithContext(ctx)
 
 		requestInfo, ok := request.RequestInfoFrom(req.Context())
 		if !ok {
 			err := errors.NewBadRequest("missing requestInfo")
+			span.RecordError(err)
 			writeError(&scope, err, w, req)
 			return
 		}
@@ -60,12 +68,14 @@ func ListResourceWithOptions(r cm_rest.ListerWithOptions, scope handlers.Request
 		// handle invalid requests, e.g. /namespaces/name/foo
 		if len(requestInfo.Parts) < 3 {
 			err := errors.NewBadRequest("invalid request path")
+			span.RecordError(err)
 			writeError(&scope, err, w, req)
 			return
 		}
 
 		namespace, err := scope.Namer.Namespace(req)
 		if err != nil {
+			span.RecordError(err)
 			writeError(&scope, err, w, req)
 			return
 		}
@@ -78,12 +88,12 @@ func ListResourceWithOptions(r cm_rest.ListerWithOptions, scope handlers.Request
 			hasName = false
 		}
 
-		ctx := req.Context()
 		ctx = request.WithNamespace(ctx, namespace)
 
 		opts := metainternalversion.ListOptions{}
 		if err := metainternalversionscheme.ParameterCodec.DecodeParameters(req.URL.Query(), scope.MetaGroupVersion, &opts); err != nil {
 			err = errors.NewBadRequest(err.Error())
+			span.RecordError(err)
 			writeError(&scope, err, w, req)
 			return
 		}
@@ -97,6 +107,7 @@ func ListResourceWithOptions(r cm_rest.ListerWithOptions, scope handlers.Request
 			if opts.FieldSelector, err = opts.FieldSelector.Transform(fn); err != nil {
 				// TODO: allow bad request to set field causes based on query parameters
 				err = errors.NewBadRequest(err.Error())
+				span.RecordError(err)
 				writeError(&scope, err, w, req)
 				return
 			}
@@ -111,39 +122,55 @@ func ListResourceWithOptions(r cm_rest.ListerWithOptions, scope handlers.Request
 			// Note that fieldSelector setting explicitly the "metadata.name"
 			// will result in reaching this branch (as the value of that field
 			// is propagated to requestInfo as the name parameter.
-			// That said, the allowed field selectors in this branch are:
-			// nil, fields.Everything and field selector matching metadata.name
-			// for our name.
+			// Other fields are passed through to the storage, which filters
+			// the metric values with them, so a field selector is allowed as
+			// long as it doesn't select a different name.
 			if opts.FieldSelector != nil && !opts.FieldSelector.Empty() {
-				selectedName, ok := opts.FieldSelector.RequiresExactMatch("metadata.name")
-				if !ok || name != selectedName {
-					writeError(&scope, errors.NewBadRequest("fieldSelector metadata.name doesn't match requested name"), w, req)
+				if !selectsName(opts.FieldSelector, name) {
+					err := errors.NewBadRequest("fieldSelector metadata.name doesn't match requested name")
+					span.RecordError(err)
+					writeError(&scope, err, w, req)
 					return
 				}
+				opts.FieldSelector = fields.AndSelectors(nameSelector, opts.FieldSelector)
 			} else {
 				opts.FieldSelector = nameSelector
 			}
 		}
 
-		// Log only long List requests (ignore Watch).
-		defer trace.LogIfLong(500 * time.Millisecond)
-		trace.Step("About to List from storage")
+		span.AddEvent("About to List from storage")
 		extraOpts, hasSubpath, subpathKey := r.NewListOptions()
 		if err := getRequestOptions(req, scope, extraOpts, hasSubpath, subpathKey, false); err != nil {
 			err = errors.NewBadRequest(err.Error())
+			span.RecordError(err)
 			writeError(&scope, err, w, req)
 			return
 		}
 		result, err := r.List(ctx, &opts, extraOpts)
 		if err != nil {
+			span.RecordError(err)
 			writeError(&scope, err, w, req)
 			return
 		}
-		trace.Step("Listing from storage done")
+		span.AddEvent("Listing from storage done")
 
 		responsewriters.WriteObjectNegotiated(scope.Serializer, negotiation.DefaultEndpointRestrictions, scope.Kind.GroupVersion(), w, req, http.StatusOK, result, false)
-		trace.Step("Writing http response done")
+		span.AddEvent("Writing http response done")
+	}
+}
+
+// selectsName checks that any requirement on metadata.name in the given
+// field selector is an exact match for the given name.
+func selectsName(selector fields.Selector, name string) bool {
+	for _, req := range selector.Requirements() {
+		if req.Field != "metadata.name" {
+			continue
+		}
+		if req.Operator == selection.NotEquals || req.Value != name {
+			return false
+		}
 	}
+	return true
 }
 
 // getRequestOptions parses out options and can include path information.  The path information shouldn't include the subresource.
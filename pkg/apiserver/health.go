@@ -0,0 +1,142 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// DefaultHealthCheckCacheDuration is how long a successful provider health
+// check is remembered for when Config.HealthCheckCacheDuration is not set.
+const DefaultHealthCheckCacheDuration = 10 * time.Second
+
+// healthCheckFailureCacheRatio is the fraction of the cache duration for which
+// failed checks are remembered: failures are cached too, so that probes don't
+// pile up on a backend which is down, but for a shorter time, so that the
+// server gets ready soon after it recovers.
+const healthCheckFailureCacheRatio = 10
+
+// providerHealthChecker adapts a provider.HealthCheckingProvider to a
+// healthz.HealthChecker.  The results of the checks are cached for a while,
+// so that frequent probes don't translate into as many requests to the backend.
+type providerHealthChecker struct {
+	name          string
+	provider      provider.HealthCheckingProvider
+	cacheDuration time.Duration
+	clock         clock.PassiveClock
+
+	// mu serializes the checks, so that concurrent probes share the result
+	// of a single call to the provider.
+	mu        sync.Mutex
+	lastCheck time.Time
+	lastErr   error
+}
+
+var _ healthz.HealthChecker = &providerHealthChecker{}
+
+func newProviderHealthChecker(name string, prov provider.HealthCheckingProvider, cacheDuration time.Duration) *providerHealthChecker {
+	if cacheDuration == 0 {
+		cacheDuration = DefaultHealthCheckCacheDuration
+	}
+	return &providerHealthChecker{
+		name:          name,
+		provider:      prov,
+		cacheDuration: cacheDuration,
+		clock:         clock.RealClock{},
+	}
+}
+
+func (c *providerHealthChecker) Name() string {
+	return c.name
+}
+
+func (c *providerHealthChecker) Check(req *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cacheDuration := c.cacheDuration
+	if c.lastErr != nil {
+		cacheDuration /= healthCheckFailureCacheRatio
+	}
+	if !c.lastCheck.IsZero() && c.clock.Since(c.lastCheck) < cacheDuration {
+		return c.lastErr
+	}
+
+	c.lastErr = nil
+	if err := c.provider.HealthCheck(req.Context()); err != nil {
+		c.lastErr = fmt.Errorf("metrics provider is not healthy: %v", err)
+	}
+	c.lastCheck = c.clock.Now()
+	return c.lastErr
+}
+
+// providerHealthCheckers returns the readiness checks for the given providers
+// which implement provider.HealthCheckingProvider, or wrap a provider
+// implementing it.  A provider serving both
+// APIs is only checked once.
+func providerHealthCheckers(cacheDuration time.Duration, customMetricsProvider provider.CustomMetricsProvider, externalMetricsProvider provider.ExternalMetricsProvider) []healthz.HealthChecker {
+	if prov, ok := provider.As[provider.HealthCheckingProvider](customMetricsProvider); ok && provider.SameProvider(customMetricsProvider, externalMetricsProvider) {
+		return []healthz.HealthChecker{newProviderHealthChecker("metrics-provider", prov, cacheDuration)}
+	}
+
+	var checks []healthz.HealthChecker
+	if prov, ok := provider.As[provider.HealthCheckingProvider](customMetricsProvider); ok {
+		checks = append(checks, newProviderHealthChecker("custom-metrics-provider", prov, cacheDuration))
+	}
+	if prov, ok := provider.As[provider.HealthCheckingProvider](externalMetricsProvider); ok {
+		checks = append(checks, newProviderHealthChecker("external-metrics-provider", prov, cacheDuration))
+	}
+	return checks
+}
+
+// listingHealthChecker reports whether the last listing of the metrics of a
+// provider implementing provider.CustomMetricsListerWithError or
+// provider.ExternalMetricsListerWithError failed.  The checks don't list the
+// metrics themselves, so that probes don't hit a failing backend.
+type listingHealthChecker struct {
+	name    string
+	listing interface {
+		Err() error
+		Invalidate()
+	}
+}
+
+var _ healthz.HealthChecker = &listingHealthChecker{}
+
+func (c *listingHealthChecker) Name() string {
+	return c.name
+}
+
+func (c *listingHealthChecker) Check(_ *http.Request) error {
+	err := c.listing.Err()
+	if err == nil {
+		return nil
+	}
+	// the running cache lists the metrics again soon, at most once per tenth
+	// of its interval, so that the server gets ready as soon as the provider
+	// recovers, even when it isn't asked for discovery meanwhile
+	c.listing.Invalidate()
+	return fmt.Errorf("unable to list the metrics of the provider: %v", err)
+}
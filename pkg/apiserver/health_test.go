@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/fake"
+)
+
+type healthCheckingProvider struct {
+	provider.MetricsProvider
+
+	err   error
+	calls int
+}
+
+func (p *healthCheckingProvider) HealthCheck(_ context.Context) error {
+	p.calls++
+	return p.err
+}
+
+func TestProviderHealthCheckerCachesResults(t *testing.T) {
+	prov := &healthCheckingProvider{}
+	clock := clocktesting.NewFakeClock(time.Now())
+	checker := newProviderHealthChecker("custom-metrics-provider", prov, time.Minute)
+	checker.clock = clock
+	req := httptest.NewRequest("GET", "/readyz", nil)
+
+	require.NoError(t, checker.Check(req))
+	require.NoError(t, checker.Check(req))
+	assert.Equal(t, 1, prov.calls, "a recent success should be served from the cache")
+
+	clock.Step(time.Minute)
+	prov.err = errors.New("backend unreachable")
+	assert.Error(t, checker.Check(req), "an expired success should not hide a failure")
+	assert.Error(t, checker.Check(req), "a recent failure should be served from the cache")
+	assert.Equal(t, 2, prov.calls)
+
+	// failures are cached for a shorter time than successes
+	prov.err = nil
+	clock.Step(time.Minute / healthCheckFailureCacheRatio)
+	require.NoError(t, checker.Check(req))
+	assert.Equal(t, 3, prov.calls)
+}
+
+func TestProviderHealthCheckers(t *testing.T) {
+	prov := &healthCheckingProvider{MetricsProvider: fake.NewProvider()}
+	other := &healthCheckingProvider{MetricsProvider: fake.NewProvider()}
+
+	checks := providerHealthCheckers(0, prov, other)
+	require.Len(t, checks, 2)
+	assert.Equal(t, "custom-metrics-provider", checks[0].Name())
+	assert.Equal(t, "external-metrics-provider", checks[1].Name())
+	assert.Equal(t, DefaultHealthCheckCacheDuration, checks[0].(*providerHealthChecker).cacheDuration)
+
+	checks = providerHealthCheckers(0, prov, prov)
+	require.Len(t, checks, 1, "a provider serving both APIs should be checked once")
+	assert.Equal(t, "metrics-provider", checks[0].Name())
+
+	assert.Empty(t, providerHealthCheckers(0, fake.NewProvider(), nil), "providers without health checks should not be checked")
+}
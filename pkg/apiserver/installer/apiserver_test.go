@@ -22,8 +22,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
 	"github.com/emicklei/go-restful/v3"
 
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -37,6 +41,7 @@ import (
 	genericapi "k8s.io/apiserver/pkg/endpoints"
 	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/component-base/tracing"
 	"k8s.io/metrics/pkg/apis/custom_metrics"
 	installcm "k8s.io/metrics/pkg/apis/custom_metrics/install"
 	cmv1beta1 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta1"
@@ -44,6 +49,8 @@ import (
 	installem "k8s.io/metrics/pkg/apis/external_metrics/install"
 	emv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
 
+	cm_tracing "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/tracing"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/tracing/tracingtest"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/defaults"
 	custommetricstorage "sigs.k8s.io/custom-metrics-apiserver/pkg/registry/custom_metrics"
@@ -131,6 +138,10 @@ func apiGroupVersion(gv schema.GroupVersion, groupInfo genericapiserver.APIGroup
 }
 
 func handleCustomMetrics(prov provider.CustomMetricsProvider) http.Handler {
+	return handleCustomMetricsWithTracing(prov, nil)
+}
+
+func handleCustomMetricsWithTracing(prov provider.CustomMetricsProvider, tp tracing.TracerProvider) http.Handler {
 	container := restful.NewContainer()
 	container.Router(restful.CurlyRouter{})
 	mux := container.ServeMux
@@ -147,12 +158,19 @@ func handleCustomMetrics(prov provider.CustomMetricsProvider) http.Handler {
 	}
 
 	var handler http.Handler = &defaultAPIServer{mux, container}
+	if tp != nil {
+		handler = genericapifilters.WithTracing(handler, tp)
+	}
 	reqInfoResolver := genericapiserver.NewRequestInfoResolver(&genericapiserver.Config{})
 	handler = genericapifilters.WithRequestInfo(handler, reqInfoResolver)
 	return handler
 }
 
 func handleExternalMetrics(prov provider.ExternalMetricsProvider) http.Handler {
+	return handleExternalMetricsWithTracing(prov, nil)
+}
+
+func handleExternalMetricsWithTracing(prov provider.ExternalMetricsProvider, tp tracing.TracerProvider) http.Handler {
 	container := restful.NewContainer()
 	container.Router(restful.CurlyRouter{})
 	mux := container.ServeMux
@@ -170,6 +188,9 @@ func handleExternalMetrics(prov provider.ExternalMetricsProvider) http.Handler {
 	}
 
 	var handler http.Handler = &defaultAPIServer{mux, container}
+	if tp != nil {
+		handler = genericapifilters.WithTracing(handler, tp)
+	}
 	reqInfoResolver := genericapiserver.NewRequestInfoResolver(&genericapiserver.Config{})
 	handler = genericapifilters.WithRequestInfo(handler, reqInfoResolver)
 	return handler
@@ -273,6 +294,13 @@ func TestCustomMetricsAPI(t *testing.T) {
 		"GET for label selected pods (namespaced)": {"GET", "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/namespaces/ns/pods/*/some-metric?labelSelector=foo%3Dbar", http.StatusOK, matchingPodsCount},
 		"GET for single node (root)":               {"GET", "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/nodes/foo/some-metric", http.StatusOK, 1},
 		"GET for single pod (namespaced)":          {"GET", "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/namespaces/ns/pods/foo/some-metric", http.StatusOK, 1},
+
+		// Field selectors
+		"GET with unsupported field selector":   {"GET", "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/nodes/*/some-metric?fieldSelector=metadata.namespace%3Dns", http.StatusBadRequest, 0},
+		"GET with invalid field selector value": {"GET", "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/nodes/*/some-metric?fieldSelector=value%3Dabc", http.StatusBadRequest, 0},
+		"GET with invalid field selector range": {"GET", "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/nodes/*/some-metric?fieldSelector=value%3E!%3D1", http.StatusBadRequest, 0},
+		"GET with value field selector":         {"GET", "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/nodes/*/some-metric?fieldSelector=value%3E%3D0", http.StatusOK, totalNodesCount},
+		"GET with name and field selector":      {"GET", "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/namespaces/ns/pods/foo/some-metric?fieldSelector=value%3C%3D0", http.StatusOK, 1},
 	}
 
 	prov := &fakeCMProvider{
@@ -333,8 +361,7 @@ func TestExternalMetricsAPI(t *testing.T) {
 	}
 
 	// "real" fake provider implementation can be used in test, because it doesn't have any dependencies.
-	// Note: this provider has a hardcoded list of external metrics.
-	prov, _ := sampleprovider.NewFakeProvider(nil, nil)
+	prov := newSampleExternalMetricsProvider(t)
 
 	server := httptest.NewServer(handleExternalMetrics(prov))
 	defer server.Close()
@@ -359,6 +386,192 @@ func TestExternalMetricsAPI(t *testing.T) {
 	}
 }
 
+// spanAttribute returns the value of the given attribute, looking at both the
+// span attributes and the attributes of its events.
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	for _, event := range span.Events {
+		for _, attr := range event.Attributes {
+			if attr.Key == key {
+				return attr.Value, true
+			}
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestCustomMetricsAPITracing(t *testing.T) {
+	prov := &fakeCMProvider{
+		rootValues: map[string][]custom_metrics.MetricValue{
+			"nodes/*/some-metric":   make([]custom_metrics.MetricValue, 4),
+			"nodes/foo/some-metric": make([]custom_metrics.MetricValue, 1),
+		},
+	}
+
+	cases := map[string]struct {
+		path       string
+		opSpan     string
+		callSpan   string
+		itemCount  int64
+		attributes map[attribute.Key]string
+	}{
+		"wildcard": {
+			path:      "/nodes/*/some-metric?labelSelector=foo%3Dbar",
+			opSpan:    "CustomMetrics.WildcardOp",
+			callSpan:  "provider.GetMetricBySelector",
+			itemCount: 4,
+			attributes: map[attribute.Key]string{
+				cm_tracing.SelectorKey: "foo=bar",
+			},
+		},
+		"individual": {
+			path:      "/nodes/foo/some-metric",
+			opSpan:    "CustomMetrics.IndividualOp",
+			callSpan:  "provider.GetMetricByName",
+			itemCount: 1,
+			attributes: map[attribute.Key]string{
+				cm_tracing.NameKey: "foo",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tp, exporter := tracingtest.NewInMemoryTracerProvider()
+			server := httptest.NewServer(handleCustomMetricsWithTracing(prov, tp))
+			defer server.Close()
+
+			path := "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + tc.path
+			if _, err := executeRequest(t, name, T{"GET", path, http.StatusOK, 0}, server, http.DefaultClient); err != nil {
+				t.Fatal(err)
+			}
+
+			spans := map[string]tracetest.SpanStub{}
+			for _, span := range exporter.GetSpans() {
+				spans[span.Name] = span
+			}
+
+			for _, spanName := range []string{"List", "CustomMetrics.List", tc.opSpan, tc.callSpan} {
+				if _, ok := spans[spanName]; !ok {
+					t.Fatalf("expected a span named %q, got %v", spanName, spans)
+				}
+			}
+
+			// the spans are nested along the request path
+			for child, parent := range map[string]string{"CustomMetrics.List": "List", tc.opSpan: "CustomMetrics.List", tc.callSpan: tc.opSpan} {
+				if spans[child].Parent.SpanID() != spans[parent].SpanContext.SpanID() {
+					t.Errorf("expected span %q to be a child of %q", child, parent)
+				}
+			}
+
+			list := spans["CustomMetrics.List"]
+			for key, expected := range map[attribute.Key]string{
+				cm_tracing.MetricKey:        "some-metric",
+				cm_tracing.GroupResourceKey: "nodes",
+			} {
+				if value, _ := spanAttribute(list, key); value.AsString() != expected {
+					t.Errorf("expected attribute %s=%q on the list span, got %q", key, expected, value.AsString())
+				}
+			}
+			for key, expected := range tc.attributes {
+				if value, _ := spanAttribute(spans[tc.opSpan], key); value.AsString() != expected {
+					t.Errorf("expected attribute %s=%q on the op span, got %q", key, expected, value.AsString())
+				}
+			}
+			if value, ok := spanAttribute(spans[tc.callSpan], cm_tracing.ItemCountKey); !ok || value.AsInt64() != tc.itemCount {
+				t.Errorf("expected the provider span to record %d items, got %v", tc.itemCount, value.Emit())
+			}
+		})
+	}
+}
+
+func TestCustomMetricsAPITracingBadRequest(t *testing.T) {
+	prov := &fakeCMProvider{}
+	tp, exporter := tracingtest.NewInMemoryTracerProvider()
+	server := httptest.NewServer(handleCustomMetricsWithTracing(prov, tp))
+	defer server.Close()
+
+	path := "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version + "/nodes/foo/some-metric?fieldSelector=metadata.name%3Dbar"
+	if _, err := executeRequest(t, "mismatched name", T{"GET", path, http.StatusBadRequest, 0}, server, http.DefaultClient); err != nil {
+		t.Fatal(err)
+	}
+
+	// the in-memory exporter only receives the spans which were ended
+	var list *tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "List" {
+			list = &span
+		}
+	}
+	if list == nil {
+		t.Fatalf("expected the List span to be ended and exported, got %v", exporter.GetSpans())
+	}
+	if len(list.Events) == 0 || list.Events[0].Name != "exception" {
+		t.Errorf("expected the List span to record the error, got events %v", list.Events)
+	}
+}
+
+// newSampleExternalMetricsProvider returns the provider of the test adapter,
+// with two series of my-external-metric written in the default namespace.
+func newSampleExternalMetricsProvider(t *testing.T) provider.ExternalMetricsProvider {
+	prov, ws := sampleprovider.NewFakeProvider(nil, nil)
+	container := restful.NewContainer()
+	container.Add(ws)
+	for _, labels := range []string{"foo%3Dbar", "foo%3Dbaz"} {
+		req := httptest.NewRequest("POST", "/write-metrics/external/default/my-external-metric?labels="+labels, strings.NewReader(`"42"`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		container.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unable to write the external metrics: %d %s", rec.Code, rec.Body)
+		}
+	}
+	return prov
+}
+
+func TestExternalMetricsAPITracing(t *testing.T) {
+	prov := newSampleExternalMetricsProvider(t)
+	tp, exporter := tracingtest.NewInMemoryTracerProvider()
+	server := httptest.NewServer(handleExternalMetricsWithTracing(prov, tp))
+	defer server.Close()
+
+	path := "/" + prefix + "/" + externalMetricsGroupVersion.Group + "/" + externalMetricsGroupVersion.Version + "/namespaces/default/my-external-metric"
+	if _, err := executeRequest(t, "external", T{"GET", path, http.StatusOK, 0}, server, http.DefaultClient); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	list, ok := spans["ExternalMetrics.List"]
+	if !ok {
+		t.Fatalf("expected an ExternalMetrics.List span, got %v", spans)
+	}
+	if value, _ := spanAttribute(list, cm_tracing.MetricKey); value.AsString() != "my-external-metric" {
+		t.Errorf("expected the metric name to be recorded, got %q", value.AsString())
+	}
+	if value, _ := spanAttribute(list, cm_tracing.NamespaceKey); value.AsString() != "default" {
+		t.Errorf("expected the namespace to be recorded, got %q", value.AsString())
+	}
+
+	call, ok := spans["provider.GetExternalMetric"]
+	if !ok {
+		t.Fatalf("expected a provider.GetExternalMetric span, got %v", spans)
+	}
+	if call.Parent.SpanID() != list.SpanContext.SpanID() {
+		t.Errorf("expected the provider span to be a child of the list span")
+	}
+	if value, _ := spanAttribute(call, cm_tracing.ItemCountKey); value.AsInt64() != 2 {
+		t.Errorf("expected the provider span to record 2 items, got %v", value.Emit())
+	}
+}
+
 func executeRequest(t *testing.T, k string, v T, server *httptest.Server, client *http.Client) (*http.Response, error) {
 	request, err := http.NewRequest(v.Method, server.URL+v.Path, nil)
 	if err != nil {
@@ -380,3 +593,91 @@ func executeRequest(t *testing.T, k string, v T, server *httptest.Server, client
 	}
 	return response, nil
 }
+
+func TestProtobufNegotiation(t *testing.T) {
+	cmProv := &fakeCMProvider{
+		namespacedValues: map[string][]custom_metrics.MetricValue{
+			"ns/pods/*/some-metric":   make([]custom_metrics.MetricValue, 3),
+			"ns/pods/foo/some-metric": make([]custom_metrics.MetricValue, 1),
+		},
+	}
+	cmServer := httptest.NewServer(handleCustomMetrics(cmProv))
+	defer cmServer.Close()
+	emServer := httptest.NewServer(handleExternalMetrics(newSampleExternalMetricsProvider(t)))
+	defer emServer.Close()
+
+	cmPath := "/" + prefix + "/" + customMetricsGroupVersion.Group + "/" + customMetricsGroupVersion.Version
+	emPath := "/" + prefix + "/" + externalMetricsGroupVersion.Group + "/" + externalMetricsGroupVersion.Version
+	cases := map[string]struct {
+		server        *httptest.Server
+		path          string
+		accept        string
+		status        int
+		contentType   string
+		expectedCount int
+	}{
+		"custom metrics list": {cmServer, cmPath + "/namespaces/ns/pods/*/some-metric", runtime.ContentTypeProtobuf, http.StatusOK, runtime.ContentTypeProtobuf, 3},
+		"custom metric value": {cmServer, cmPath + "/namespaces/ns/pods/foo/some-metric", runtime.ContentTypeProtobuf, http.StatusOK, runtime.ContentTypeProtobuf, 1},
+		"external metrics":    {emServer, emPath + "/namespaces/default/my-external-metric", runtime.ContentTypeProtobuf, http.StatusOK, runtime.ContentTypeProtobuf, 2},
+		"provider error":      {cmServer, cmPath + "/namespaces/ns/pods/*/other-metric", runtime.ContentTypeProtobuf, http.StatusInternalServerError, runtime.ContentTypeProtobuf, 0},
+		"discovery":           {cmServer, cmPath, runtime.ContentTypeProtobuf, http.StatusOK, runtime.ContentTypeProtobuf, 0},
+		"preferred protobuf":  {emServer, emPath + "/namespaces/default/my-external-metric", runtime.ContentTypeProtobuf + ", " + runtime.ContentTypeJSON, http.StatusOK, runtime.ContentTypeProtobuf, 2},
+		"preferred JSON":      {emServer, emPath + "/namespaces/default/my-external-metric", runtime.ContentTypeJSON + ", " + runtime.ContentTypeProtobuf, http.StatusOK, runtime.ContentTypeJSON, 2},
+	}
+
+	client := http.Client{}
+	for k, v := range cases {
+		request, err := http.NewRequest(http.MethodGet, v.server.URL+v.path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error (%s): %v", k, err)
+		}
+		request.Header.Set("Accept", v.accept)
+		response, err := client.Do(request)
+		if err != nil {
+			t.Errorf("unexpected error (%s): %v", k, err)
+			continue
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			t.Errorf("unexpected error (%s): %v", k, err)
+			continue
+		}
+		if response.StatusCode != v.status {
+			t.Errorf("Expected %d (%s), got %d", v.status, k, response.StatusCode)
+			continue
+		}
+		if contentType := response.Header.Get("Content-Type"); contentType != v.contentType {
+			t.Errorf("Expected content type %s (%s), got %s", v.contentType, k, contentType)
+			continue
+		}
+
+		obj, err := runtime.Decode(Codecs.UniversalDecoder(), body)
+		if err != nil {
+			t.Errorf("unable to decode the response (%s): %v", k, err)
+			continue
+		}
+		count := 0
+		switch obj := obj.(type) {
+		case *custom_metrics.MetricValueList:
+			count = len(obj.Items)
+		case *custom_metrics.MetricValue:
+			count = 1
+		case *external_metrics.ExternalMetricValueList:
+			count = len(obj.Items)
+		case *metav1.Status:
+			if obj.Code != int32(v.status) {
+				t.Errorf("Expected a status of code %d (%s), got %#v", v.status, k, obj)
+			}
+		case *metav1.APIResourceList:
+			if len(obj.APIResources) == 0 {
+				t.Errorf("Expected resources to be listed (%s)", k)
+			}
+		default:
+			t.Errorf("unexpected response object (%s): %T", k, obj)
+		}
+		if count != v.expectedCount {
+			t.Errorf("Expected %d items, got %d (%s)", v.expectedCount, count, k)
+		}
+	}
+}
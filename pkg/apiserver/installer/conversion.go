@@ -21,8 +21,11 @@ import (
 
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	cmv1beta1 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta1"
 	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
 )
 
 func ConvertURLValuesToV1beta1MetricListOptions(in *url.Values, out *cmv1beta1.MetricListOptions, s conversion.Scope) error {
@@ -63,6 +66,13 @@ func ConvertURLValuesToV1beta2MetricListOptions(in *url.Values, out *cmv1beta2.M
 
 // RegisterConversions adds conversion functions to the given scheme.
 func RegisterConversions(s *runtime.Scheme) error {
+	// validate field selectors against the fields supported for custom metrics
+	for _, gv := range []schema.GroupVersion{cmv1beta1.SchemeGroupVersion, cmv1beta2.SchemeGroupVersion} {
+		if err := s.AddFieldLabelConversionFunc(gv.WithKind("MetricValue"), provider.ConvertMetricFieldLabel); err != nil {
+			return err
+		}
+	}
+
 	if err := s.AddConversionFunc((*url.Values)(nil), (*cmv1beta1.MetricListOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return ConvertURLValuesToV1beta1MetricListOptions(a.(*url.Values), b.(*cmv1beta1.MetricListOptions), scope)
 	}); err != nil {
@@ -0,0 +1,318 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inventory describes the metrics served by an adapter, along with
+// the state of the machinery serving them, for debugging.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/dynamicmapper"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// Path is the path adapters serve the inventory at.
+const Path = "/debug/metrics-inventory"
+
+// Inventory lists the metrics served by an adapter.
+type Inventory struct {
+	CustomMetrics   []CustomMetric   `json:"customMetrics"`
+	ExternalMetrics []ExternalMetric `json:"externalMetrics"`
+	// CustomMetricsListingError and ExternalMetricsListingError are the
+	// errors of the last listings of the metrics, if they failed, in which
+	// case the metrics last listed successfully are described.
+	CustomMetricsListingError   string `json:"customMetricsListingError,omitempty"`
+	ExternalMetricsListingError string `json:"externalMetricsListingError,omitempty"`
+	// Caches describes the caches of the providers implementing
+	// provider.CacheStatsProvider.
+	Caches []Cache `json:"caches,omitempty"`
+	// RESTMapper describes the RESTMapper used to normalize the group
+	// resources of the custom metrics, if any.
+	RESTMapper *RESTMapperStatus `json:"restMapper,omitempty"`
+}
+
+// CustomMetric describes a custom metric, as listed by the provider.
+type CustomMetric struct {
+	Metric        string `json:"metric"`
+	GroupResource string `json:"groupResource"`
+	// Namespaced is only known for listed metrics.
+	Namespaced bool `json:"namespaced"`
+	// NormalizedGroupResource is the group resource resolved by the
+	// RESTMapper, or NormalizationError the reason it couldn't be.
+	NormalizedGroupResource string `json:"normalizedGroupResource,omitempty"`
+	NormalizationError      string `json:"normalizationError,omitempty"`
+	// Listed is false for metrics which were queried, but which the
+	// provider doesn't list.
+	Listed  bool         `json:"listed"`
+	Queries *QueryStatus `json:"queries,omitempty"`
+}
+
+// ExternalMetric describes an external metric, as listed by the provider.
+type ExternalMetric struct {
+	Metric string `json:"metric"`
+	// Listed is false for metrics which were queried, but which the
+	// provider doesn't list.
+	Listed  bool         `json:"listed"`
+	Queries *QueryStatus `json:"queries,omitempty"`
+}
+
+// Cache describes a cache of the provider serving an API group.
+type Cache struct {
+	APIGroup string `json:"apiGroup"`
+	provider.CacheStats
+}
+
+// RESTMapperStatus describes a RESTMapper.  Only the state of the
+// dynamicmapper.RegeneratingDiscoveryRESTMapper is known.
+type RESTMapperStatus struct {
+	Type string `json:"type,omitempty"`
+	// Error is the reason the RESTMapper couldn't be constructed.
+	Error string `json:"error,omitempty"`
+	*dynamicmapper.Status
+}
+
+// Listing is a cache of the metrics listed by a provider, such as the
+// provider.ListingCache serving discovery.
+type Listing[T any] interface {
+	provider.CacheStatsProvider
+	// List returns the listed metrics, which are the ones last listed
+	// successfully when the last listing failed.
+	List() []T
+	// Err returns the error of the last listing, if it failed.
+	Err() error
+}
+
+// Collector collects the inventory of the metrics served by an adapter.
+// It is an http.Handler serving the inventory as JSON.
+type Collector struct {
+	// CustomMetrics and ExternalMetrics are the providers of the adapter.
+	// Either may be nil when the adapter doesn't serve the API.
+	CustomMetrics   provider.CustomMetricsProvider
+	ExternalMetrics provider.ExternalMetricsProvider
+	// CustomMetricQueries and ExternalMetricQueries track the queries
+	// made to the providers.  Either may be nil.
+	CustomMetricQueries   *Tracker
+	ExternalMetricQueries *Tracker
+	// CustomMetricsListing and ExternalMetricsListing are the caches of the
+	// metrics listed for discovery, if any.  The listed metrics are
+	// collected from them when set, and from the providers otherwise.
+	CustomMetricsListing   Listing[provider.CustomMetricInfo]
+	ExternalMetricsListing Listing[provider.ExternalMetricInfo]
+	// RESTMapper, if set, is used to normalize the group resources of the
+	// custom metrics.
+	RESTMapper apimeta.RESTMapper
+	// NewRESTMapper, if set, constructs the RESTMapper on the first
+	// collection if it isn't set, so that adapters serving the inventory
+	// don't wait for discovery at startup.  Failed constructions are
+	// retried on the next collection.
+	NewRESTMapper func() (apimeta.RESTMapper, error)
+
+	// restMapperLock serializes the constructions of the RESTMapper.
+	restMapperLock sync.Mutex
+}
+
+// Collect returns the current inventory.
+func (c *Collector) Collect() *Inventory {
+	restMapper, err := c.restMapper()
+	inventory := &Inventory{}
+	inventory.CustomMetrics, inventory.CustomMetricsListingError = c.customMetrics(restMapper)
+	inventory.ExternalMetrics, inventory.ExternalMetricsListingError = c.externalMetrics()
+
+	inventory.Caches = appendCaches(inventory.Caches, custom_metrics.GroupName, c.CustomMetrics, c.CustomMetricsListing)
+	inventory.Caches = appendCaches(inventory.Caches, external_metrics.GroupName, c.ExternalMetrics, c.ExternalMetricsListing)
+
+	switch {
+	case err != nil:
+		inventory.RESTMapper = &RESTMapperStatus{Error: err.Error()}
+	case restMapper != nil:
+		inventory.RESTMapper = &RESTMapperStatus{Type: fmt.Sprintf("%T", restMapper)}
+		if mapper, ok := restMapper.(interface{ Status() dynamicmapper.Status }); ok {
+			status := mapper.Status()
+			inventory.RESTMapper.Status = &status
+		}
+	}
+
+	return inventory
+}
+
+// restMapper returns the RESTMapper, constructing it with NewRESTMapper if
+// it isn't set yet.
+func (c *Collector) restMapper() (apimeta.RESTMapper, error) {
+	c.restMapperLock.Lock()
+	defer c.restMapperLock.Unlock()
+	if c.RESTMapper == nil && c.NewRESTMapper != nil {
+		restMapper, err := c.NewRESTMapper()
+		if err != nil {
+			return nil, err
+		}
+		c.RESTMapper = restMapper
+	}
+	return c.RESTMapper, nil
+}
+
+// appendCaches appends the caches of the given sources of an API group which
+// implement provider.CacheStatsProvider, or wrap a provider implementing it.
+func appendCaches(caches []Cache, apiGroup string, sources ...any) []Cache {
+	for _, source := range sources {
+		stats, ok := provider.As[provider.CacheStatsProvider](source)
+		if !ok {
+			continue
+		}
+		for _, cacheStats := range stats.CacheStats() {
+			caches = append(caches, Cache{APIGroup: apiGroup, CacheStats: cacheStats})
+		}
+	}
+	return caches
+}
+
+// listed returns the metrics of the listing if set, along with the error of
+// its last listing, or the ones listed by the given function otherwise.
+func listed[T any](listing Listing[T], list func() []T) ([]T, string) {
+	if listing == nil {
+		return list(), ""
+	}
+	var listingError string
+	if err := listing.Err(); err != nil {
+		listingError = err.Error()
+	}
+	return listing.List(), listingError
+}
+
+func (c *Collector) customMetrics(restMapper apimeta.RESTMapper) ([]CustomMetric, string) {
+	metrics := []CustomMetric{}
+	if c.CustomMetrics == nil {
+		return metrics, ""
+	}
+
+	infos, listingError := listed(c.CustomMetricsListing, c.CustomMetrics.ListAllMetrics)
+	queried := sets.New[trackerKey]()
+	for _, info := range infos {
+		metric := customMetric(info, restMapper)
+		metric.Listed = true
+		if c.CustomMetricQueries != nil {
+			keys := []trackerKey{{resource: info.GroupResource, metric: info.Metric}}
+			if normalized := schema.ParseGroupResource(metric.NormalizedGroupResource); metric.NormalizedGroupResource != "" && normalized != info.GroupResource {
+				keys = append(keys, trackerKey{resource: normalized, metric: info.Metric})
+			}
+			for _, key := range keys {
+				if metric.Queries == nil {
+					metric.Queries = c.CustomMetricQueries.Status(key.resource, key.metric)
+				}
+				queried.Insert(key)
+			}
+		}
+		metrics = append(metrics, metric)
+	}
+
+	if c.CustomMetricQueries != nil {
+		c.CustomMetricQueries.each(func(resource schema.GroupResource, metricName string, status *QueryStatus) {
+			if queried.Has(trackerKey{resource: resource, metric: metricName}) {
+				return
+			}
+			metric := customMetric(provider.CustomMetricInfo{GroupResource: resource, Metric: metricName}, restMapper)
+			metric.Queries = status
+			metrics = append(metrics, metric)
+		})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].GroupResource != metrics[j].GroupResource {
+			return metrics[i].GroupResource < metrics[j].GroupResource
+		}
+		if metrics[i].Metric != metrics[j].Metric {
+			return metrics[i].Metric < metrics[j].Metric
+		}
+		return !metrics[i].Namespaced && metrics[j].Namespaced
+	})
+	return metrics, listingError
+}
+
+func customMetric(info provider.CustomMetricInfo, restMapper apimeta.RESTMapper) CustomMetric {
+	metric := CustomMetric{
+		Metric:        info.Metric,
+		GroupResource: info.GroupResource.String(),
+		Namespaced:    info.Namespaced,
+	}
+	if restMapper != nil {
+		normalized, _, err := info.Normalized(restMapper)
+		if err != nil {
+			metric.NormalizationError = err.Error()
+		} else {
+			metric.NormalizedGroupResource = normalized.GroupResource.String()
+		}
+	}
+	return metric
+}
+
+func (c *Collector) externalMetrics() ([]ExternalMetric, string) {
+	metrics := []ExternalMetric{}
+	if c.ExternalMetrics == nil {
+		return metrics, ""
+	}
+
+	infos, listingError := listed(c.ExternalMetricsListing, c.ExternalMetrics.ListAllExternalMetrics)
+	listedMetrics := sets.New[string]()
+	for _, info := range infos {
+		metric := ExternalMetric{Metric: info.Metric, Listed: true}
+		if c.ExternalMetricQueries != nil {
+			metric.Queries = c.ExternalMetricQueries.Status(schema.GroupResource{}, info.Metric)
+		}
+		listedMetrics.Insert(info.Metric)
+		metrics = append(metrics, metric)
+	}
+
+	if c.ExternalMetricQueries != nil {
+		c.ExternalMetricQueries.each(func(_ schema.GroupResource, metricName string, status *QueryStatus) {
+			if !listedMetrics.Has(metricName) {
+				metrics = append(metrics, ExternalMetric{Metric: metricName, Queries: status})
+			}
+		})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Metric < metrics[j].Metric
+	})
+	return metrics, listingError
+}
+
+// ServeHTTP serves the current inventory as JSON.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, private")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c.Collect()); err != nil {
+		klog.ErrorS(err, "Unable to write the metrics inventory")
+	}
+}
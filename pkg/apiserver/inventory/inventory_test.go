@@ -0,0 +1,231 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/fake"
+)
+
+type listingProvider struct {
+	provider.MetricsProvider
+	customMetrics   []provider.CustomMetricInfo
+	externalMetrics []provider.ExternalMetricInfo
+	cacheStats      []provider.CacheStats
+}
+
+func (p *listingProvider) ListAllMetrics() []provider.CustomMetricInfo {
+	return p.customMetrics
+}
+
+func (p *listingProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	return p.externalMetrics
+}
+
+func (p *listingProvider) CacheStats() []provider.CacheStats {
+	return p.cacheStats
+}
+
+func restMapper() apimeta.RESTMapper {
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), apimeta.RESTScopeNamespace)
+	return mapper
+}
+
+func testCollector() *Collector {
+	prov := &listingProvider{
+		MetricsProvider: fake.NewProvider(),
+		customMetrics: []provider.CustomMetricInfo{
+			{GroupResource: schema.GroupResource{Resource: "pod"}, Namespaced: true, Metric: "cpu"},
+			{GroupResource: schema.GroupResource{Group: "wardle", Resource: "flunders"}, Namespaced: true, Metric: "size"},
+		},
+		externalMetrics: []provider.ExternalMetricInfo{{Metric: "queue_length"}},
+		cacheStats:      []provider.CacheStats{{Name: "series", Entries: 3, Hits: 5, Misses: 1}},
+	}
+	return &Collector{
+		CustomMetrics:         prov,
+		ExternalMetrics:       prov,
+		CustomMetricQueries:   NewTracker(0),
+		ExternalMetricQueries: NewTracker(0),
+		RESTMapper:            restMapper(),
+	}
+}
+
+func TestCollect(t *testing.T) {
+	collector := testCollector()
+	failure := errors.New("backend is down")
+	// queries use the resource given in the request, which may differ
+	// from the listed one until normalized
+	collector.CustomMetricQueries.ObserveRequest(schema.GroupResource{Resource: "pods"}, "cpu", time.Second, 1, nil)
+	collector.CustomMetricQueries.ObserveRequest(schema.GroupResource{Resource: "pods"}, "memory", time.Second, 0, failure)
+	collector.ExternalMetricQueries.ObserveRequest(schema.GroupResource{}, "queue_length", time.Second, 1, nil)
+	collector.ExternalMetricQueries.ObserveRequest(schema.GroupResource{}, "queue_age", time.Second, 0, failure)
+
+	inventory := collector.Collect()
+
+	require.Len(t, inventory.CustomMetrics, 3)
+	cpu := inventory.CustomMetrics[1]
+	assert.Equal(t, "cpu", cpu.Metric)
+	assert.Equal(t, "pod", cpu.GroupResource)
+	assert.Equal(t, "pods", cpu.NormalizedGroupResource)
+	assert.True(t, cpu.Listed)
+	assert.True(t, cpu.Namespaced)
+	if assert.NotNil(t, cpu.Queries) {
+		assert.Equal(t, int64(1), cpu.Queries.Queries)
+	}
+
+	memory := inventory.CustomMetrics[2]
+	assert.Equal(t, "memory", memory.Metric)
+	assert.Equal(t, "pods", memory.GroupResource)
+	assert.False(t, memory.Listed)
+	if assert.NotNil(t, memory.Queries) {
+		assert.Equal(t, "backend is down", memory.Queries.LastError)
+	}
+
+	size := inventory.CustomMetrics[0]
+	assert.Equal(t, "flunders.wardle", size.GroupResource)
+	assert.Empty(t, size.NormalizedGroupResource)
+	assert.NotEmpty(t, size.NormalizationError)
+	assert.Nil(t, size.Queries)
+
+	require.Len(t, inventory.ExternalMetrics, 2)
+	assert.Equal(t, "queue_age", inventory.ExternalMetrics[0].Metric)
+	assert.False(t, inventory.ExternalMetrics[0].Listed)
+	assert.Equal(t, "queue_length", inventory.ExternalMetrics[1].Metric)
+	assert.True(t, inventory.ExternalMetrics[1].Listed)
+	assert.NotNil(t, inventory.ExternalMetrics[1].Queries)
+
+	assert.Equal(t, []Cache{
+		{APIGroup: "custom.metrics.k8s.io", CacheStats: provider.CacheStats{Name: "series", Entries: 3, Hits: 5, Misses: 1}},
+		{APIGroup: "external.metrics.k8s.io", CacheStats: provider.CacheStats{Name: "series", Entries: 3, Hits: 5, Misses: 1}},
+	}, inventory.Caches)
+
+	if assert.NotNil(t, inventory.RESTMapper) {
+		assert.Equal(t, "*meta.DefaultRESTMapper", inventory.RESTMapper.Type)
+		assert.Nil(t, inventory.RESTMapper.Status)
+	}
+}
+
+// failedListing is a listing whose last listing failed.
+type failedListing[T any] struct {
+	metrics []T
+	err     error
+}
+
+func (l *failedListing[T]) List() []T {
+	return l.metrics
+}
+
+func (l *failedListing[T]) Err() error {
+	return l.err
+}
+
+func (l *failedListing[T]) CacheStats() []provider.CacheStats {
+	return nil
+}
+
+func TestCollectFromListings(t *testing.T) {
+	collector := testCollector()
+	collector.CustomMetricsListing = &failedListing[provider.CustomMetricInfo]{
+		metrics: []provider.CustomMetricInfo{{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "cpu"}},
+		err:     errors.New("backend is down"),
+	}
+	collector.ExternalMetricsListing = &failedListing[provider.ExternalMetricInfo]{}
+
+	inventory := collector.Collect()
+
+	require.Len(t, inventory.CustomMetrics, 1)
+	assert.Equal(t, "cpu", inventory.CustomMetrics[0].Metric)
+	assert.True(t, inventory.CustomMetrics[0].Listed)
+	assert.Equal(t, "backend is down", inventory.CustomMetricsListingError)
+	assert.Empty(t, inventory.ExternalMetrics)
+	assert.Empty(t, inventory.ExternalMetricsListingError)
+}
+
+func TestCollectWithLazyRESTMapper(t *testing.T) {
+	collector := testCollector()
+	collector.RESTMapper = nil
+	constructions := 0
+	collector.NewRESTMapper = func() (apimeta.RESTMapper, error) {
+		constructions++
+		if constructions == 1 {
+			return nil, errors.New("discovery failed")
+		}
+		return restMapper(), nil
+	}
+
+	inventory := collector.Collect()
+	if assert.NotNil(t, inventory.RESTMapper) {
+		assert.Equal(t, "discovery failed", inventory.RESTMapper.Error)
+	}
+	assert.Empty(t, inventory.CustomMetrics[1].NormalizedGroupResource)
+
+	inventory = collector.Collect()
+	if assert.NotNil(t, inventory.RESTMapper) {
+		assert.Equal(t, "*meta.DefaultRESTMapper", inventory.RESTMapper.Type)
+		assert.Empty(t, inventory.RESTMapper.Error)
+	}
+	assert.Equal(t, "pods", inventory.CustomMetrics[1].NormalizedGroupResource)
+
+	collector.Collect()
+	assert.Equal(t, 2, constructions, "the RESTMapper should only be constructed until it succeeds")
+}
+
+func TestCollectWithoutProviders(t *testing.T) {
+	inventory := (&Collector{}).Collect()
+
+	assert.Empty(t, inventory.CustomMetrics)
+	assert.Empty(t, inventory.ExternalMetrics)
+	assert.Empty(t, inventory.Caches)
+	assert.Nil(t, inventory.RESTMapper)
+}
+
+func TestServeHTTP(t *testing.T) {
+	server := httptest.NewServer(testCollector())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var inventory map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&inventory))
+	assert.Len(t, inventory["customMetrics"], 2)
+	assert.Len(t, inventory["externalMetrics"], 1)
+	assert.Contains(t, inventory, "restMapper")
+
+	resp, err = http.Post(server.URL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
+)
+
+// DefaultMaxTrackedMetrics is the number of distinct metrics a Tracker
+// remembers the queries of when no limit is configured.
+const DefaultMaxTrackedMetrics = 1000
+
+// QueryStatus describes the queries made to a provider for a single metric.
+type QueryStatus struct {
+	// Queries and Errors count the calls made to the provider, and the
+	// ones which failed.
+	Queries int64 `json:"queries"`
+	Errors  int64 `json:"errors"`
+	// LastQueryTime is the time at which the provider was last called.
+	LastQueryTime time.Time `json:"lastQueryTime,omitzero"`
+	// LastErrorTime and LastError describe the last failed call.
+	LastErrorTime time.Time `json:"lastErrorTime,omitzero"`
+	LastError     string    `json:"lastError,omitempty"`
+	// LastValueTimestamp is the most recent timestamp of the values
+	// returned by the provider.
+	LastValueTimestamp time.Time `json:"lastValueTimestamp,omitzero"`
+}
+
+type trackerKey struct {
+	resource schema.GroupResource
+	metric   string
+}
+
+// Tracker is a metrics.ProviderObserver remembering the last queries made
+// to a provider for each metric.  It tracks a bounded number of metrics, so
+// that arbitrary metric names in requests can't grow it indefinitely.
+type Tracker struct {
+	limit int
+	clock clock.PassiveClock
+
+	lock     sync.Mutex
+	statuses map[trackerKey]*QueryStatus
+}
+
+var _ metrics.ProviderObserver = &Tracker{}
+
+// NewTracker creates a Tracker remembering up to limit metrics (a limit
+// of zero or less uses DefaultMaxTrackedMetrics).
+func NewTracker(limit int) *Tracker {
+	if limit <= 0 {
+		limit = DefaultMaxTrackedMetrics
+	}
+	return &Tracker{
+		limit:    limit,
+		clock:    clock.RealClock{},
+		statuses: make(map[trackerKey]*QueryStatus),
+	}
+}
+
+// status returns the status of the given metric, or nil if the tracker is
+// full.  It must be called with the lock held.
+func (t *Tracker) status(resource schema.GroupResource, metric string) *QueryStatus {
+	key := trackerKey{resource: resource, metric: metric}
+	status, ok := t.statuses[key]
+	if !ok {
+		if len(t.statuses) >= t.limit {
+			return nil
+		}
+		status = &QueryStatus{}
+		t.statuses[key] = status
+	}
+	return status
+}
+
+func (t *Tracker) ObserveRequest(resource schema.GroupResource, metric string, _ time.Duration, _ int, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	status := t.status(resource, metric)
+	if status == nil {
+		return
+	}
+	now := t.clock.Now()
+	status.Queries++
+	status.LastQueryTime = now
+	if err != nil {
+		status.Errors++
+		status.LastErrorTime = now
+		status.LastError = err.Error()
+	}
+}
+
+func (t *Tracker) ObserveFreshness(resource schema.GroupResource, metric string, timestamp metav1.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	status := t.status(resource, metric)
+	if status != nil && timestamp.After(status.LastValueTimestamp) {
+		status.LastValueTimestamp = timestamp.Time
+	}
+}
+
+// Status returns a copy of the status of the given metric, or nil if it
+// was never queried.
+func (t *Tracker) Status(resource schema.GroupResource, metric string) *QueryStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	status, ok := t.statuses[trackerKey{resource: resource, metric: metric}]
+	if !ok {
+		return nil
+	}
+	statusCopy := *status
+	return &statusCopy
+}
+
+// each calls fn with a copy of the status of every queried metric.
+func (t *Tracker) each(fn func(resource schema.GroupResource, metric string, status *QueryStatus)) {
+	t.lock.Lock()
+	statuses := make(map[trackerKey]QueryStatus, len(t.statuses))
+	for key, status := range t.statuses {
+		statuses[key] = *status
+	}
+	t.lock.Unlock()
+
+	for key, status := range statuses {
+		fn(key.resource, key.metric, &status)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestTracker(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := clocktesting.NewFakePassiveClock(now)
+	tracker := NewTracker(2)
+	tracker.clock = clock
+
+	assert.Nil(t, tracker.Status(pods, "cpu"))
+
+	tracker.ObserveRequest(pods, "cpu", time.Second, 2, nil)
+	tracker.ObserveFreshness(pods, "cpu", metav1.NewTime(now.Add(-time.Minute)))
+	tracker.ObserveFreshness(pods, "cpu", metav1.NewTime(now.Add(-2*time.Minute)))
+	clock.SetTime(now.Add(time.Second))
+	tracker.ObserveRequest(pods, "cpu", time.Second, 0, errors.New("backend is down"))
+
+	assert.Equal(t, &QueryStatus{
+		Queries:            2,
+		Errors:             1,
+		LastQueryTime:      now.Add(time.Second),
+		LastErrorTime:      now.Add(time.Second),
+		LastError:          "backend is down",
+		LastValueTimestamp: now.Add(-time.Minute),
+	}, tracker.Status(pods, "cpu"))
+
+	// only the first metrics are tracked once the limit is reached
+	tracker.ObserveRequest(pods, "memory", time.Second, 1, nil)
+	tracker.ObserveRequest(pods, "network", time.Second, 1, nil)
+	assert.NotNil(t, tracker.Status(pods, "memory"))
+	assert.Nil(t, tracker.Status(pods, "network"))
+	assert.Equal(t, int64(2), tracker.Status(pods, "cpu").Queries)
+}
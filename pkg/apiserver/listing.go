@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"time"
+
+	"k8s.io/apiserver/pkg/endpoints/discovery"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// listMetrics sets up the listings of the metrics of the providers served by
+// discovery.  The metrics last listed successfully are served when listing
+// them fails, which makes the server unready if the innermost provider
+// reports listing errors: wrappers observing the listings, such as the
+// recording provider, implement the listers with errors whether the
+// providers they wrap do or not.  When interval is positive,
+// the listed metrics are cached, and refreshed every interval once the
+// server is started, and whenever the providers implementing
+// provider.MetricsChangeNotifier notify a change.
+func (s *CustomMetricsAdapterServer) listMetrics(interval time.Duration) error {
+	var checks []healthz.HealthChecker
+	if s.customMetricsProvider != nil {
+		s.customMetricsListing = provider.NewCustomMetricsListingCache(s.customMetricsProvider, interval).
+			WithObserver(metrics.NewListingObserver(custom_metrics.GroupName).ObserveListing)
+		if _, ok := provider.As[provider.CustomMetricsListerWithError](provider.Innermost(s.customMetricsProvider)); ok {
+			checks = append(checks, &listingHealthChecker{name: "custom-metrics-listing", listing: s.customMetricsListing})
+		}
+		if notifier, ok := provider.As[provider.MetricsChangeNotifier](s.customMetricsProvider); ok && interval > 0 {
+			notifier.OnMetricsChange(s.customMetricsListing.Invalidate)
+		}
+	}
+	if s.externalMetricsProvider != nil {
+		s.externalMetricsListing = provider.NewExternalMetricsListingCache(s.externalMetricsProvider, interval).
+			WithObserver(metrics.NewListingObserver(external_metrics.GroupName).ObserveListing)
+		if _, ok := provider.As[provider.ExternalMetricsListerWithError](provider.Innermost(s.externalMetricsProvider)); ok {
+			checks = append(checks, &listingHealthChecker{name: "external-metrics-listing", listing: s.externalMetricsListing})
+		}
+		if notifier, ok := provider.As[provider.MetricsChangeNotifier](s.externalMetricsProvider); ok && interval > 0 {
+			notifier.OnMetricsChange(s.externalMetricsListing.Invalidate)
+		}
+	}
+
+	if err := s.GenericAPIServer.AddReadyzChecks(checks...); err != nil {
+		return err
+	}
+	if interval == 0 {
+		return nil
+	}
+
+	return s.GenericAPIServer.AddPostStartHook("metrics-listing-caches", func(ctx genericapiserver.PostStartHookContext) error {
+		if s.customMetricsListing != nil {
+			go s.customMetricsListing.Run(ctx)
+		}
+		if s.externalMetricsListing != nil {
+			go s.externalMetricsListing.Run(ctx)
+		}
+		return nil
+	})
+}
+
+// customMetricsLister returns the lister of the custom metrics resources.
+func (s *CustomMetricsAdapterServer) customMetricsLister() discovery.APIResourceLister {
+	metadata, _ := provider.As[provider.MetricMetadataProvider](s.customMetricsProvider)
+	return provider.NewCachedCustomMetricResourceLister(s.customMetricsListing, metadata)
+}
+
+// externalMetricsLister returns the lister of the external metrics
+// resources.
+func (s *CustomMetricsAdapterServer) externalMetricsLister() discovery.APIResourceLister {
+	metadata, _ := provider.As[provider.MetricMetadataProvider](s.externalMetricsProvider)
+	return provider.NewCachedExternalMetricResourceLister(s.externalMetricsListing, metadata)
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metadata"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/fake"
+)
+
+func TestMetricsListingCache(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	prov := &listingProvider{MetricsProvider: fake.NewProvider()}
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: pods, Namespaced: true, Metric: "http_requests"},
+	})
+	config := startServer(t, prov, func(c *Config) {
+		c.MetricsListingInterval = time.Hour
+	})
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	require.NoError(t, err)
+	discoveryClient.UseLegacyDiscovery = true
+	listings := prov.customMetricListings()
+
+	discoveredMetrics := func() []string {
+		t.Helper()
+		resources, err := discoveryClient.ServerResourcesForGroupVersion("custom.metrics.k8s.io/v1beta2")
+		require.NoError(t, err)
+		var names []string
+		for _, resource := range resources.APIResources {
+			names = append(names, resource.Name)
+		}
+		return names
+	}
+
+	for range 3 {
+		assert.Equal(t, []string{"pods/http_requests"}, discoveredMetrics())
+		resp, _ := fetchAggregatedDiscovery(t, config, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, listings, prov.customMetricListings(), "the discovery requests should be served from the cache")
+
+	// the provider notifies the change of its metrics
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: pods, Namespaced: true, Metric: "http_requests"},
+		{GroupResource: pods, Namespaced: true, Metric: "packets"},
+	})
+	assert.Equal(t, []string{"pods/http_requests", "pods/packets"}, discoveredMetrics())
+}
+
+// failingListingProvider lists the custom metrics of a listingProvider with
+// ListAllMetricsWithError, unless it is given an error.
+type failingListingProvider struct {
+	*listingProvider
+
+	lock sync.Mutex
+	err  error
+}
+
+func (p *failingListingProvider) fail(err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.err = err
+}
+
+func (p *failingListingProvider) ListAllMetricsWithError(_ context.Context) ([]provider.CustomMetricInfo, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.ListAllMetrics(), nil
+}
+
+func TestMetricsListingErrors(t *testing.T) {
+	prov := &failingListingProvider{listingProvider: &listingProvider{MetricsProvider: fake.NewProvider()}}
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"},
+	})
+	server := newServer(t, prov)
+	// the readiness checks are installed when the server is prepared to run
+	server.GenericAPIServer.PrepareRun()
+	httpServer := httptest.NewServer(server.GenericAPIServer.Handler)
+	t.Cleanup(httpServer.Close)
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: httpServer.URL})
+	require.NoError(t, err)
+	discoveryClient.UseLegacyDiscovery = true
+
+	discoveredMetrics := func() []string {
+		t.Helper()
+		resources, err := discoveryClient.ServerResourcesForGroupVersion("custom.metrics.k8s.io/v1beta2")
+		require.NoError(t, err)
+		var names []string
+		for _, resource := range resources.APIResources {
+			names = append(names, resource.Name)
+		}
+		return names
+	}
+	readiness := func() int {
+		t.Helper()
+		resp, err := http.Get(httpServer.URL + "/readyz/custom-metrics-listing")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, []string{"pods/http_requests"}, discoveredMetrics())
+	assert.Equal(t, http.StatusOK, readiness())
+
+	prov.fail(errors.New("backend unavailable"))
+	assert.Equal(t, []string{"pods/http_requests"}, discoveredMetrics(), "the metrics last listed should be served when listing fails")
+	assert.Equal(t, http.StatusInternalServerError, readiness())
+	inventory := server.MetricsInventory().Collect()
+	require.Len(t, inventory.CustomMetrics, 1)
+	assert.Equal(t, "http_requests", inventory.CustomMetrics[0].Metric, "the inventory should describe the metrics last listed")
+	assert.Equal(t, "backend unavailable", inventory.CustomMetricsListingError)
+	require.NotEmpty(t, inventory.Caches)
+	assert.Equal(t, "custom-metrics-listing", inventory.Caches[0].Name)
+	assert.Equal(t, "backend unavailable", inventory.Caches[0].LastRefreshError)
+
+	// the readiness checks don't list the metrics, the next listing does
+	prov.fail(nil)
+	assert.Equal(t, http.StatusInternalServerError, readiness(), "the readiness checks shouldn't list the metrics")
+	assert.Equal(t, []string{"pods/http_requests"}, discoveredMetrics())
+	assert.Equal(t, http.StatusOK, readiness())
+	assert.Empty(t, server.MetricsInventory().Collect().Caches[0].LastRefreshError)
+}
+
+// listingWrapper wraps a provider, and forwards the listings with errors to
+// it, like the recording provider.
+type listingWrapper struct {
+	provider.MetricsProvider
+}
+
+func (w *listingWrapper) Unwrap() provider.MetricsProvider {
+	return w.MetricsProvider
+}
+
+func (w *listingWrapper) ListAllMetricsWithError(ctx context.Context) ([]provider.CustomMetricInfo, error) {
+	return provider.ListCustomMetrics(ctx, w.MetricsProvider)
+}
+
+func TestMetricsListingReadinessChecks(t *testing.T) {
+	for name, tc := range map[string]struct {
+		provider provider.MetricsProvider
+		checked  bool
+	}{
+		"listing errors": {
+			provider: &failingListingProvider{listingProvider: &listingProvider{MetricsProvider: fake.NewProvider()}},
+			checked:  true,
+		},
+		"wrapped listing errors": {
+			provider: &listingWrapper{MetricsProvider: &failingListingProvider{listingProvider: &listingProvider{MetricsProvider: fake.NewProvider()}}},
+			checked:  true,
+		},
+		"wrapper of a provider without listing errors": {
+			provider: &listingWrapper{MetricsProvider: &listingProvider{MetricsProvider: fake.NewProvider()}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := newServer(t, tc.provider)
+			server.GenericAPIServer.PrepareRun()
+			httpServer := httptest.NewServer(server.GenericAPIServer.Handler)
+			t.Cleanup(httpServer.Close)
+
+			resp, err := http.Get(httpServer.URL + "/readyz/custom-metrics-listing")
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			if tc.checked {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestMetricsInventoryListingCaches(t *testing.T) {
+	prov := &listingProvider{MetricsProvider: fake.NewProvider()}
+	prov.setMetrics([]provider.CustomMetricInfo{
+		{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"},
+	})
+
+	for name, tc := range map[string]struct {
+		interval time.Duration
+		caches   []string
+	}{
+		"not cached": {
+			caches: []string{"custom-metrics-listing", "external-metrics-listing"},
+		},
+		"cached": {
+			interval: time.Hour,
+			caches:   []string{"custom-metrics-listing", "external-metrics-listing"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := newServer(t, prov, func(c *Config) {
+				c.MetricsListingInterval = tc.interval
+			})
+
+			var caches []string
+			for _, cache := range server.MetricsInventory().Collect().Caches {
+				caches = append(caches, cache.Name)
+			}
+			assert.Equal(t, tc.caches, caches)
+		})
+	}
+}
+
+// describedProvider is a listing provider describing its metrics.
+type describedProvider struct {
+	*listingProvider
+	customMetadata   map[provider.CustomMetricInfo]*provider.MetricMetadata
+	externalMetadata map[string]*provider.MetricMetadata
+}
+
+func (p *describedProvider) CustomMetricMetadata(info provider.CustomMetricInfo) *provider.MetricMetadata {
+	return p.customMetadata[info]
+}
+
+func (p *describedProvider) ExternalMetricMetadata(info provider.ExternalMetricInfo) *provider.MetricMetadata {
+	return p.externalMetadata[info.Metric]
+}
+
+func TestMetricsMetadata(t *testing.T) {
+	requests := provider.MetricMetadata{Unit: "requests/s", Kind: provider.MetricKindRate, Description: "Requests served", SelectableLabels: []string{"verb"}}
+	podsRequests := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"}
+	prov := &describedProvider{
+		listingProvider:  &listingProvider{MetricsProvider: fake.NewProvider()},
+		customMetadata:   map[provider.CustomMetricInfo]*provider.MetricMetadata{podsRequests: &requests},
+		externalMetadata: map[string]*provider.MetricMetadata{"queue_length": {Kind: provider.MetricKindGauge}},
+	}
+	prov.setMetrics([]provider.CustomMetricInfo{podsRequests}, provider.ExternalMetricInfo{Metric: "queue_length"})
+	server := newServer(t, prov, func(c *Config) {
+		c.MetricsListingInterval = time.Hour
+	})
+
+	collected := server.MetricsMetadata().Collect()
+	assert.Equal(t, []metadata.CustomMetric{{Metric: "http_requests", GroupResource: "pods", Namespaced: true, MetricMetadata: requests}}, collected.CustomMetrics)
+	assert.Equal(t, []metadata.ExternalMetric{{Metric: "queue_length", MetricMetadata: provider.MetricMetadata{Kind: provider.MetricKindGauge}}}, collected.ExternalMetrics)
+
+	// the kinds and units are served as the categories of the resources
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(startServer(t, prov))
+	require.NoError(t, err)
+	resources, err := discoveryClient.ServerResourcesForGroupVersion("external.metrics.k8s.io/v1beta1")
+	require.NoError(t, err)
+	require.Len(t, resources.APIResources, 1)
+	assert.Equal(t, []string{"kind:gauge"}, resources.APIResources[0].Categories)
+}
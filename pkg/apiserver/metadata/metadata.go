@@ -0,0 +1,125 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metadata serves the metadata of the metrics served by an adapter,
+// such as their units and kinds, to the consumers of the metrics.
+package metadata
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// Path is the path adapters serve the metadata at.
+const Path = "/metrics-metadata"
+
+// Metadata lists the metrics served by an adapter, with their metadata.
+type Metadata struct {
+	CustomMetrics   []CustomMetric   `json:"customMetrics"`
+	ExternalMetrics []ExternalMetric `json:"externalMetrics"`
+}
+
+// CustomMetric describes a custom metric, as listed by the provider.
+type CustomMetric struct {
+	Metric        string `json:"metric"`
+	GroupResource string `json:"groupResource"`
+	Namespaced    bool   `json:"namespaced"`
+	provider.MetricMetadata
+}
+
+// ExternalMetric describes an external metric, as listed by the provider.
+type ExternalMetric struct {
+	Metric string `json:"metric"`
+	provider.MetricMetadata
+}
+
+// Handler serves the metadata of the metrics listed by its listers as JSON.
+type Handler struct {
+	// CustomMetrics and ExternalMetrics list the metrics of the providers.
+	// Either may be nil when the adapter doesn't serve the API.
+	CustomMetrics   func() []provider.CustomMetricInfo
+	ExternalMetrics func() []provider.ExternalMetricInfo
+	// CustomMetricsMetadata and ExternalMetricsMetadata describe the listed
+	// metrics.  Either may be nil when the provider doesn't describe them.
+	CustomMetricsMetadata   provider.MetricMetadataProvider
+	ExternalMetricsMetadata provider.MetricMetadataProvider
+}
+
+// Collect returns the current metadata of the metrics.
+func (h *Handler) Collect() *Metadata {
+	metadata := &Metadata{
+		CustomMetrics:   []CustomMetric{},
+		ExternalMetrics: []ExternalMetric{},
+	}
+
+	if h.CustomMetrics != nil {
+		for _, info := range h.CustomMetrics() {
+			metric := CustomMetric{
+				Metric:        info.Metric,
+				GroupResource: info.GroupResource.String(),
+				Namespaced:    info.Namespaced,
+			}
+			if h.CustomMetricsMetadata != nil {
+				if m := h.CustomMetricsMetadata.CustomMetricMetadata(info); m != nil {
+					metric.MetricMetadata = *m
+				}
+			}
+			metadata.CustomMetrics = append(metadata.CustomMetrics, metric)
+		}
+	}
+	if h.ExternalMetrics != nil {
+		for _, info := range h.ExternalMetrics() {
+			metric := ExternalMetric{Metric: info.Metric}
+			if h.ExternalMetricsMetadata != nil {
+				if m := h.ExternalMetricsMetadata.ExternalMetricMetadata(info); m != nil {
+					metric.MetricMetadata = *m
+				}
+			}
+			metadata.ExternalMetrics = append(metadata.ExternalMetrics, metric)
+		}
+	}
+
+	slices.SortFunc(metadata.CustomMetrics, func(a, b CustomMetric) int {
+		return cmp.Or(cmp.Compare(a.GroupResource, b.GroupResource), cmp.Compare(a.Metric, b.Metric))
+	})
+	slices.SortFunc(metadata.ExternalMetrics, func(a, b ExternalMetric) int {
+		return cmp.Compare(a.Metric, b.Metric)
+	})
+	return metadata
+}
+
+// ServeHTTP serves the current metadata as JSON.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, private")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(h.Collect()); err != nil {
+		klog.ErrorS(err, "Unable to write the metrics metadata")
+	}
+}
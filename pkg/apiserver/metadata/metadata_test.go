@@ -0,0 +1,110 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// staticMetadata describes metrics with fixed metadata.
+type staticMetadata struct {
+	custom   map[provider.CustomMetricInfo]provider.MetricMetadata
+	external map[string]provider.MetricMetadata
+}
+
+func (m staticMetadata) CustomMetricMetadata(info provider.CustomMetricInfo) *provider.MetricMetadata {
+	if metadata, found := m.custom[info]; found {
+		return &metadata
+	}
+	return nil
+}
+
+func (m staticMetadata) ExternalMetricMetadata(info provider.ExternalMetricInfo) *provider.MetricMetadata {
+	if metadata, found := m.external[info.Metric]; found {
+		return &metadata
+	}
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	podsRequests := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "requests"}
+	metadata := staticMetadata{
+		custom: map[provider.CustomMetricInfo]provider.MetricMetadata{podsRequests: {
+			Unit:             "requests/s",
+			Kind:             provider.MetricKindRate,
+			Description:      "Requests served",
+			SelectableLabels: []string{"verb"},
+		}},
+		external: map[string]provider.MetricMetadata{"queue_length": {Kind: provider.MetricKindGauge}},
+	}
+	handler := &Handler{
+		CustomMetrics: func() []provider.CustomMetricInfo {
+			return []provider.CustomMetricInfo{
+				{GroupResource: schema.GroupResource{Resource: "services"}, Namespaced: true, Metric: "requests"},
+				podsRequests,
+				{GroupResource: schema.GroupResource{Group: "apps", Resource: "deployments"}, Namespaced: true, Metric: "replicas"},
+			}
+		},
+		CustomMetricsMetadata: metadata,
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	served := Metadata{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Equal(t, Metadata{
+		CustomMetrics: []CustomMetric{
+			{Metric: "replicas", GroupResource: "deployments.apps", Namespaced: true},
+			{Metric: "requests", GroupResource: "pods", Namespaced: true, MetricMetadata: provider.MetricMetadata{
+				Unit:             "requests/s",
+				Kind:             provider.MetricKindRate,
+				Description:      "Requests served",
+				SelectableLabels: []string{"verb"},
+			}},
+			{Metric: "requests", GroupResource: "services", Namespaced: true},
+		},
+		ExternalMetrics: []ExternalMetric{},
+	}, served)
+
+	handler.ExternalMetrics = func() []provider.ExternalMetricInfo {
+		return []provider.ExternalMetricInfo{{Metric: "queue_length"}, {Metric: "lag"}}
+	}
+	assert.Equal(t, []ExternalMetric{{Metric: "lag"}, {Metric: "queue_length"}}, handler.Collect().ExternalMetrics,
+		"the metrics should be served without metadata when they aren't described")
+	handler.ExternalMetricsMetadata = metadata
+	assert.Equal(t, []ExternalMetric{
+		{Metric: "lag"},
+		{Metric: "queue_length", MetricMetadata: provider.MetricMetadata{Kind: provider.MetricKindGauge}},
+	}, handler.Collect().ExternalMetrics)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, Path, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+}
@@ -32,11 +32,93 @@ var (
 		StabilityLevel: metrics.ALPHA,
 		Buckets:        metrics.ExponentialBuckets(1, 1.364, 20),
 	}, []string{"group"})
+
+	staleValues = metrics.NewCounterVec(&metrics.CounterOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "stale_values_total",
+		Help:           "Number of metric values rejected for being older than the maximum age",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"group"})
+
+	metricValueFreshness = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "metric_value_freshness_seconds",
+		Help:           "Freshness of metric values exported, per metric",
+		StabilityLevel: metrics.ALPHA,
+		Buckets:        metrics.ExponentialBuckets(1, 1.364, 20),
+	}, []string{"group", "resource", "metric"})
+
+	providerRequestDuration = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "provider_request_duration_seconds",
+		Help:           "Latency of calls to the metrics provider, per metric",
+		StabilityLevel: metrics.ALPHA,
+		Buckets:        metrics.DefBuckets,
+	}, []string{"group", "resource", "metric"})
+
+	providerRequestErrors = metrics.NewCounterVec(&metrics.CounterOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "provider_request_errors_total",
+		Help:           "Number of failed calls to the metrics provider, per metric and status reason",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"group", "resource", "metric", "reason"})
+
+	providerResultItems = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "provider_result_items",
+		Help:           "Number of metric values returned by calls to the metrics provider, per metric",
+		StabilityLevel: metrics.ALPHA,
+		Buckets:        metrics.ExponentialBuckets(1, 2, 12),
+	}, []string{"group", "resource", "metric"})
+
+	providerListings = metrics.NewCounterVec(&metrics.CounterOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "provider_listings_total",
+		Help:           "Number of attempts to list the metrics of the metrics provider, per result",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"group", "result"})
+
+	providerLastListingSuccess = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "provider_last_listing_success_timestamp_seconds",
+		Help:           "Timestamp of the last successful listing of the metrics of the metrics provider",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"group"})
+
+	configReloads = metrics.NewCounterVec(&metrics.CounterOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "config_reloads_total",
+		Help:           "Number of attempts to reload the provider configuration, per result",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"result"})
+
+	configLastReloadSuccess = metrics.NewGauge(&metrics.GaugeOpts{
+		Namespace:      "metrics_apiserver",
+		Name:           "config_last_reload_success_timestamp_seconds",
+		Help:           "Timestamp of the last successful reload of the provider configuration",
+		StabilityLevel: metrics.ALPHA,
+	})
 )
 
 // RegisterMetrics registers API server metrics, given a registration function.
 func RegisterMetrics(registrationFunc func(metrics.Registerable) error) error {
-	return registrationFunc(metricFreshness)
+	for _, metric := range []metrics.Registerable{
+		metricFreshness,
+		staleValues,
+		metricValueFreshness,
+		providerRequestDuration,
+		providerRequestErrors,
+		providerResultItems,
+		providerListings,
+		providerLastListingSuccess,
+		configReloads,
+		configLastReloadSuccess,
+	} {
+		if err := registrationFunc(metric); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // FreshnessObserver captures individual observations of the timestamp of
@@ -62,3 +144,80 @@ func (o *freshnessObserver) Observe(timestamp metav1.Time) {
 	metricFreshness.WithLabelValues(o.apiGroup).
 		Observe(o.clock.Since(timestamp.Time).Seconds())
 }
+
+// StalenessObserver counts metric values which were not served because they
+// were too old.
+type StalenessObserver interface {
+	ObserveStale(count int)
+}
+
+// NewStalenessObserver creates a StalenessObserver for a given metrics API group.
+func NewStalenessObserver(apiGroup string) StalenessObserver {
+	return &stalenessObserver{
+		apiGroup: apiGroup,
+	}
+}
+
+type stalenessObserver struct {
+	apiGroup string
+}
+
+func (o *stalenessObserver) ObserveStale(count int) {
+	if count > 0 {
+		staleValues.WithLabelValues(o.apiGroup).Add(float64(count))
+	}
+}
+
+// ListingObserver records the outcome of the listings of the metrics of a
+// provider.
+type ListingObserver interface {
+	ObserveListing(err error)
+}
+
+// NewListingObserver creates a ListingObserver for a given metrics API group.
+func NewListingObserver(apiGroup string) ListingObserver {
+	return &listingObserver{
+		apiGroup: apiGroup,
+		clock:    clock.RealClock{},
+	}
+}
+
+type listingObserver struct {
+	apiGroup string
+	clock    clock.PassiveClock
+}
+
+func (o *listingObserver) ObserveListing(err error) {
+	if err != nil {
+		providerListings.WithLabelValues(o.apiGroup, "failure").Inc()
+		return
+	}
+	providerListings.WithLabelValues(o.apiGroup, "success").Inc()
+	providerLastListingSuccess.WithLabelValues(o.apiGroup).Set(float64(o.clock.Now().Unix()))
+}
+
+// ReloadObserver records the outcome of the reloads of the provider
+// configuration.
+type ReloadObserver interface {
+	ObserveReload(err error)
+}
+
+// NewReloadObserver creates a ReloadObserver.
+func NewReloadObserver() ReloadObserver {
+	return &reloadObserver{
+		clock: clock.RealClock{},
+	}
+}
+
+type reloadObserver struct {
+	clock clock.PassiveClock
+}
+
+func (o *reloadObserver) ObserveReload(err error) {
+	if err != nil {
+		configReloads.WithLabelValues("failure").Inc()
+		return
+	}
+	configReloads.WithLabelValues("success").Inc()
+	configLastReloadSuccess.Set(float64(o.clock.Now().Unix()))
+}
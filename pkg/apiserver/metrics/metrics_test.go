@@ -17,11 +17,14 @@ limitations under the License.
 package metrics
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
 
+	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/component-base/metrics/testutil"
 	"k8s.io/metrics/pkg/apis/custom_metrics"
 	"k8s.io/metrics/pkg/apis/external_metrics"
@@ -114,3 +117,133 @@ func TestFreshness(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestLabelGuard(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	nodes := schema.GroupResource{Resource: "nodes"}
+
+	limited := NewLabelGuard(nil, 2)
+	for _, c := range []struct {
+		resource         schema.GroupResource
+		metric           string
+		served           bool
+		expectedResource string
+		expectedMetric   string
+	}{
+		{pods, "missing", false, OtherLabelValue, OtherLabelValue},
+		{pods, "foo", true, "pods", "foo"},
+		{nodes, "foo", true, "nodes", "foo"},
+		{pods, "bar", true, OtherLabelValue, OtherLabelValue},
+		{pods, "foo", false, "pods", "foo"},
+	} {
+		if c.served {
+			limited.Admit(c.resource, c.metric)
+		}
+		resource, metric := limited.Labels(c.resource, c.metric)
+		if resource != c.expectedResource || metric != c.expectedMetric {
+			t.Errorf("expected labels %s/%s for %s/%s, got %s/%s", c.expectedResource, c.expectedMetric, c.resource, c.metric, resource, metric)
+		}
+	}
+
+	disabled := NewLabelGuard(nil, 0)
+	disabled.Admit(pods, "foo")
+	if resource, metric := disabled.Labels(pods, "foo"); resource != OtherLabelValue || metric != OtherLabelValue {
+		t.Errorf("expected a zero limit to report every metric as other, got %s/%s", resource, metric)
+	}
+
+	allowlisted := NewLabelGuard([]string{"foo"}, 1)
+	if resource, metric := allowlisted.Labels(nodes, "foo"); resource != "nodes" || metric != "foo" {
+		t.Errorf("expected allowlisted metric to be labeled, got %s/%s", resource, metric)
+	}
+	if resource, metric := allowlisted.Labels(pods, "bar"); resource != OtherLabelValue || metric != OtherLabelValue {
+		t.Errorf("expected metric missing from the allowlist to be reported as other, got %s/%s", resource, metric)
+	}
+}
+
+func TestProviderObserverErrors(t *testing.T) {
+	providerRequestErrors.Create(nil)
+	providerRequestErrors.Reset()
+
+	pods := schema.GroupResource{Resource: "pods"}
+	observer := NewProviderObserver("custom.metrics.k8s.io", NewLabelGuard(nil, 1))
+	observer.ObserveRequest(pods, "missing", time.Second, 0, apierr.NewNotFound(pods, "bar"))
+	observer.ObserveRequest(pods, "foo", time.Second, 3, nil)
+	observer.ObserveRequest(pods, "foo", time.Second, 0, apierr.NewNotFound(pods, "bar"))
+	observer.ObserveRequest(pods, "foo", time.Second, 0, apierr.NewNotFound(pods, "baz"))
+	observer.ObserveRequest(pods, "bar", time.Second, 0, errors.New("backend unavailable"))
+
+	err := testutil.CollectAndCompare(providerRequestErrors, strings.NewReader(`
+	# HELP metrics_apiserver_provider_request_errors_total [ALPHA] Number of failed calls to the metrics provider, per metric and status reason
+	# TYPE metrics_apiserver_provider_request_errors_total counter
+	metrics_apiserver_provider_request_errors_total{group="custom.metrics.k8s.io",metric="foo",reason="NotFound",resource="pods"} 2
+	metrics_apiserver_provider_request_errors_total{group="custom.metrics.k8s.io",metric="other",reason="NotFound",resource="other"} 1
+	metrics_apiserver_provider_request_errors_total{group="custom.metrics.k8s.io",metric="other",reason="Unknown",resource="other"} 1
+	`))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReloadObserver(t *testing.T) {
+	configReloads.Create(nil)
+	configReloads.Reset()
+	configLastReloadSuccess.Create(nil)
+
+	now := time.Unix(1700000000, 0)
+	observer := NewReloadObserver()
+	observer.(*reloadObserver).clock = clocktesting.NewFakeClock(now)
+	observer.ObserveReload(nil)
+	observer.ObserveReload(errors.New("invalid configuration"))
+	observer.ObserveReload(errors.New("invalid configuration"))
+
+	err := testutil.CollectAndCompare(configReloads, strings.NewReader(`
+	# HELP metrics_apiserver_config_reloads_total [ALPHA] Number of attempts to reload the provider configuration, per result
+	# TYPE metrics_apiserver_config_reloads_total counter
+	metrics_apiserver_config_reloads_total{result="failure"} 2
+	metrics_apiserver_config_reloads_total{result="success"} 1
+	`), "metrics_apiserver_config_reloads_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testutil.CollectAndCompare(configLastReloadSuccess, strings.NewReader(`
+	# HELP metrics_apiserver_config_last_reload_success_timestamp_seconds [ALPHA] Timestamp of the last successful reload of the provider configuration
+	# TYPE metrics_apiserver_config_last_reload_success_timestamp_seconds gauge
+	metrics_apiserver_config_last_reload_success_timestamp_seconds 1.7e+09
+	`), "metrics_apiserver_config_last_reload_success_timestamp_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListingObserver(t *testing.T) {
+	providerListings.Create(nil)
+	providerListings.Reset()
+	providerLastListingSuccess.Create(nil)
+	providerLastListingSuccess.Reset()
+
+	now := time.Unix(1700000000, 0)
+	observer := NewListingObserver("external.metrics.k8s.io")
+	observer.(*listingObserver).clock = clocktesting.NewFakeClock(now)
+	observer.ObserveListing(nil)
+	observer.ObserveListing(errors.New("backend unavailable"))
+
+	err := testutil.CollectAndCompare(providerListings, strings.NewReader(`
+	# HELP metrics_apiserver_provider_listings_total [ALPHA] Number of attempts to list the metrics of the metrics provider, per result
+	# TYPE metrics_apiserver_provider_listings_total counter
+	metrics_apiserver_provider_listings_total{group="external.metrics.k8s.io",result="failure"} 1
+	metrics_apiserver_provider_listings_total{group="external.metrics.k8s.io",result="success"} 1
+	`), "metrics_apiserver_provider_listings_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testutil.CollectAndCompare(providerLastListingSuccess, strings.NewReader(`
+	# HELP metrics_apiserver_provider_last_listing_success_timestamp_seconds [ALPHA] Timestamp of the last successful listing of the metrics of the metrics provider
+	# TYPE metrics_apiserver_provider_last_listing_success_timestamp_seconds gauge
+	metrics_apiserver_provider_last_listing_success_timestamp_seconds{group="external.metrics.k8s.io"} 1.7e+09
+	`), "metrics_apiserver_provider_last_listing_success_timestamp_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
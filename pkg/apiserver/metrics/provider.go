@@ -0,0 +1,172 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// OtherLabelValue replaces the resource and metric labels of the metrics
+	// which are not allowed by a LabelGuard.
+	OtherLabelValue = "other"
+
+	// DefaultMaxLabeledMetrics is the number of distinct metrics which get
+	// their own labels when no limit is configured.
+	DefaultMaxLabeledMetrics = 100
+)
+
+// LabelGuard bounds the cardinality of the per-metric instrumentation.
+// Metrics which are not allowed are all reported under OtherLabelValue.
+type LabelGuard struct {
+	allowlist sets.Set[string]
+	limit     int
+
+	lock sync.RWMutex
+	// admitted tracks the labeled metrics for each group resource
+	admitted map[schema.GroupResource]sets.Set[string]
+	count    int
+}
+
+// NewLabelGuard creates a LabelGuard.  If the allowlist is not empty, only the
+// metrics it names are labeled individually.  Otherwise, the first limit distinct
+// resource and metric pairs to be served successfully are (a limit of zero or less
+// reports every metric under OtherLabelValue).
+func NewLabelGuard(allowlist []string, limit int) *LabelGuard {
+	return &LabelGuard{
+		allowlist: sets.New(allowlist...),
+		limit:     limit,
+		admitted:  make(map[schema.GroupResource]sets.Set[string]),
+	}
+}
+
+// Admit records that the provider successfully served the given metric,
+// giving it its own labels if the limit has not been reached yet.  Requests
+// which fail (for instance for metrics which don't exist) aren't admitted,
+// so that they can't use up the limit.
+func (g *LabelGuard) Admit(resource schema.GroupResource, metric string) {
+	if g.allowlist.Len() > 0 {
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	metrics, ok := g.admitted[resource]
+	if ok && metrics.Has(metric) || g.count >= g.limit {
+		return
+	}
+	if !ok {
+		metrics = sets.New[string]()
+		g.admitted[resource] = metrics
+	}
+	metrics.Insert(metric)
+	g.count++
+}
+
+// Labels returns the resource and metric label values to use for the given metric.
+func (g *LabelGuard) Labels(resource schema.GroupResource, metric string) (string, string) {
+	if g.allowlist.Len() > 0 {
+		if g.allowlist.Has(metric) {
+			return resource.String(), metric
+		}
+		return OtherLabelValue, OtherLabelValue
+	}
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.admitted[resource].Has(metric) {
+		return resource.String(), metric
+	}
+	return OtherLabelValue, OtherLabelValue
+}
+
+// ProviderObserver captures per-metric observations of the calls made to
+// metrics providers, and of the values they return.
+type ProviderObserver interface {
+	// ObserveRequest records the outcome of a single provider call.
+	ObserveRequest(resource schema.GroupResource, metric string, duration time.Duration, items int, err error)
+	// ObserveFreshness records the timestamp of a single metric value.
+	ObserveFreshness(resource schema.GroupResource, metric string, timestamp metav1.Time)
+}
+
+// NewProviderObserver creates a ProviderObserver for a given metrics API group.
+// If guard is nil, a LabelGuard with the default limit is used.
+func NewProviderObserver(apiGroup string, guard *LabelGuard) ProviderObserver {
+	if guard == nil {
+		guard = NewLabelGuard(nil, DefaultMaxLabeledMetrics)
+	}
+	return &providerObserver{
+		apiGroup: apiGroup,
+		guard:    guard,
+		clock:    clock.RealClock{},
+	}
+}
+
+type providerObserver struct {
+	apiGroup string
+	guard    *LabelGuard
+	clock    clock.PassiveClock
+}
+
+func (o *providerObserver) ObserveRequest(resource schema.GroupResource, metric string, duration time.Duration, items int, err error) {
+	if err == nil {
+		o.guard.Admit(resource, metric)
+	}
+	resourceLabel, metricLabel := o.guard.Labels(resource, metric)
+	providerRequestDuration.WithLabelValues(o.apiGroup, resourceLabel, metricLabel).Observe(duration.Seconds())
+	if err != nil {
+		reason := apierr.ReasonForError(err)
+		if reason == metav1.StatusReasonUnknown {
+			// errors which aren't API status errors have no reason
+			reason = "Unknown"
+		}
+		providerRequestErrors.WithLabelValues(o.apiGroup, resourceLabel, metricLabel, string(reason)).Inc()
+		return
+	}
+	providerResultItems.WithLabelValues(o.apiGroup, resourceLabel, metricLabel).Observe(float64(items))
+}
+
+func (o *providerObserver) ObserveFreshness(resource schema.GroupResource, metric string, timestamp metav1.Time) {
+	resourceLabel, metricLabel := o.guard.Labels(resource, metric)
+	metricValueFreshness.WithLabelValues(o.apiGroup, resourceLabel, metricLabel).
+		Observe(o.clock.Since(timestamp.Time).Seconds())
+}
+
+// ProviderObservers is a ProviderObserver passing observations on to each
+// of the observers it contains.
+type ProviderObservers []ProviderObserver
+
+func (o ProviderObservers) ObserveRequest(resource schema.GroupResource, metric string, duration time.Duration, items int, err error) {
+	for _, observer := range o {
+		observer.ObserveRequest(resource, metric, duration, items, err)
+	}
+}
+
+func (o ProviderObservers) ObserveFreshness(resource schema.GroupResource, metric string, timestamp metav1.Time) {
+	for _, observer := range o {
+		observer.ObserveFreshness(resource, metric, timestamp)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+)
+
+// metricValueList returns a list of values of a metric for size pods.
+func metricValueList(size int) *custom_metrics.MetricValueList {
+	timestamp := metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	window := int64(60)
+	list := &custom_metrics.MetricValueList{Items: make([]custom_metrics.MetricValue, size)}
+	for i := range list.Items {
+		list.Items[i] = custom_metrics.MetricValue{
+			DescribedObject: custom_metrics.ObjectReference{
+				Kind:       "Pod",
+				APIVersion: "v1",
+				Namespace:  "default",
+				Name:       fmt.Sprintf("frontend-7d9c8b6f5-%05d", i),
+			},
+			Metric: custom_metrics.MetricIdentifier{
+				Name:     "http_requests_per_second",
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"verb": "GET"}},
+			},
+			Timestamp:     timestamp,
+			WindowSeconds: &window,
+			Value:         *resource.NewMilliQuantity(int64(1000+i), resource.DecimalSI),
+		}
+	}
+	return list
+}
+
+// encoderFor returns the encoder of the custom metrics API for mediaType.
+func encoderFor(t testing.TB, mediaType string) runtime.Encoder {
+	t.Helper()
+	info, found := runtime.SerializerInfoForMediaType(Codecs.SupportedMediaTypes(), mediaType)
+	require.True(t, found, "no serializer for %s", mediaType)
+	return Codecs.EncoderForVersion(info.Serializer, cmv1beta2.SchemeGroupVersion)
+}
+
+func TestSerializationRoundTrip(t *testing.T) {
+	list := metricValueList(3)
+	sizes := map[string]int{}
+	for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeProtobuf} {
+		t.Run(mediaType, func(t *testing.T) {
+			data, err := runtime.Encode(encoderFor(t, mediaType), list)
+			require.NoError(t, err)
+			sizes[mediaType] = len(data)
+
+			decoded, err := runtime.Decode(Codecs.UniversalDecoder(), data)
+			require.NoError(t, err)
+			decodedList, ok := decoded.(*custom_metrics.MetricValueList)
+			require.True(t, ok, "unexpected object %T", decoded)
+			require.Len(t, decodedList.Items, len(list.Items))
+			for i, value := range decodedList.Items {
+				expected := list.Items[i]
+				assert.Equal(t, expected.DescribedObject, value.DescribedObject)
+				assert.Equal(t, expected.Metric, value.Metric)
+				assert.WithinDuration(t, expected.Timestamp.Time, value.Timestamp.Time, 0)
+				assert.Equal(t, expected.WindowSeconds, value.WindowSeconds)
+				assert.Equal(t, expected.Value.String(), value.Value.String())
+			}
+		})
+	}
+	assert.Less(t, sizes[runtime.ContentTypeProtobuf], sizes[runtime.ContentTypeJSON])
+}
+
+func BenchmarkEncodeMetricValueList(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		list := metricValueList(size)
+		for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeProtobuf} {
+			b.Run(fmt.Sprintf("%s/%d", mediaType, size), func(b *testing.B) {
+				encoder := encoderFor(b, mediaType)
+				buf := &bytes.Buffer{}
+				b.ReportAllocs()
+				for b.Loop() {
+					buf.Reset()
+					if err := encoder.Encode(list, buf); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(buf.Len()), "bytes/response")
+			})
+		}
+	}
+}
+
+func BenchmarkDecodeMetricValueList(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		list := metricValueList(size)
+		for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeProtobuf} {
+			b.Run(fmt.Sprintf("%s/%d", mediaType, size), func(b *testing.B) {
+				data, err := runtime.Encode(encoderFor(b, mediaType), list)
+				if err != nil {
+					b.Fatal(err)
+				}
+				decoder := Codecs.UniversalDeserializer()
+				b.ReportAllocs()
+				for b.Loop() {
+					if _, _, err := decoder.Decode(data, nil, &cmv1beta2.MetricValueList{}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
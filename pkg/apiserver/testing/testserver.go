@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testing runs metrics adapter API servers in-process, to test
+// clients against them.
+package testing
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// TestServer is a metrics adapter API server serving plain HTTP, without
+// authentication nor authorization.
+type TestServer struct {
+	// ClientConfig is the configuration of the clients of the server.
+	ClientConfig *rest.Config
+	// Server is the adapter API server.
+	Server *apiserver.CustomMetricsAdapterServer
+}
+
+// StartTestServer starts a server for the given providers, either of which
+// may be nil to disable its API.  The server is stopped when the test ends.
+func StartTestServer(t testing.TB, customMetricsProvider provider.CustomMetricsProvider, externalMetricsProvider provider.ExternalMetricsProvider) *TestServer {
+	t.Helper()
+
+	serverConfig := genericapiserver.NewRecommendedConfig(apiserver.Codecs)
+	serverConfig.LoopbackClientConfig = &rest.Config{}
+	// the server doesn't listen on a secure port to derive it from
+	serverConfig.ExternalAddress = "127.0.0.1:443"
+	config := &apiserver.Config{GenericConfig: &serverConfig.Config}
+
+	server, err := config.Complete(nil).New(t.Name(), customMetricsProvider, externalMetricsProvider)
+	if err != nil {
+		t.Fatalf("unable to create the test server: %v", err)
+	}
+	httpServer := httptest.NewServer(server.GenericAPIServer.Handler)
+	t.Cleanup(httpServer.Close)
+
+	return &TestServer{
+		ClientConfig: &rest.Config{Host: httpServer.URL},
+		Server:       server,
+	}
+}
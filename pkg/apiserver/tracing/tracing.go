@@ -0,0 +1,78 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing provides the OpenTelemetry span attributes recorded along
+// the request path of the metrics API server.  Package tracingtest captures
+// the resulting spans in tests.
+//
+// Spans are only recorded when the incoming request carries a span, which the
+// generic API server adds when a tracer provider is configured (for instance
+// with --tracing-config-file).  Providers receive the request context, so any
+// span they start from it becomes a child of the API server's spans.
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Attribute keys recorded on the metrics API server spans.
+const (
+	MetricKey         = attribute.Key("metric")
+	GroupResourceKey  = attribute.Key("group_resource")
+	NamespaceKey      = attribute.Key("namespace")
+	NameKey           = attribute.Key("name")
+	SelectorKey       = attribute.Key("selector")
+	MetricSelectorKey = attribute.Key("metric_selector")
+	ItemCountKey      = attribute.Key("item_count")
+)
+
+// Metric returns the attribute holding the name of the requested metric.
+func Metric(metric string) attribute.KeyValue {
+	return MetricKey.String(metric)
+}
+
+// GroupResource returns the attribute holding the group-resource described by the metric.
+func GroupResource(groupResource schema.GroupResource) attribute.KeyValue {
+	return GroupResourceKey.String(groupResource.String())
+}
+
+// Namespace returns the attribute holding the namespace of the request.
+func Namespace(namespace string) attribute.KeyValue {
+	return NamespaceKey.String(namespace)
+}
+
+// Name returns the attribute holding the name of the described object.
+func Name(name string) attribute.KeyValue {
+	return NameKey.String(name)
+}
+
+// Selector returns the attribute holding the label selector for the described objects.
+func Selector(selector labels.Selector) attribute.KeyValue {
+	return SelectorKey.String(selector.String())
+}
+
+// MetricSelector returns the attribute holding the label selector for the metric.
+func MetricSelector(selector labels.Selector) attribute.KeyValue {
+	return MetricSelectorKey.String(selector.String())
+}
+
+// ItemCount returns the attribute holding the number of metric values returned.
+func ItemCount(count int) attribute.KeyValue {
+	return ItemCountKey.Int(count)
+}
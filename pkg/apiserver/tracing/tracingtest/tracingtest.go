@@ -0,0 +1,39 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracingtest captures the spans recorded by the metrics API server
+// in tests.  It is kept apart from package tracing so that adapter binaries
+// don't depend on the OpenTelemetry SDK.
+package tracingtest
+
+import (
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"k8s.io/component-base/tracing"
+)
+
+// NewInMemoryTracerProvider returns a tracer provider which records every span
+// synchronously into the returned exporter.  It can be plugged into an adapter
+// through AdapterBase.TracerProvider.
+func NewInMemoryTracerProvider() (tracing.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSyncer(exporter),
+	)
+	return tp, exporter
+}
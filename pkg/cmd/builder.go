@@ -19,24 +19,35 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/spf13/pflag"
 
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/authorization/union"
 	openapinamer "k8s.io/apiserver/pkg/endpoints/openapi"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/component-base/tracing"
 	openapicommon "k8s.io/kube-openapi/pkg/common"
 
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/inventory"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metadata"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/cmd/config"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/cmd/options"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/dynamicmapper"
 	generatedcore "sigs.k8s.io/custom-metrics-apiserver/pkg/generated/openapi/core"
@@ -49,8 +60,11 @@ import (
 // Embed it in a struct containing your options, then:
 //
 // - Use Flags() to add flags, then call Flags().Parse(os.Argv)
+// - Optionally, use ProviderConfig to decode the provider section of the --config file
 // - Use DynamicClient and RESTMapper to fetch handles to common utilities
 // - Use WithCustomMetrics(provider) and WithExternalMetrics(provider) to install metrics providers
+// - Use WithWebService(ws) and WithNonGoRestfulHandler(path, handler) to serve extra routes
+// - Use WithRunnable(task) and WithLeaderRunnable(task) to run background tasks, the latter only on the leader
 // - Use Run(stopChannel) to start the server
 //
 // All methods on this struct are idempotent except for Run -- they'll perform any
@@ -63,6 +77,16 @@ type AdapterBase struct {
 	// Name is the name of the API server.  It defaults to custom-metrics-adapter
 	Name string
 
+	// ConfigFile is the path of an AdapterConfiguration file.  Settings from
+	// the file apply to the flags which were not set on the command line.
+	// It's set from a flag.
+	ConfigFile string
+	// ConfigReloadInterval is the interval at which ConfigFile is checked for
+	// changes to reload the providers implementing provider.ReloadableProvider.
+	// It defaults to DefaultConfigReloadInterval when the flags are installed,
+	// and zero disables reloading.  It's set from a flag.
+	ConfigReloadInterval time.Duration
+
 	// RemoteKubeConfigFile specifies the kubeconfig to use to construct
 	// the dynamic client and RESTMapper.  It's set from a flag.
 	RemoteKubeConfigFile string
@@ -74,6 +98,30 @@ type AdapterBase struct {
 	// ClientBurst specifies the maximum QPS burst for client-side throttle. It's set from a flag.
 	ClientBurst int
 
+	// EnableMetricsInventory serves the inventory of the metrics served by
+	// the adapter at /debug/metrics-inventory, with their group resources
+	// normalized by RESTMapper.  It defaults to true when the flags are
+	// installed, and it's set from a flag.
+	EnableMetricsInventory bool
+	// EnableMetricsMetadata serves the metadata of the metrics listed by the
+	// providers at /metrics-metadata.  It defaults to true when the flags
+	// are installed, and it's set from a flag.
+	EnableMetricsMetadata bool
+	// MetricsListingInterval is the interval at which the metrics listed by
+	// the providers are refreshed in the cache serving discovery.  Zero
+	// disables the cache.  It's set from a flag.
+	MetricsListingInterval time.Duration
+	// HealthCheckCacheDuration is how long the result of a successful health
+	// check of the providers implementing provider.HealthCheckingProvider is
+	// reused for by readyz.  It defaults to
+	// apiserver.DefaultHealthCheckCacheDuration when the flags are installed,
+	// and it's set from a flag.
+	HealthCheckCacheDuration time.Duration
+
+	// LeaderElection configures the election of the replica running the
+	// tasks registered with WithLeaderRunnable.  It's set from flags.
+	LeaderElection *options.LeaderElectionOptions
+
 	// FlagSet is the flagset to add flags to.
 	// It defaults to the normal CommandLine flags
 	// if not explicitly set.
@@ -85,6 +133,11 @@ type AdapterBase struct {
 	// OpenAPIV3Config
 	OpenAPIV3Config *openapicommon.OpenAPIV3Config
 
+	// TracerProvider, if set, is used to trace requests instead of the
+	// tracer provider configured with --tracing-config-file.  It is mostly
+	// useful to capture spans in tests.
+	TracerProvider tracing.TracerProvider
+
 	// flagOnce controls initialization of the flags.
 	flagOnce sync.Once
 
@@ -94,11 +147,18 @@ type AdapterBase struct {
 	dynamicClient   dynamic.Interface
 	informers       informers.SharedInformerFactory
 
+	adapterConfig    *config.AdapterConfiguration
+	eventBroadcaster record.EventBroadcaster
+
 	config *apiserver.Config
 	server *apiserver.CustomMetricsAdapterServer
 
 	cmProvider provider.CustomMetricsProvider
 	emProvider provider.ExternalMetricsProvider
+
+	runnables       []Runnable
+	leaderRunnables []Runnable
+	routes          []route
 }
 
 // InstallFlags installs the minimum required set of flags into the flagset.
@@ -111,11 +171,43 @@ func (b *AdapterBase) InstallFlags() {
 
 		b.CustomMetricsAdapterServerOptions.AddFlags(b.FlagSet)
 
+		if b.LeaderElection == nil {
+			b.LeaderElection = options.NewLeaderElectionOptions(b.Name)
+		}
+		b.LeaderElection.AddFlags(b.FlagSet)
+
+		if b.ConfigReloadInterval == 0 {
+			b.ConfigReloadInterval = DefaultConfigReloadInterval
+		}
+		if b.HealthCheckCacheDuration == 0 {
+			b.HealthCheckCacheDuration = apiserver.DefaultHealthCheckCacheDuration
+		}
+		// the debugging endpoints are served unless disabled on the
+		// command line, or after the flags are installed
+		b.EnableMetricsInventory = true
+		b.EnableMetricsMetadata = true
+
+		b.FlagSet.StringVar(&b.ConfigFile, "config", b.ConfigFile,
+			"Path to an AdapterConfiguration file. Flags set on the command line take precedence over the file.")
+		b.FlagSet.DurationVar(&b.ConfigReloadInterval, "config-reload-interval", b.ConfigReloadInterval,
+			"Interval at which to check the --config file for changes to the provider section, "+
+				"for providers supporting reloads. Zero disables reloading.")
 		b.FlagSet.StringVar(&b.RemoteKubeConfigFile, "lister-kubeconfig", b.RemoteKubeConfigFile,
 			"kubeconfig file pointing at the 'core' kubernetes server with enough rights to list "+
 				"any described objects")
 		b.FlagSet.DurationVar(&b.DiscoveryInterval, "discovery-interval", b.DiscoveryInterval,
 			"Interval at which to refresh API discovery information")
+		b.FlagSet.BoolVar(&b.EnableMetricsInventory, "enable-metrics-inventory", b.EnableMetricsInventory,
+			"Serve the metrics listed by the providers, and the last queries made for each of them, at "+inventory.Path+
+				" to the users authorized to get that path.")
+		b.FlagSet.BoolVar(&b.EnableMetricsMetadata, "enable-metrics-metadata", b.EnableMetricsMetadata,
+			"Serve the units, kinds, descriptions and selectable labels of the metrics listed by the providers at "+metadata.Path+
+				" to the users authorized to get that path.")
+		b.FlagSet.DurationVar(&b.MetricsListingInterval, "metrics-listing-interval", b.MetricsListingInterval,
+			"Interval at which to refresh the metrics listed by the providers for discovery. "+
+				"Zero lists them on each discovery request.")
+		b.FlagSet.DurationVar(&b.HealthCheckCacheDuration, "health-check-cache-duration", b.HealthCheckCacheDuration,
+			"How long readyz reuses a successful health check of the providers for. Failed checks are reused for a tenth of it.")
 		b.FlagSet.Float32Var(&b.ClientQPS, "client-qps", rest.DefaultQPS, "Maximum QPS for client-side throttle")
 		b.FlagSet.IntVar(&b.ClientBurst, "client-burst", rest.DefaultBurst, "Maximum QPS burst for client-side throttle")
 	})
@@ -139,12 +231,45 @@ func (b *AdapterBase) Flags() *pflag.FlagSet {
 	return b.FlagSet
 }
 
+// AdapterConfiguration returns the configuration loaded from the file given
+// by ConfigFile, or nil if there is none.  The first call applies the file to
+// the flags which were not set on the command line, so it must only be made
+// once the flags are parsed; the other methods of AdapterBase call it as needed.
+func (b *AdapterBase) AdapterConfiguration() (*config.AdapterConfiguration, error) {
+	if b.adapterConfig == nil && b.ConfigFile != "" {
+		adapterConfig, err := config.Load(b.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := adapterConfig.ApplyTo(b.Flags()); err != nil {
+			return nil, err
+		}
+		b.adapterConfig = adapterConfig
+	}
+	return b.adapterConfig, nil
+}
+
+// ProviderConfig strictly decodes the provider section of the configuration
+// file into the given object.  It leaves the object untouched if there is no
+// configuration file, or if the file has no provider section.
+func (b *AdapterBase) ProviderConfig(into interface{}) error {
+	adapterConfig, err := b.AdapterConfiguration()
+	if err != nil || adapterConfig == nil {
+		return err
+	}
+	return adapterConfig.DecodeProvider(into)
+}
+
 // ClientConfig returns the REST client configuration used to construct
 // clients for the clients and RESTMapper, and may be used for other
 // purposes as well.  If you need to mutate it, be sure to copy it with
 // rest.CopyConfig first.
 func (b *AdapterBase) ClientConfig() (*rest.Config, error) {
 	if b.clientConfig == nil {
+		if _, err := b.AdapterConfiguration(); err != nil {
+			return nil, err
+		}
+
 		var clientConfig *rest.Config
 		var err error
 		if len(b.RemoteKubeConfigFile) > 0 {
@@ -280,6 +405,10 @@ func (b *AdapterBase) Config() (*apiserver.Config, error) {
 	if b.config == nil {
 		b.InstallFlags() // just to be sure
 
+		if _, err := b.AdapterConfiguration(); err != nil {
+			return nil, err
+		}
+
 		if b.Name == "" {
 			b.Name = "custom-metrics-adapter"
 		}
@@ -294,7 +423,13 @@ func (b *AdapterBase) Config() (*apiserver.Config, error) {
 		}
 		b.CustomMetricsAdapterServerOptions.OpenAPIV3Config = b.OpenAPIV3Config
 
-		if errList := b.CustomMetricsAdapterServerOptions.Validate(); len(errList) > 0 {
+		if b.LeaderElection.ResourceName == "" {
+			b.LeaderElection.ResourceName = b.Name
+		}
+
+		errList := b.CustomMetricsAdapterServerOptions.Validate()
+		errList = append(errList, b.LeaderElection.Validate()...)
+		if len(errList) > 0 {
 			return nil, utilerrors.NewAggregate(errList)
 		}
 
@@ -311,8 +446,24 @@ func (b *AdapterBase) Config() (*apiserver.Config, error) {
 		if err != nil {
 			return nil, err
 		}
+		if b.TracerProvider != nil {
+			serverConfig.TracerProvider = b.TracerProvider
+		}
+		if serverConfig.Authorization.Authorizer != nil {
+			// the routes are looked up on each request, as they may be
+			// registered after the configuration is built
+			serverConfig.Authorization.Authorizer = union.New(routeAuthorizer{routes: &b.routes}, serverConfig.Authorization.Authorizer)
+		}
+		stalenessPolicy, err := b.CustomMetricsAdapterServerOptions.Staleness.Policy()
+		if err != nil {
+			return nil, err
+		}
 		b.config = &apiserver.Config{
-			GenericConfig: &serverConfig.Config,
+			GenericConfig:            &serverConfig.Config,
+			StalenessPolicy:          stalenessPolicy,
+			MetricLabelGuard:         b.CustomMetricsAdapterServerOptions.Instrumentation.LabelGuard(),
+			MetricsListingInterval:   b.MetricsListingInterval,
+			HealthCheckCacheDuration: b.HealthCheckCacheDuration,
 		}
 	}
 
@@ -336,6 +487,17 @@ func (b *AdapterBase) Server() (*apiserver.CustomMetricsAdapterServer, error) {
 		if err != nil {
 			return nil, err
 		}
+		if b.EnableMetricsInventory {
+			collector := server.MetricsInventory()
+			// the RESTMapper runs discovery, so it's only constructed for
+			// the first inventory request unless the providers use it
+			collector.NewRESTMapper = b.RESTMapper
+			b.WithNonGoRestfulHandler(inventory.Path, collector)
+		}
+		if b.EnableMetricsMetadata {
+			b.WithNonGoRestfulHandler(metadata.Path, server.MetricsMetadata())
+		}
+		installRoutes(server.GenericAPIServer.Handler, b.routes)
 		b.server = server
 	}
 
@@ -367,5 +529,138 @@ func (b *AdapterBase) Run(ctx context.Context) error {
 		return err
 	}
 
-	return server.GenericAPIServer.PrepareRun().RunWithContext(ctx)
+	watchers, err := b.configWatchers(ctx)
+	if err != nil {
+		return err
+	}
+	if b.eventBroadcaster != nil {
+		defer b.eventBroadcaster.Shutdown()
+	}
+	runnables := append(b.runnableProviders(), b.runnables...)
+	for _, watcher := range watchers {
+		runnables = append(runnables, RunnableFunc(func(ctx context.Context) error {
+			watcher.Run(ctx)
+			return nil
+		}))
+	}
+
+	leaderElection, err := b.leaderElectionConfig()
+	if err != nil {
+		return err
+	}
+
+	// start the background tasks before serving, and stop them along with
+	// the server; if any of them fails, the server is stopped too
+	group := newRunGroup(ctx)
+	for _, r := range runnables {
+		group.Go(r.Start)
+	}
+	group.Go(func(ctx context.Context) error {
+		return b.runLeaderRunnables(ctx, leaderElection, group.fail)
+	})
+
+	err = server.GenericAPIServer.PrepareRun().RunWithContext(group.Context())
+	if groupErr := group.Wait(); err == nil {
+		err = groupErr
+	}
+	return err
+}
+
+// configWatchers returns the watchers reloading the providers when the
+// provider section of the configuration file changes.
+func (b *AdapterBase) configWatchers(ctx context.Context) ([]*ConfigWatcher, error) {
+	if b.ConfigFile == "" || b.ConfigReloadInterval <= 0 {
+		return nil, nil
+	}
+
+	var reloadables []provider.ReloadableProvider
+	if reloadable, ok := provider.As[provider.ReloadableProvider](b.cmProvider); ok {
+		reloadables = append(reloadables, reloadable)
+	}
+	if reloadable, ok := provider.As[provider.ReloadableProvider](b.emProvider); ok && !provider.SameProvider(b.cmProvider, b.emProvider) {
+		reloadables = append(reloadables, reloadable)
+	}
+	if len(reloadables) == 0 {
+		return nil, nil
+	}
+
+	recorder, eventObject, err := b.eventRecorder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var watchers []*ConfigWatcher
+	for i, reloadable := range reloadables {
+		watcher := NewConfigWatcher(b.ConfigFile, reloadable)
+		watcher.Interval = b.ConfigReloadInterval
+		watcher.Extract = func(data []byte) ([]byte, error) {
+			adapterConfig, err := config.Decode(data)
+			if err != nil {
+				return nil, err
+			}
+			return adapterConfig.Provider.Raw, nil
+		}
+		if i == 0 {
+			// the changes requiring a restart are only reported once
+			watcher.Ignored = restartRequiredSections
+		}
+		watcher.Recorder = recorder
+		watcher.EventObject = eventObject
+		watchers = append(watchers, watcher)
+	}
+	return watchers, nil
+}
+
+// restartRequiredSections returns the sections of the configuration file,
+// other than the provider section, which differ between two versions of it.
+func restartRequiredSections(previous, current []byte) []string {
+	previousConfig, err := config.Decode(previous)
+	if err != nil {
+		return nil
+	}
+	currentConfig, err := config.Decode(current)
+	if err != nil {
+		return nil
+	}
+
+	var sections []string
+	for _, section := range []struct {
+		name              string
+		previous, current interface{}
+	}{
+		{"serving", previousConfig.Serving, currentConfig.Serving},
+		{"authentication", previousConfig.Authentication, currentConfig.Authentication},
+		{"authorization", previousConfig.Authorization, currentConfig.Authorization},
+		{"client", previousConfig.Client, currentConfig.Client},
+	} {
+		if !apiequality.Semantic.DeepEqual(section.previous, section.current) {
+			sections = append(sections, section.name)
+		}
+	}
+	return sections
+}
+
+// eventRecorder returns a recorder for events about the adapter's pod, which
+// is identified by the POD_NAME and POD_NAMESPACE environment variables.  It
+// returns a nil recorder when they are not set.
+func (b *AdapterBase) eventRecorder(ctx context.Context) (record.EventRecorder, *corev1.ObjectReference, error) {
+	podName, podNamespace := os.Getenv("POD_NAME"), os.Getenv("POD_NAMESPACE")
+	if podName == "" || podNamespace == "" {
+		return nil, nil, nil
+	}
+
+	clientConfig, err := b.ClientConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	b.eventBroadcaster = broadcaster
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: b.Name})
+	return recorder, &corev1.ObjectReference{Kind: "Pod", Namespace: podNamespace, Name: podName, APIVersion: "v1"}, nil
 }
@@ -17,14 +17,20 @@ limitations under the License.
 package cmd
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
 	"k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
 
 	"k8s.io/kube-openapi/pkg/builder"
 
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/fake"
 )
 
@@ -55,3 +61,59 @@ func TestDefaultOpenAPIConfig(t *testing.T) {
 		assert.NoError(t, err2)
 	})
 }
+
+func TestAdapterConfigurationFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1
+kind: AdapterConfiguration
+client:
+  kubeconfig: /etc/adapter/kubeconfig
+  qps: 12.5
+  burst: 20
+provider:
+  query: up
+`), 0o600))
+
+	adapter := &AdapterBase{FlagSet: pflag.NewFlagSet("test", pflag.ContinueOnError)}
+	require.NoError(t, adapter.Flags().Parse([]string{"--config", path, "--client-burst=50"}))
+
+	cfg, err := adapter.AdapterConfiguration()
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	assert.Equal(t, "/etc/adapter/kubeconfig", adapter.RemoteKubeConfigFile)
+	assert.Equal(t, float32(12.5), adapter.ClientQPS)
+	assert.Equal(t, 50, adapter.ClientBurst, "flags set on the command line should take precedence")
+
+	var providerConfig struct {
+		Query string `json:"query"`
+	}
+	require.NoError(t, adapter.ProviderConfig(&providerConfig))
+	assert.Equal(t, "up", providerConfig.Query)
+}
+
+func TestFlagDefaults(t *testing.T) {
+	adapter := &AdapterBase{FlagSet: pflag.NewFlagSet("test", pflag.ContinueOnError)}
+	require.NoError(t, adapter.Flags().Parse(nil))
+
+	assert.True(t, adapter.EnableMetricsInventory)
+	assert.True(t, adapter.EnableMetricsMetadata)
+	assert.Equal(t, DefaultConfigReloadInterval, adapter.ConfigReloadInterval)
+	assert.Equal(t, apiserver.DefaultHealthCheckCacheDuration, adapter.HealthCheckCacheDuration)
+
+	adapter = &AdapterBase{
+		FlagSet:                  pflag.NewFlagSet("test", pflag.ContinueOnError),
+		ConfigReloadInterval:     time.Minute,
+		HealthCheckCacheDuration: time.Second,
+	}
+	require.NoError(t, adapter.Flags().Parse(nil))
+
+	assert.Equal(t, time.Minute, adapter.ConfigReloadInterval, "the flags should default to the preset fields")
+	assert.Equal(t, time.Second, adapter.HealthCheckCacheDuration, "the flags should default to the preset fields")
+
+	adapter = &AdapterBase{FlagSet: pflag.NewFlagSet("test", pflag.ContinueOnError)}
+	require.NoError(t, adapter.Flags().Parse([]string{"--enable-metrics-inventory=false"}))
+
+	assert.False(t, adapter.EnableMetricsInventory)
+	assert.True(t, adapter.EnableMetricsMetadata)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Load reads and strictly decodes the configuration file at the given path.
+// Unknown or duplicate fields are errors, as are any apiVersion and kind
+// other than the supported ones.
+func Load(path string) (*AdapterConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read configuration file: %v", err)
+	}
+	cfg, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Decode strictly decodes a configuration file in YAML or JSON.
+func Decode(data []byte) (*AdapterConfiguration, error) {
+	cfg := &AdapterConfiguration{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, err
+	}
+
+	gvk := cfg.GroupVersionKind()
+	if gvk != SchemeGroupVersion.WithKind("AdapterConfiguration") {
+		return nil, fmt.Errorf("unsupported apiVersion %q and kind %q: expected %q and %q",
+			cfg.APIVersion, cfg.Kind, SchemeGroupVersion.String(), "AdapterConfiguration")
+	}
+	return cfg, nil
+}
+
+// ApplyTo sets the flags corresponding to the settings of the configuration.
+// Flags which were set on the command line are left untouched, so that they
+// take precedence over the configuration file.
+func (c *AdapterConfiguration) ApplyTo(fs *pflag.FlagSet) error {
+	values := map[string]string{}
+	setString := func(flag, value string) {
+		if value != "" {
+			values[flag] = value
+		}
+	}
+	setList := func(flag string, value []string) {
+		if len(value) > 0 {
+			values[flag] = strings.Join(value, ",")
+		}
+	}
+
+	setString("bind-address", c.Serving.BindAddress)
+	if c.Serving.SecurePort != nil {
+		values["secure-port"] = strconv.Itoa(int(*c.Serving.SecurePort))
+	}
+	setString("cert-dir", c.Serving.CertDir)
+	setString("tls-cert-file", c.Serving.TLSCertFile)
+	setString("tls-private-key-file", c.Serving.TLSPrivateKeyFile)
+	setString("tls-min-version", c.Serving.TLSMinVersion)
+	setList("tls-cipher-suites", c.Serving.TLSCipherSuites)
+
+	setString("authentication-kubeconfig", c.Authentication.Kubeconfig)
+	setString("client-ca-file", c.Authentication.ClientCAFile)
+	setString("requestheader-client-ca-file", c.Authentication.RequestHeaderClientCAFile)
+	if c.Authentication.SkipLookup != nil {
+		values["authentication-skip-lookup"] = strconv.FormatBool(*c.Authentication.SkipLookup)
+	}
+	if c.Authentication.TolerateLookupFailure != nil {
+		values["authentication-tolerate-lookup-failure"] = strconv.FormatBool(*c.Authentication.TolerateLookupFailure)
+	}
+
+	setString("authorization-kubeconfig", c.Authorization.Kubeconfig)
+	setList("authorization-always-allow-paths", c.Authorization.AlwaysAllowPaths)
+
+	setString("lister-kubeconfig", c.Client.Kubeconfig)
+	if c.Client.DiscoveryInterval != nil {
+		values["discovery-interval"] = c.Client.DiscoveryInterval.Duration.String()
+	}
+	if c.Client.QPS != nil {
+		values["client-qps"] = strconv.FormatFloat(float64(*c.Client.QPS), 'f', -1, 32)
+	}
+	if c.Client.Burst != nil {
+		values["client-burst"] = strconv.Itoa(int(*c.Client.Burst))
+	}
+
+	for name, value := range values {
+		flag := fs.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("the adapter has no --%s flag to configure", name)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value for --%s in the configuration file: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// DecodeProvider strictly decodes the provider-specific section of the
+// configuration into the given object.  It does nothing if the section is
+// absent.
+func (c *AdapterConfiguration) DecodeProvider(into interface{}) error {
+	if len(c.Provider.Raw) == 0 {
+		return nil
+	}
+	if err := yaml.UnmarshalStrict(c.Provider.Raw, into); err != nil {
+		return fmt.Errorf("invalid provider configuration: %v", err)
+	}
+	return nil
+}
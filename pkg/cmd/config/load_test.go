@@ -0,0 +1,112 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validConfig = `
+apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1
+kind: AdapterConfiguration
+serving:
+  securePort: 6443
+  tlsCipherSuites: [TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384]
+authentication:
+  skipLookup: true
+client:
+  kubeconfig: /etc/adapter/kubeconfig
+  discoveryInterval: 1m
+  qps: 12.5
+  burst: 20
+provider:
+  prometheusURL: http://prometheus:9090
+`
+
+func TestDecode(t *testing.T) {
+	cfg, err := Decode([]byte(validConfig))
+	require.NoError(t, err)
+	assert.Equal(t, int32(6443), *cfg.Serving.SecurePort)
+	assert.Equal(t, time.Minute, cfg.Client.DiscoveryInterval.Duration)
+	assert.True(t, *cfg.Authentication.SkipLookup)
+
+	for name, data := range map[string]string{
+		"unknown field":   "apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1\nkind: AdapterConfiguration\nserving:\n  port: 443\n",
+		"duplicate field": "apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1\nkind: AdapterConfiguration\nclient:\n  qps: 1\n  qps: 2\n",
+		"wrong type":      "apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1\nkind: AdapterConfiguration\nclient:\n  burst: many\n",
+		"wrong version":   "apiVersion: adapter.custom-metrics.config.k8s.io/v1\nkind: AdapterConfiguration\n",
+		"wrong kind":      "apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1\nkind: Configuration\n",
+		"missing kind":    "client:\n  qps: 1\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestApplyTo(t *testing.T) {
+	cfg, err := Decode([]byte(validConfig))
+	require.NoError(t, err)
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	securePort := fs.Int("secure-port", 443, "")
+	cipherSuites := fs.StringSlice("tls-cipher-suites", nil, "")
+	skipLookup := fs.Bool("authentication-skip-lookup", false, "")
+	kubeconfig := fs.String("lister-kubeconfig", "", "")
+	discoveryInterval := fs.Duration("discovery-interval", 0, "")
+	qps := fs.Float32("client-qps", 5, "")
+	burst := fs.Int("client-burst", 10, "")
+	require.NoError(t, fs.Parse([]string{"--client-qps=100"}))
+
+	require.NoError(t, cfg.ApplyTo(fs))
+	assert.Equal(t, 6443, *securePort)
+	assert.Equal(t, []string{"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384"}, *cipherSuites)
+	assert.True(t, *skipLookup)
+	assert.Equal(t, "/etc/adapter/kubeconfig", *kubeconfig)
+	assert.Equal(t, time.Minute, *discoveryInterval)
+	assert.Equal(t, float32(100), *qps, "flags set on the command line should take precedence")
+	assert.Equal(t, 20, *burst)
+
+	t.Run("missing flag", func(t *testing.T) {
+		assert.Error(t, cfg.ApplyTo(pflag.NewFlagSet("empty", pflag.ContinueOnError)))
+	})
+}
+
+func TestDecodeProvider(t *testing.T) {
+	cfg, err := Decode([]byte(validConfig))
+	require.NoError(t, err)
+
+	var providerConfig struct {
+		PrometheusURL string `json:"prometheusURL"`
+	}
+	require.NoError(t, cfg.DecodeProvider(&providerConfig))
+	assert.Equal(t, "http://prometheus:9090", providerConfig.PrometheusURL)
+
+	var strictConfig struct {
+		URL string `json:"url"`
+	}
+	assert.Error(t, cfg.DecodeProvider(&strictConfig), "unknown provider fields should be rejected")
+
+	empty := &AdapterConfiguration{}
+	assert.NoError(t, empty.DecodeProvider(&strictConfig))
+}
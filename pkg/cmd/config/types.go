@@ -0,0 +1,118 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config defines the configuration file format of metrics adapters
+// built with AdapterBase.
+package config
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the API group of the adapter configuration file.
+const GroupName = "adapter.custom-metrics.config.k8s.io"
+
+// SchemeGroupVersion is the only supported version of the configuration file.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// AdapterConfiguration configures a metrics adapter.  It is read from the file
+// given by the --config flag.
+//
+// Each setting has an equivalent flag.  Flags set on the command line take
+// precedence over the configuration file, which takes precedence over the
+// defaults of the flags.
+type AdapterConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Serving configures the secure serving of the metrics APIs.
+	Serving ServingConfiguration `json:"serving,omitempty"`
+
+	// Authentication configures the delegated authentication of requests.
+	Authentication AuthenticationConfiguration `json:"authentication,omitempty"`
+
+	// Authorization configures the delegated authorization of requests.
+	Authorization AuthorizationConfiguration `json:"authorization,omitempty"`
+
+	// Client configures the client used to list described objects and to
+	// discover the resources of the cluster.
+	Client ClientConfiguration `json:"client,omitempty"`
+
+	// Provider is the provider-specific section of the configuration.  It is
+	// not interpreted by the adapter library; adapters decode it with
+	// AdapterBase.ProviderConfig.
+	Provider runtime.RawExtension `json:"provider,omitempty"`
+}
+
+// ServingConfiguration configures the secure serving of the metrics APIs.
+type ServingConfiguration struct {
+	// BindAddress is the IP address on which to listen (--bind-address).
+	BindAddress string `json:"bindAddress,omitempty"`
+	// SecurePort is the port on which to serve HTTPS (--secure-port).
+	SecurePort *int32 `json:"securePort,omitempty"`
+	// CertDir is the directory holding the TLS certificates (--cert-dir).
+	CertDir string `json:"certDir,omitempty"`
+	// TLSCertFile is the file containing the serving certificate (--tls-cert-file).
+	TLSCertFile string `json:"tlsCertFile,omitempty"`
+	// TLSPrivateKeyFile is the file containing the serving key (--tls-private-key-file).
+	TLSPrivateKeyFile string `json:"tlsPrivateKeyFile,omitempty"`
+	// TLSMinVersion is the minimum TLS version supported (--tls-min-version).
+	TLSMinVersion string `json:"tlsMinVersion,omitempty"`
+	// TLSCipherSuites is the list of allowed cipher suites (--tls-cipher-suites).
+	TLSCipherSuites []string `json:"tlsCipherSuites,omitempty"`
+}
+
+// AuthenticationConfiguration configures the delegated authentication of requests.
+type AuthenticationConfiguration struct {
+	// Kubeconfig points at the server creating tokenreviews (--authentication-kubeconfig).
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// ClientCAFile is the CA bundle used to verify client certificates (--client-ca-file).
+	ClientCAFile string `json:"clientCAFile,omitempty"`
+	// RequestHeaderClientCAFile is the CA bundle used to verify the front proxy
+	// (--requestheader-client-ca-file).
+	RequestHeaderClientCAFile string `json:"requestHeaderClientCAFile,omitempty"`
+	// SkipLookup skips looking up the authentication configuration in the
+	// cluster (--authentication-skip-lookup).
+	SkipLookup *bool `json:"skipLookup,omitempty"`
+	// TolerateLookupFailure starts the server even if the authentication
+	// configuration can't be looked up (--authentication-tolerate-lookup-failure).
+	TolerateLookupFailure *bool `json:"tolerateLookupFailure,omitempty"`
+}
+
+// AuthorizationConfiguration configures the delegated authorization of requests.
+type AuthorizationConfiguration struct {
+	// Kubeconfig points at the server creating subjectaccessreviews (--authorization-kubeconfig).
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// AlwaysAllowPaths are paths which don't require authorization
+	// (--authorization-always-allow-paths).
+	AlwaysAllowPaths []string `json:"alwaysAllowPaths,omitempty"`
+}
+
+// ClientConfiguration configures the client used to list described objects
+// and to discover the resources of the cluster.
+type ClientConfiguration struct {
+	// Kubeconfig points at the cluster to list objects from (--lister-kubeconfig).
+	// The in-cluster configuration is used if empty.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// DiscoveryInterval is the interval at which to refresh API discovery
+	// information (--discovery-interval).
+	DiscoveryInterval *metav1.Duration `json:"discoveryInterval,omitempty"`
+	// QPS is the maximum QPS for the client-side throttle (--client-qps).
+	QPS *float32 `json:"qps,omitempty"`
+	// Burst is the maximum QPS burst for the client-side throttle (--client-burst).
+	Burst *int32 `json:"burst,omitempty"`
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+// leaderElectionConfig returns the configuration of the leader election,
+// or nil if it's disabled.
+func (b *AdapterBase) leaderElectionConfig() (*leaderelection.LeaderElectionConfig, error) {
+	if !b.LeaderElection.LeaderElect {
+		return nil, nil
+	}
+
+	lock, err := b.leaderElectionLock()
+	if err != nil {
+		return nil, err
+	}
+	return &leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            b.Name,
+		LeaseDuration:   b.LeaderElection.LeaseDuration.Duration,
+		RenewDeadline:   b.LeaderElection.RenewDeadline.Duration,
+		RetryPeriod:     b.LeaderElection.RetryPeriod.Duration,
+		ReleaseOnCancel: true,
+	}, nil
+}
+
+// runLeaderRunnables runs the leader-only runnables until the context is
+// done, taking part in the given leader election if it's not nil.  The errors
+// of the runnables are passed to onError.  Replicas without leader-only
+// runnables don't take part in the election.
+func (b *AdapterBase) runLeaderRunnables(ctx context.Context, config *leaderelection.LeaderElectionConfig, onError func(error)) error {
+	if len(b.leaderRunnables) == 0 {
+		return nil
+	}
+	if config == nil {
+		startRunnables(ctx, b.leaderRunnables, onError)()
+		return nil
+	}
+	return runLeaderElection(ctx, *config, b.leaderRunnables, onError)
+}
+
+// leaderElectionLock returns the resource lock of the leader election, which
+// is accessed with the client configuration of the adapter.
+func (b *AdapterBase) leaderElectionLock() (resourcelock.Interface, error) {
+	clientConfig, err := b.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get hostname for leader election: %v", err)
+	}
+	// add a unique suffix so that two processes on the same host don't
+	// accidentally both become active
+	identity := hostname + "_" + string(uuid.NewUUID())
+
+	return resourcelock.NewFromKubeconfig(
+		b.LeaderElection.ResourceLock,
+		b.LeaderElection.ResourceNamespace,
+		b.LeaderElection.ResourceName,
+		resourcelock.ResourceLockConfig{Identity: identity},
+		rest.AddUserAgent(clientConfig, "leader-election"),
+		b.LeaderElection.RenewDeadline.Duration,
+	)
+}
+
+// runLeaderElection takes part in the leader election until the context is
+// done, running the runnables while leading.  Unlike most components, the
+// adapter keeps running when it loses the leadership: the runnables are
+// stopped and the replica becomes a candidate again.
+func runLeaderElection(ctx context.Context, config leaderelection.LeaderElectionConfig, runnables []Runnable, onError func(error)) error {
+	for ctx.Err() == nil {
+		// the runnables are started asynchronously by the elector, so make
+		// sure they are either waited for, or not started at all
+		var mu sync.Mutex
+		var running sync.WaitGroup
+		stopped := false
+
+		config.Callbacks = leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				mu.Lock()
+				if stopped {
+					mu.Unlock()
+					return
+				}
+				running.Add(1)
+				mu.Unlock()
+				defer running.Done()
+
+				klog.InfoS("Started leading, starting leader-only tasks", "lock", config.Lock.Describe())
+				startRunnables(ctx, runnables, onError)()
+			},
+			OnStoppedLeading: func() {
+				klog.InfoS("Stopped leading, stopping leader-only tasks", "lock", config.Lock.Describe())
+			},
+		}
+
+		elector, err := leaderelection.NewLeaderElector(config)
+		if err != nil {
+			return fmt.Errorf("unable to set up leader election: %v", err)
+		}
+		elector.Run(ctx)
+
+		mu.Lock()
+		stopped = true
+		mu.Unlock()
+		running.Wait()
+	}
+	return nil
+}
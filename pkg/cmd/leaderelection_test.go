@@ -0,0 +1,104 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestRunLeaderElection(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	newConfig := func(identity string) leaderelection.LeaderElectionConfig {
+		return leaderelection.LeaderElectionConfig{
+			Lock: &resourcelock.LeaseLock{
+				LeaseMeta:  metav1.ObjectMeta{Namespace: "kube-system", Name: "test-adapter"},
+				Client:     client.CoordinationV1(),
+				LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+			},
+			LeaseDuration:   2 * time.Second,
+			RenewDeadline:   time.Second,
+			RetryPeriod:     100 * time.Millisecond,
+			ReleaseOnCancel: true,
+		}
+	}
+
+	var running, started atomic.Int32
+	leaderTask := RunnableFunc(func(ctx context.Context) error {
+		started.Add(1)
+		running.Add(1)
+		defer running.Add(-1)
+		<-ctx.Done()
+		return nil
+	})
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	doneA := make(chan error)
+	go func() { doneA <- runLeaderElection(ctxA, newConfig("a"), []Runnable{leaderTask}, nil) }()
+	require.NoError(t, wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		return running.Load() == 1, nil
+	}), "the first candidate should become leader")
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	doneB := make(chan error)
+	go func() { doneB <- runLeaderElection(ctxB, newConfig("b"), []Runnable{leaderTask}, nil) }()
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int32(1), running.Load(), "only the leader should run leader-only tasks")
+
+	// the lease is released on shutdown, so the other candidate takes over
+	cancelA()
+	require.NoError(t, <-doneA)
+	require.NoError(t, wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		return started.Load() == 2 && running.Load() == 1, nil
+	}), "the second candidate should take over")
+
+	cancelB()
+	require.NoError(t, <-doneB)
+	assert.Equal(t, int32(0), running.Load(), "leader-only tasks should be stopped on shutdown")
+}
+
+func TestRunLeaderRunnablesWithoutElection(t *testing.T) {
+	var ran atomic.Bool
+	adapter := &AdapterBase{}
+	adapter.WithLeaderRunnable(RunnableFunc(func(ctx context.Context) error {
+		ran.Store(true)
+		return nil
+	}))
+
+	require.NoError(t, adapter.runLeaderRunnables(context.Background(), nil, nil))
+	assert.True(t, ran.Load(), "leader-only tasks should run on every replica without leader election")
+}
+
+func TestRunLeaderRunnablesWithoutRunnables(t *testing.T) {
+	adapter := &AdapterBase{}
+
+	// the configuration has no lock, so taking part in the election would
+	// fail
+	require.NoError(t, adapter.runLeaderRunnables(context.Background(), &leaderelection.LeaderElectionConfig{}, nil))
+}
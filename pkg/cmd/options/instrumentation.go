@@ -0,0 +1,76 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
+)
+
+// InstrumentationOptions contains the options used to bound the cardinality
+// of the per-metric instrumentation of the adapter.
+type InstrumentationOptions struct {
+	MetricAllowlist   []string
+	MaxLabeledMetrics int
+}
+
+// NewInstrumentationOptions creates a new instance of InstrumentationOptions
+// with its default values.
+func NewInstrumentationOptions() *InstrumentationOptions {
+	return &InstrumentationOptions{
+		MaxLabeledMetrics: metrics.DefaultMaxLabeledMetrics,
+	}
+}
+
+// AddFlags adds the flags defined for the options, to the given flagset.
+func (o *InstrumentationOptions) AddFlags(fs *pflag.FlagSet) {
+	if o == nil {
+		return
+	}
+
+	fs.StringSliceVar(&o.MetricAllowlist, "instrumented-metrics", o.MetricAllowlist,
+		"Names of the metrics which get their own labels in the per-metric instrumentation of the adapter. "+
+			"If empty, the first --max-instrumented-metrics resource and metric pairs to be served successfully do.")
+	fs.IntVar(&o.MaxLabeledMetrics, "max-instrumented-metrics", o.MaxLabeledMetrics,
+		"Maximum number of distinct resource and metric pairs which get their own labels in the per-metric "+
+			"instrumentation of the adapter, when --instrumented-metrics is not set. Pairs are admitted in the order "+
+			"they are first served successfully, and the others are reported together. Zero reports every metric together.")
+}
+
+// Validate validates InstrumentationOptions
+func (o *InstrumentationOptions) Validate() []error {
+	if o == nil {
+		return nil
+	}
+
+	errors := []error{}
+	if o.MaxLabeledMetrics < 0 {
+		errors = append(errors, fmt.Errorf("--max-instrumented-metrics must not be negative"))
+	}
+	return errors
+}
+
+// LabelGuard returns the guard bounding the per-metric instrumentation labels.
+func (o *InstrumentationOptions) LabelGuard() *metrics.LabelGuard {
+	if o == nil {
+		return metrics.NewLabelGuard(nil, metrics.DefaultMaxLabeledMetrics)
+	}
+	return metrics.NewLabelGuard(o.MetricAllowlist, o.MaxLabeledMetrics)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	componentbaseconfig "k8s.io/component-base/config"
+	componentbaseoptions "k8s.io/component-base/config/options"
+	"k8s.io/component-base/config/validation"
+)
+
+// LeaderElectionOptions contains the options used to elect the replica of
+// the adapter which runs the leader-only background tasks.
+type LeaderElectionOptions struct {
+	componentbaseconfig.LeaderElectionConfiguration
+}
+
+// NewLeaderElectionOptions creates a new instance of LeaderElectionOptions
+// with its default values, which disable leader election.  The lock defaults
+// to a Lease with the given name.
+func NewLeaderElectionOptions(name string) *LeaderElectionOptions {
+	return &LeaderElectionOptions{
+		LeaderElectionConfiguration: componentbaseconfig.LeaderElectionConfiguration{
+			LeaderElect:       false,
+			LeaseDuration:     metav1.Duration{Duration: 15 * time.Second},
+			RenewDeadline:     metav1.Duration{Duration: 10 * time.Second},
+			RetryPeriod:       metav1.Duration{Duration: 2 * time.Second},
+			ResourceLock:      resourcelock.LeasesResourceLock,
+			ResourceName:      name,
+			ResourceNamespace: metav1.NamespaceSystem,
+		},
+	}
+}
+
+// AddFlags adds the flags defined for the options, to the given flagset.
+func (o *LeaderElectionOptions) AddFlags(fs *pflag.FlagSet) {
+	if o == nil {
+		return
+	}
+
+	componentbaseoptions.BindLeaderElectionFlags(&o.LeaderElectionConfiguration, fs)
+}
+
+// Validate validates LeaderElectionOptions
+func (o *LeaderElectionOptions) Validate() []error {
+	if o == nil {
+		return nil
+	}
+
+	errs := validation.ValidateLeaderElectionConfiguration(&o.LeaderElectionConfiguration, field.NewPath("leaderElection"))
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs.ToAggregate().Errors()
+}
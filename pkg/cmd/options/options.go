@@ -34,11 +34,14 @@ import (
 //
 // It is based on a subset of [genericoptions.RecommendedOptions].
 type CustomMetricsAdapterServerOptions struct {
-	SecureServing  *genericoptions.SecureServingOptionsWithLoopback
-	Authentication *genericoptions.DelegatingAuthenticationOptions
-	Authorization  *genericoptions.DelegatingAuthorizationOptions
-	Audit          *genericoptions.AuditOptions
-	Features       *genericoptions.FeatureOptions
+	SecureServing   *genericoptions.SecureServingOptionsWithLoopback
+	Authentication  *genericoptions.DelegatingAuthenticationOptions
+	Authorization   *genericoptions.DelegatingAuthorizationOptions
+	Audit           *genericoptions.AuditOptions
+	Features        *genericoptions.FeatureOptions
+	Tracing         *genericoptions.TracingOptions
+	Staleness       *StalenessOptions
+	Instrumentation *InstrumentationOptions
 
 	OpenAPIConfig   *openapicommon.Config
 	OpenAPIV3Config *openapicommon.OpenAPIV3Config
@@ -49,11 +52,14 @@ type CustomMetricsAdapterServerOptions struct {
 // CustomMetricsAdapterServerOptions with its default values.
 func NewCustomMetricsAdapterServerOptions() *CustomMetricsAdapterServerOptions {
 	o := &CustomMetricsAdapterServerOptions{
-		SecureServing:  genericoptions.NewSecureServingOptions().WithLoopback(),
-		Authentication: genericoptions.NewDelegatingAuthenticationOptions(),
-		Authorization:  genericoptions.NewDelegatingAuthorizationOptions(),
-		Audit:          genericoptions.NewAuditOptions(),
-		Features:       genericoptions.NewFeatureOptions(),
+		SecureServing:   genericoptions.NewSecureServingOptions().WithLoopback(),
+		Authentication:  genericoptions.NewDelegatingAuthenticationOptions(),
+		Authorization:   genericoptions.NewDelegatingAuthorizationOptions(),
+		Audit:           genericoptions.NewAuditOptions(),
+		Features:        genericoptions.NewFeatureOptions(),
+		Tracing:         genericoptions.NewTracingOptions(),
+		Staleness:       NewStalenessOptions(),
+		Instrumentation: NewInstrumentationOptions(),
 
 		EnableMetrics: true,
 	}
@@ -73,6 +79,9 @@ func (o CustomMetricsAdapterServerOptions) Validate() []error {
 	errors = append(errors, o.Authorization.Validate()...)
 	errors = append(errors, o.Audit.Validate()...)
 	errors = append(errors, o.Features.Validate()...)
+	errors = append(errors, o.Tracing.Validate()...)
+	errors = append(errors, o.Staleness.Validate()...)
+	errors = append(errors, o.Instrumentation.Validate()...)
 	return errors
 }
 
@@ -83,6 +92,9 @@ func (o *CustomMetricsAdapterServerOptions) AddFlags(fs *pflag.FlagSet) {
 	o.Authorization.AddFlags(fs)
 	o.Audit.AddFlags(fs)
 	o.Features.AddFlags(fs)
+	o.Tracing.AddFlags(fs)
+	o.Staleness.AddFlags(fs)
+	o.Instrumentation.AddFlags(fs)
 }
 
 // ApplyTo applies CustomMetricsAdapterServerOptions to the server configuration.
@@ -113,6 +125,9 @@ func (o *CustomMetricsAdapterServerOptions) ApplyTo(serverConfig *genericapiserv
 	if err := o.Features.ApplyTo(&serverConfig.Config, clientset, serverConfig.SharedInformerFactory); err != nil {
 		return err
 	}
+	if err := o.Tracing.ApplyTo(nil, &serverConfig.Config); err != nil {
+		return err
+	}
 
 	// enable OpenAPI schemas
 	if o.OpenAPIConfig != nil {
@@ -61,6 +61,21 @@ func TestValidate(t *testing.T) {
 			args:      []string{"--secure-port=6443", "--audit-log-path=file", "--audit-log-format=txt"},
 			shouldErr: true,
 		},
+		{
+			testName:  "metric-max-age",
+			args:      []string{"--secure-port=6443", "--metric-max-age=5m", "--metric-max-age-overrides=foo=30s,bar=0s", "--stale-metric-action=Reject"},
+			shouldErr: false,
+		},
+		{
+			testName:  "invalid-metric-max-age-override",
+			args:      []string{"--secure-port=6443", "--metric-max-age-overrides=foo=soon"},
+			shouldErr: true,
+		},
+		{
+			testName:  "invalid-stale-metric-action",
+			args:      []string{"--secure-port=6443", "--metric-max-age=5m", "--stale-metric-action=Ignore"},
+			shouldErr: true,
+		},
 	}
 
 	for _, c := range cases {
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/registry/staleness"
+)
+
+// StalenessOptions contains the options used to configure the maximum age
+// of the metric values served by the adapter.
+type StalenessOptions struct {
+	MaxAge          time.Duration
+	MaxAgePerMetric map[string]string
+	Action          string
+}
+
+// NewStalenessOptions creates a new instance of StalenessOptions with its
+// default values, which serve values of any age.
+func NewStalenessOptions() *StalenessOptions {
+	return &StalenessOptions{
+		MaxAgePerMetric: map[string]string{},
+		Action:          string(staleness.ActionDrop),
+	}
+}
+
+// AddFlags adds the flags defined for the options, to the given flagset.
+func (o *StalenessOptions) AddFlags(fs *pflag.FlagSet) {
+	if o == nil {
+		return
+	}
+
+	fs.DurationVar(&o.MaxAge, "metric-max-age", o.MaxAge,
+		"Maximum age of the metric values served to clients. Zero means no limit.")
+	fs.StringToStringVar(&o.MaxAgePerMetric, "metric-max-age-overrides", o.MaxAgePerMetric,
+		"Maximum age of the values of specific metrics, as a list of metric=duration pairs, "+
+			"overriding --metric-max-age. A duration of zero means no limit.")
+	fs.StringVar(&o.Action, "stale-metric-action", o.Action,
+		fmt.Sprintf("What to do with metric values older than their maximum age: %q removes them from responses, "+
+			"%q fails the request.", staleness.ActionDrop, staleness.ActionReject))
+}
+
+// Validate validates StalenessOptions
+func (o *StalenessOptions) Validate() []error {
+	if o == nil {
+		return nil
+	}
+
+	errors := []error{}
+	if o.MaxAge < 0 {
+		errors = append(errors, fmt.Errorf("--metric-max-age must not be negative"))
+	}
+	for metric, maxAge := range o.MaxAgePerMetric {
+		d, err := time.ParseDuration(maxAge)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("invalid maximum age %q for metric %s: %v", maxAge, metric, err))
+		} else if d < 0 {
+			errors = append(errors, fmt.Errorf("maximum age for metric %s must not be negative", metric))
+		}
+	}
+	switch staleness.Action(o.Action) {
+	case staleness.ActionDrop, staleness.ActionReject:
+	default:
+		errors = append(errors, fmt.Errorf("--stale-metric-action must be one of %q or %q", staleness.ActionDrop, staleness.ActionReject))
+	}
+	return errors
+}
+
+// Policy returns the staleness policy described by the options, or nil if
+// values of any age should be served.
+func (o *StalenessOptions) Policy() (*staleness.Policy, error) {
+	if o == nil || (o.MaxAge == 0 && len(o.MaxAgePerMetric) == 0) {
+		return nil, nil
+	}
+
+	policy := &staleness.Policy{
+		MaxAge:          o.MaxAge,
+		MaxAgePerMetric: make(map[string]time.Duration, len(o.MaxAgePerMetric)),
+		Action:          staleness.Action(o.Action),
+	}
+	for metric, maxAge := range o.MaxAgePerMetric {
+		d, err := time.ParseDuration(maxAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maximum age %q for metric %s: %v", maxAge, metric, err)
+		}
+		policy.MaxAgePerMetric[metric] = d
+	}
+	return policy, nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// DefaultConfigReloadInterval is the default interval at which configuration
+// files are checked for changes.
+const DefaultConfigReloadInterval = 10 * time.Second
+
+// Reasons of the events recorded when reloading a configuration file.
+const (
+	ConfigReloadedReason        = "ConfigReloaded"
+	ConfigReloadFailedReason    = "ConfigReloadFailed"
+	ConfigRestartRequiredReason = "ConfigRestartRequired"
+)
+
+// ConfigWatcher checks a configuration file for changes, and hands the new
+// configuration over to a provider.ReloadableProvider when it changes.
+//
+// The file is polled rather than watched with file system notifications, as
+// files mounted from ConfigMaps are updated by swapping symlinks, which
+// notifications don't reliably report.
+type ConfigWatcher struct {
+	// Path is the path of the configuration file.
+	Path string
+	// Interval is the interval at which the file is checked for changes.
+	// It defaults to DefaultConfigReloadInterval.
+	Interval time.Duration
+	// Extract returns the configuration to hand over to the provider from
+	// the contents of the file.  If nil, the whole file is handed over.
+	Extract func(data []byte) ([]byte, error)
+	// Ignored, if set, returns the names of the settings which differ
+	// between two versions of the file, but aren't part of the configuration
+	// handed over to the provider, and so only apply after a restart.
+	Ignored func(previous, current []byte) []string
+	// Recorder, if set, records an event about EventObject for each reload.
+	Recorder    record.EventRecorder
+	EventObject *corev1.ObjectReference
+
+	provider provider.ReloadableProvider
+	observer metrics.ReloadObserver
+
+	// last holds the contents of the file on the last check, whether they
+	// were accepted or not, so that an invalid configuration is only
+	// reported once.
+	last []byte
+	// config holds the configuration the provider last accepted, so that
+	// changes to the rest of the file don't reload it.
+	config []byte
+}
+
+// NewConfigWatcher creates a ConfigWatcher reloading the given provider when
+// the file at the given path changes.
+func NewConfigWatcher(path string, reloadable provider.ReloadableProvider) *ConfigWatcher {
+	return &ConfigWatcher{
+		Path:     path,
+		provider: reloadable,
+		observer: metrics.NewReloadObserver(),
+	}
+}
+
+// Run checks the configuration file for changes until the context is done.
+// The configuration found on the first check is assumed to be the one the
+// provider was started with.
+func (w *ConfigWatcher) Run(ctx context.Context) {
+	interval := w.Interval
+	if interval == 0 {
+		interval = DefaultConfigReloadInterval
+	}
+
+	if data, err := os.ReadFile(w.Path); err != nil {
+		klog.ErrorS(err, "Unable to read configuration file", "path", w.Path)
+	} else {
+		w.last = data
+		if config, err := w.extract(data); err == nil {
+			w.config = config
+		}
+	}
+
+	wait.UntilWithContext(ctx, w.check, interval)
+}
+
+// check reloads the provider if its configuration changed since the last check.
+func (w *ConfigWatcher) check(ctx context.Context) {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		// the file may be in the middle of being replaced, so wait for the
+		// next check before reporting anything
+		klog.V(4).InfoS("Unable to read configuration file", "path", w.Path, "err", err)
+		return
+	}
+	if w.last != nil && bytes.Equal(data, w.last) {
+		return
+	}
+	previous := w.last
+	w.last = data
+
+	if previous != nil && w.Ignored != nil {
+		if ignored := w.Ignored(previous, data); len(ignored) > 0 {
+			klog.InfoS("Configuration changes require a restart to apply", "path", w.Path, "settings", ignored)
+			w.event(corev1.EventTypeWarning, ConfigRestartRequiredReason, "Changes to %s in %s only apply after a restart", strings.Join(ignored, ", "), w.Path)
+		}
+	}
+
+	config, err := w.extract(data)
+	if err == nil && w.config != nil && bytes.Equal(config, w.config) {
+		// the configuration of the provider didn't change
+		return
+	}
+	if err == nil {
+		err = w.provider.Reload(ctx, config)
+	}
+
+	w.observer.ObserveReload(err)
+	if err != nil {
+		klog.ErrorS(err, "Unable to reload configuration, keeping the previous one", "path", w.Path)
+		w.event(corev1.EventTypeWarning, ConfigReloadFailedReason, "Unable to reload %s, keeping the previous configuration: %v", w.Path, err)
+		return
+	}
+	w.config = config
+	klog.InfoS("Reloaded configuration", "path", w.Path)
+	w.event(corev1.EventTypeNormal, ConfigReloadedReason, "Reloaded %s", w.Path)
+}
+
+// extract returns the configuration to hand over to the provider.  It is
+// never nil on success, so that an empty configuration can be told apart
+// from one which was never extracted.
+func (w *ConfigWatcher) extract(data []byte) ([]byte, error) {
+	if w.Extract == nil {
+		return data, nil
+	}
+	config, err := w.Extract(data)
+	if err == nil && config == nil {
+		config = []byte{}
+	}
+	return config, err
+}
+
+func (w *ConfigWatcher) event(eventType, reason, messageFmt string, args ...interface{}) {
+	if w.Recorder == nil || w.EventObject == nil {
+		return
+	}
+	w.Recorder.Eventf(w.EventObject, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/fake"
+)
+
+type reloadableProvider struct {
+	configs []string
+	err     error
+}
+
+func (p *reloadableProvider) Reload(_ context.Context, config []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.configs = append(p.configs, string(config))
+	return nil
+}
+
+type reloadableMetricsProvider struct {
+	provider.MetricsProvider
+	*reloadableProvider
+}
+
+func TestConfigWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	write := func(data string) {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	}
+	write("initial")
+
+	prov := &reloadableProvider{}
+	recorder := record.NewFakeRecorder(10)
+	watcher := NewConfigWatcher(path, prov)
+	watcher.Recorder = recorder
+	watcher.EventObject = &corev1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "adapter"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// with a cancelled context, Run only records the initial configuration
+	watcher.Run(ctx)
+
+	watcher.check(context.Background())
+	assert.Empty(t, prov.configs, "unchanged configurations should not be reloaded")
+
+	write("updated")
+	watcher.check(context.Background())
+	watcher.check(context.Background())
+	assert.Equal(t, []string{"updated"}, prov.configs)
+	assert.Contains(t, <-recorder.Events, ConfigReloadedReason)
+
+	prov.err = errors.New("invalid rule")
+	write("invalid")
+	watcher.check(context.Background())
+	watcher.check(context.Background())
+	assert.Equal(t, []string{"updated"}, prov.configs, "the previous configuration should be kept")
+	assert.Contains(t, <-recorder.Events, ConfigReloadFailedReason)
+	assert.Empty(t, recorder.Events, "an invalid configuration should only be reported once")
+
+	prov.err = nil
+	require.NoError(t, os.Remove(path))
+	watcher.check(context.Background())
+	assert.Empty(t, recorder.Events, "missing files should be waited for")
+
+	write("fixed")
+	watcher.check(context.Background())
+	assert.Equal(t, []string{"updated", "fixed"}, prov.configs)
+}
+
+func TestConfigWatcherExtract(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(data string) {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	}
+	write(`
+apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1
+kind: AdapterConfiguration
+provider:
+  rules: [a]
+`)
+
+	prov := &reloadableProvider{}
+	adapter := &AdapterBase{ConfigFile: path, ConfigReloadInterval: DefaultConfigReloadInterval}
+	adapter.WithCustomMetrics(&reloadableMetricsProvider{MetricsProvider: fake.NewProvider(), reloadableProvider: prov})
+	watchers, err := adapter.configWatchers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, watchers, 1)
+	watcher := watchers[0]
+
+	write(`
+apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1
+kind: AdapterConfiguration
+provider:
+  rules: [a, b]
+`)
+	watcher.check(context.Background())
+	assert.Equal(t, []string{`{"rules":["a","b"]}`}, prov.configs)
+
+	recorder := record.NewFakeRecorder(10)
+	watcher.Recorder = recorder
+	watcher.EventObject = &corev1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "adapter"}
+	write(`
+apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1
+kind: AdapterConfiguration
+serving:
+  securePort: 8443
+client:
+  qps: 50
+provider:
+  rules: [a, b]
+`)
+	watcher.check(context.Background())
+	assert.Len(t, prov.configs, 1, "changes outside of the provider section should not reload the provider")
+	assert.Equal(t, "Warning "+ConfigRestartRequiredReason+" Changes to serving, client in "+path+" only apply after a restart", <-recorder.Events)
+	assert.Empty(t, recorder.Events)
+
+	write(`
+apiVersion: adapter.custom-metrics.config.k8s.io/v1alpha1
+kind: AdapterConfiguration
+unknown: true
+`)
+	watcher.check(context.Background())
+	assert.Len(t, prov.configs, 1, "invalid configuration files should not be handed over")
+}
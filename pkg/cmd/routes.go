@@ -0,0 +1,158 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+)
+
+// RouteAccess controls who may access an extra route mounted on the secure
+// server with WithWebService or WithNonGoRestfulHandler.
+type RouteAccess int
+
+const (
+	// RouteAuthorized routes are only served to users authorized by the
+	// delegated authorizer, for the non-resource URL of the request and the
+	// lowercased HTTP method as verb.  This is the default.
+	RouteAuthorized RouteAccess = iota
+	// RouteAuthenticated routes are served to any authenticated user.
+	RouteAuthenticated
+	// RoutePublic routes are served to anyone, including anonymous users.
+	// Anonymous authentication must be enabled, which is the default.
+	RoutePublic
+)
+
+// RouteOption configures an extra route.
+type RouteOption func(*route)
+
+// WithRouteAccess sets who may access an extra route.
+func WithRouteAccess(access RouteAccess) RouteOption {
+	return func(r *route) {
+		r.access = access
+	}
+}
+
+// route is an extra route mounted on the secure server.
+type route struct {
+	// path is the path of the route, which also matches the paths below it
+	// when prefix is set.
+	path   string
+	prefix bool
+	access RouteAccess
+
+	webService *restful.WebService
+	handler    http.Handler
+}
+
+func newRoute(path string, prefix bool, opts []RouteOption) route {
+	r := route{path: path, prefix: prefix}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+func (r *route) matches(path string) bool {
+	if path == r.path {
+		return true
+	}
+	return r.prefix && strings.HasPrefix(path, strings.TrimSuffix(r.path, "/")+"/")
+}
+
+// WithWebService mounts the given web service on the secure server, next to
+// the metrics APIs.  Requests to its routes go through the same authentication
+// and authorization as the metrics APIs, unless relaxed with WithRouteAccess.
+// It must be called before Server or Run.
+func (b *AdapterBase) WithWebService(ws *restful.WebService, opts ...RouteOption) {
+	r := newRoute(ws.RootPath(), true, opts)
+	r.webService = ws
+	b.routes = append(b.routes, r)
+}
+
+// WithNonGoRestfulHandler mounts the given handler on the secure server at
+// the given path, or below it if the path ends with a slash.  Requests to it
+// go through the same authentication and authorization as the metrics APIs,
+// unless relaxed with WithRouteAccess.  It must be called before Server or Run.
+func (b *AdapterBase) WithNonGoRestfulHandler(path string, handler http.Handler, opts ...RouteOption) {
+	r := newRoute(path, strings.HasSuffix(path, "/"), opts)
+	r.handler = handler
+	b.routes = append(b.routes, r)
+}
+
+// installRoutes mounts the extra routes on the given server handler.
+func installRoutes(handler *genericapiserver.APIServerHandler, routes []route) {
+	for _, r := range routes {
+		switch {
+		case r.webService != nil:
+			handler.GoRestfulContainer.Add(r.webService)
+		case r.prefix:
+			handler.NonGoRestfulMux.HandlePrefix(r.path, r.handler)
+		default:
+			handler.NonGoRestfulMux.Handle(r.path, r.handler)
+		}
+	}
+}
+
+// routeAuthorizer allows the requests to the extra routes which don't need
+// to be authorized by the delegated authorizer, and has no opinion about the
+// other requests.
+type routeAuthorizer struct {
+	routes *[]route
+}
+
+var _ authorizer.Authorizer = routeAuthorizer{}
+
+func (a routeAuthorizer) Authorize(_ context.Context, attrs authorizer.Attributes) (authorizer.Decision, string, error) {
+	if attrs.IsResourceRequest() {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	for _, r := range *a.routes {
+		if !r.matches(attrs.GetPath()) {
+			continue
+		}
+		switch r.access {
+		case RoutePublic:
+			return authorizer.DecisionAllow, "", nil
+		case RouteAuthenticated:
+			if isAuthenticated(attrs.GetUser()) {
+				return authorizer.DecisionAllow, "", nil
+			}
+		}
+	}
+	return authorizer.DecisionNoOpinion, "", nil
+}
+
+func isAuthenticated(u user.Info) bool {
+	if u == nil {
+		return false
+	}
+	for _, group := range u.GetGroups() {
+		if group == user.AllAuthenticated {
+			return true
+		}
+	}
+	return false
+}
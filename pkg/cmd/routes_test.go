@@ -0,0 +1,124 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver"
+)
+
+func TestInstallRoutes(t *testing.T) {
+	adapter := &AdapterBase{}
+
+	ws := new(restful.WebService)
+	ws.Path("/write-metrics")
+	ws.Route(ws.POST("/{metric}").To(func(req *restful.Request, resp *restful.Response) {
+		_, _ = resp.Write([]byte("wrote " + req.PathParameter("metric")))
+	}))
+	adapter.WithWebService(ws)
+	adapter.WithNonGoRestfulHandler("/debug/state", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("state"))
+	}))
+	adapter.WithNonGoRestfulHandler("/debug/files/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(req.URL.Path))
+	}))
+
+	handler := genericapiserver.NewAPIServerHandler("test", apiserver.Codecs, func(h http.Handler) http.Handler { return h }, http.NotFoundHandler())
+	installRoutes(handler, adapter.routes)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	for path, expected := range map[string]string{
+		"/write-metrics/some-metric": "wrote some-metric",
+		"/debug/state":               "state",
+		"/debug/files/a/b":           "/debug/files/a/b",
+	} {
+		resp, err := http.Post(server.URL+path, "application/json", nil)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, expected, string(body), path)
+	}
+}
+
+func TestRouteAuthorizer(t *testing.T) {
+	adapter := &AdapterBase{}
+	adapter.WithWebService(new(restful.WebService).Path("/write-metrics"), WithRouteAccess(RoutePublic))
+	adapter.WithNonGoRestfulHandler("/debug/", http.NotFoundHandler(), WithRouteAccess(RouteAuthenticated))
+	adapter.WithNonGoRestfulHandler("/admin", http.NotFoundHandler())
+	authz := routeAuthorizer{routes: &adapter.routes}
+
+	anonymous := &user.DefaultInfo{Name: user.Anonymous, Groups: []string{user.AllUnauthenticated}}
+	authenticated := &user.DefaultInfo{Name: "jane", Groups: []string{user.AllAuthenticated}}
+
+	cases := map[string]struct {
+		attrs    authorizer.AttributesRecord
+		expected authorizer.Decision
+	}{
+		"public route": {
+			attrs:    authorizer.AttributesRecord{User: anonymous, Verb: "post", Path: "/write-metrics/namespaces/default/pods/foo/metric"},
+			expected: authorizer.DecisionAllow,
+		},
+		"public route root": {
+			attrs:    authorizer.AttributesRecord{User: anonymous, Verb: "get", Path: "/write-metrics"},
+			expected: authorizer.DecisionAllow,
+		},
+		"similar path": {
+			attrs:    authorizer.AttributesRecord{User: anonymous, Verb: "get", Path: "/write-metrics-other"},
+			expected: authorizer.DecisionNoOpinion,
+		},
+		"authenticated route for anonymous user": {
+			attrs:    authorizer.AttributesRecord{User: anonymous, Verb: "get", Path: "/debug/state"},
+			expected: authorizer.DecisionNoOpinion,
+		},
+		"authenticated route for authenticated user": {
+			attrs:    authorizer.AttributesRecord{User: authenticated, Verb: "get", Path: "/debug/state"},
+			expected: authorizer.DecisionAllow,
+		},
+		"authorized route": {
+			attrs:    authorizer.AttributesRecord{User: authenticated, Verb: "post", Path: "/admin"},
+			expected: authorizer.DecisionNoOpinion,
+		},
+		"resource request": {
+			attrs:    authorizer.AttributesRecord{User: anonymous, Verb: "get", Resource: "pods", ResourceRequest: true, Path: "/write-metrics/pods"},
+			expected: authorizer.DecisionNoOpinion,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			decision, _, err := authz.Authorize(context.Background(), tc.attrs)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, decision)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// Runnable is a background task run by the adapter alongside the API server,
+// such as a collector feeding the provider.  Providers implementing Runnable
+// are run like the tasks registered with WithRunnable.
+//
+// Runnables are started before the server starts serving.  When the adapter
+// stops, their context is cancelled, and Run waits for them to return.  If a
+// runnable returns an error, the adapter stops and Run returns the error.
+type Runnable interface {
+	// Start runs the task until the context is done.  It should return nil,
+	// or the context error, once it stopped because the context is done.
+	Start(ctx context.Context) error
+}
+
+// RunnableFunc adapts a function to the Runnable interface.
+type RunnableFunc func(ctx context.Context) error
+
+// Start calls f(ctx).
+func (f RunnableFunc) Start(ctx context.Context) error {
+	return f(ctx)
+}
+
+// WithRunnable registers a task to run on every replica of the adapter, from
+// the time it starts running until it stops.
+func (b *AdapterBase) WithRunnable(r Runnable) {
+	b.runnables = append(b.runnables, r)
+}
+
+// WithLeaderRunnable registers a task to run only on the replica of the
+// adapter which is elected leader, while it is.  When leader election is
+// disabled, the task runs on every replica like the ones registered with
+// WithRunnable.  Serving is not affected by the outcome of the election.
+func (b *AdapterBase) WithLeaderRunnable(r Runnable) {
+	b.leaderRunnables = append(b.leaderRunnables, r)
+}
+
+// runnableProviders returns the providers of the adapter which need to run
+// background tasks, looking through the wrappers which don't run any.
+func (b *AdapterBase) runnableProviders() []Runnable {
+	var runnables []Runnable
+	if r, ok := provider.As[Runnable](b.cmProvider); ok {
+		runnables = append(runnables, r)
+	}
+	if r, ok := provider.As[Runnable](b.emProvider); ok && !provider.SameProvider(b.cmProvider, b.emProvider) {
+		runnables = append(runnables, r)
+	}
+	return runnables
+}
+
+// runGroup runs background tasks.  When any of them fails, the others are
+// stopped, and the first error is returned once all of them returned.
+type runGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+func newRunGroup(ctx context.Context) *runGroup {
+	ctx, cancel := context.WithCancel(ctx)
+	return &runGroup{ctx: ctx, cancel: cancel}
+}
+
+// fail records the error and stops the group.  Cancellation errors are
+// expected when stopping, so they're ignored.
+func (g *runGroup) fail(err error) {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	g.errOnce.Do(func() {
+		g.err = err
+	})
+	g.cancel()
+}
+
+// Context returns the context of the group, which is done once the group is
+// stopped.
+func (g *runGroup) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs f in the group.
+func (g *runGroup) Go(f func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		g.fail(f(g.ctx))
+	}()
+}
+
+// Wait stops the group, and returns the first error once all the functions
+// run in the group returned.
+func (g *runGroup) Wait() error {
+	g.cancel()
+	g.wg.Wait()
+	return g.err
+}
+
+// startRunnables starts the given runnables.  When one of them fails, onError
+// is called with its error.  It returns a function waiting for the runnables
+// to return once the context is done.
+func startRunnables(ctx context.Context, runnables []Runnable, onError func(error)) (wait func()) {
+	var wg sync.WaitGroup
+	for _, r := range runnables {
+		wg.Add(1)
+		go func(r Runnable) {
+			defer wg.Done()
+			if err := r.Start(ctx); err != nil {
+				onError(err)
+			}
+		}(r)
+	}
+	return wg.Wait
+}
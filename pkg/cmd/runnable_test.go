@@ -0,0 +1,102 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/fake"
+)
+
+type runnableProvider struct {
+	provider.MetricsProvider
+	started atomic.Int32
+}
+
+func (p *runnableProvider) Start(ctx context.Context) error {
+	p.started.Add(1)
+	<-ctx.Done()
+	return nil
+}
+
+func TestRunnableProviders(t *testing.T) {
+	shared := &runnableProvider{MetricsProvider: fake.NewProvider()}
+	adapter := &AdapterBase{}
+	adapter.WithCustomMetrics(shared)
+	adapter.WithExternalMetrics(shared)
+	assert.Len(t, adapter.runnableProviders(), 1, "a provider serving both APIs should only be started once")
+
+	adapter.WithExternalMetrics(&runnableProvider{MetricsProvider: fake.NewProvider()})
+	assert.Len(t, adapter.runnableProviders(), 2)
+
+	adapter.WithExternalMetrics(fake.NewProvider())
+	assert.Len(t, adapter.runnableProviders(), 1, "providers which don't implement Runnable should not be started")
+}
+
+func TestRunGroup(t *testing.T) {
+	t.Run("drains on shutdown", func(t *testing.T) {
+		group := newRunGroup(context.Background())
+		prov := &runnableProvider{}
+		var drained atomic.Bool
+		group.Go(prov.Start)
+		group.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			drained.Store(true)
+			return ctx.Err()
+		})
+
+		assert.NoError(t, group.Wait(), "cancellation errors should be ignored")
+		assert.True(t, drained.Load(), "Wait should wait for the tasks to return")
+		assert.Equal(t, int32(1), prov.started.Load())
+	})
+
+	t.Run("propagates errors", func(t *testing.T) {
+		group := newRunGroup(context.Background())
+		failure := errors.New("collector failed")
+		group.Go(func(ctx context.Context) error {
+			return failure
+		})
+		group.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return errors.New("stopped")
+		})
+
+		<-group.Context().Done()
+		assert.Equal(t, failure, group.Wait(), "the first error should be returned")
+	})
+
+	t.Run("leader runnables", func(t *testing.T) {
+		group := newRunGroup(context.Background())
+		failure := errors.New("aggregator failed")
+		adapter := &AdapterBase{}
+		adapter.WithLeaderRunnable(RunnableFunc(func(ctx context.Context) error {
+			return failure
+		}))
+		group.Go(func(ctx context.Context) error {
+			return adapter.runLeaderRunnables(ctx, nil, group.fail)
+		})
+
+		<-group.Context().Done()
+		assert.Equal(t, failure, group.Wait())
+	})
+}
@@ -39,6 +39,24 @@ type RegeneratingDiscoveryRESTMapper struct {
 	mu sync.RWMutex
 
 	delegate meta.RESTMapper
+
+	// statusMu guards status, which is updated even when regenerating fails
+	statusMu sync.Mutex
+	status   Status
+}
+
+// Status describes the state of the mappings of a RegeneratingDiscoveryRESTMapper.
+type Status struct {
+	// RefreshInterval is the interval at which the mappings are regenerated.
+	RefreshInterval time.Duration `json:"refreshInterval"`
+	// LastRefreshTime is the time of the last attempt to regenerate the mappings.
+	LastRefreshTime time.Time `json:"lastRefreshTime,omitzero"`
+	// LastSuccessTime is the time at which the mappings were last regenerated.
+	LastSuccessTime time.Time `json:"lastSuccessTime,omitzero"`
+	// LastError is the error of the last attempt, if it failed.
+	LastError string `json:"lastError,omitempty"`
+	// Resources is the number of resources known to the mappings.
+	Resources int `json:"resources"`
 }
 
 func NewRESTMapper(discoveryClient discovery.DiscoveryInterface, refreshInterval time.Duration) (*RegeneratingDiscoveryRESTMapper, error) {
@@ -63,12 +81,31 @@ func (m *RegeneratingDiscoveryRESTMapper) RunUntil(stop <-chan struct{}) {
 }
 
 func (m *RegeneratingDiscoveryRESTMapper) RegenerateMappings() error {
+	now := time.Now()
 	resources, err := restmapper.GetAPIGroupResources(m.discoveryClient)
 	if err != nil {
+		m.statusMu.Lock()
+		m.status.LastRefreshTime = now
+		m.status.LastError = err.Error()
+		m.statusMu.Unlock()
 		return err
 	}
 	newDelegate := restmapper.NewDiscoveryRESTMapper(resources)
 
+	count := 0
+	for _, group := range resources {
+		for _, versionResources := range group.VersionedResources {
+			count += len(versionResources)
+		}
+	}
+	m.statusMu.Lock()
+	m.status = Status{
+		LastRefreshTime: now,
+		LastSuccessTime: now,
+		Resources:       count,
+	}
+	m.statusMu.Unlock()
+
 	// don't lock until we're ready to replace
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -77,6 +114,16 @@ func (m *RegeneratingDiscoveryRESTMapper) RegenerateMappings() error {
 	return nil
 }
 
+// Status returns the state of the mappings.
+func (m *RegeneratingDiscoveryRESTMapper) Status() Status {
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+
+	status := m.status
+	status.RefreshInterval = m.refreshInterval
+	return status
+}
+
 func (m *RegeneratingDiscoveryRESTMapper) KindFor(resource schema.GroupVersionResource) (schema.GroupVersionKind, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -17,6 +17,7 @@ limitations under the License.
 package dynamicmapper
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery/fake"
 	core "k8s.io/client-go/testing"
@@ -92,3 +94,26 @@ func TestRegeneratingUpdatesMapper(t *testing.T) {
 		assert.Equal(t, schema.GroupVersionKind{Version: "v1alpha1", Kind: "Flunder", Group: "wardle"}, flundersGVK, "should have correctly fetched the kind for 'flunders.wardle' the second time")
 	}
 }
+
+func TestRegeneratingReportsStatus(t *testing.T) {
+	mapper, fakeDiscovery := setupMapper(t, nil)
+	require.NoError(t, mapper.RegenerateMappings())
+
+	status := mapper.Status()
+	assert.Equal(t, testingMapperRefreshInterval, status.RefreshInterval)
+	assert.Equal(t, 1, status.Resources)
+	assert.Empty(t, status.LastError)
+	assert.False(t, status.LastSuccessTime.IsZero())
+	assert.Equal(t, status.LastSuccessTime, status.LastRefreshTime)
+	lastSuccess := status.LastSuccessTime
+
+	fakeDiscovery.PrependReactor("get", "group", func(core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("discovery is down")
+	})
+	require.Error(t, mapper.RegenerateMappings())
+
+	status = mapper.Status()
+	assert.Equal(t, "discovery is down", status.LastError)
+	assert.Equal(t, lastSuccess, status.LastSuccessTime)
+	assert.Equal(t, 1, status.Resources, "the previous mappings should still be in use")
+}
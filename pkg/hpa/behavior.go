@@ -0,0 +1,160 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hpa
+
+import (
+	"math"
+	"slices"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+)
+
+// Reasons for the desired replica count to differ from the one proposed by
+// the metrics, as reported in the ScalingLimited condition of HPAs.
+const (
+	ReasonDesiredWithinRange = "DesiredWithinRange"
+	ReasonTooFewReplicas     = "TooFewReplicas"
+	ReasonTooManyReplicas    = "TooManyReplicas"
+	ReasonScaleUpLimit       = "ScaleUpLimit"
+	ReasonScaleDownLimit     = "ScaleDownLimit"
+)
+
+// defaultScaleUpRules and defaultScaleDownRules are the scaling rules
+// defaulted by the API server when an HPA doesn't set them.
+func defaultScaleUpRules() *autoscalingv2.HPAScalingRules {
+	maxPolicy := autoscalingv2.MaxChangePolicySelect
+	return &autoscalingv2.HPAScalingRules{
+		SelectPolicy: &maxPolicy,
+		Policies: []autoscalingv2.HPAScalingPolicy{
+			{Type: autoscalingv2.PodsScalingPolicy, Value: 4, PeriodSeconds: 15},
+			{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+		},
+	}
+}
+
+func defaultScaleDownRules() *autoscalingv2.HPAScalingRules {
+	maxPolicy := autoscalingv2.MaxChangePolicySelect
+	return &autoscalingv2.HPAScalingRules{
+		SelectPolicy: &maxPolicy,
+		Policies: []autoscalingv2.HPAScalingPolicy{
+			{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+		},
+	}
+}
+
+// scalingRules returns the rules of the HPA behavior, with the defaults of
+// the API server for the ones it doesn't set.
+func scalingRules(behavior *autoscalingv2.HorizontalPodAutoscalerBehavior) (scaleUp, scaleDown *autoscalingv2.HPAScalingRules) {
+	scaleUp, scaleDown = defaultScaleUpRules(), defaultScaleDownRules()
+	if behavior == nil {
+		return scaleUp, scaleDown
+	}
+	if behavior.ScaleUp != nil {
+		if behavior.ScaleUp.SelectPolicy != nil {
+			scaleUp.SelectPolicy = behavior.ScaleUp.SelectPolicy
+		}
+		if behavior.ScaleUp.Policies != nil {
+			scaleUp.Policies = behavior.ScaleUp.Policies
+		}
+	}
+	if behavior.ScaleDown != nil {
+		if behavior.ScaleDown.SelectPolicy != nil {
+			scaleDown.SelectPolicy = behavior.ScaleDown.SelectPolicy
+		}
+		if behavior.ScaleDown.Policies != nil {
+			scaleDown.Policies = behavior.ScaleDown.Policies
+		}
+	}
+	return scaleUp, scaleDown
+}
+
+// scaleUpLimit returns the largest replica count the scaling rules allow,
+// assuming no scaling happened during their periods.
+func scaleUpLimit(currentReplicas int32, rules *autoscalingv2.HPAScalingRules) int32 {
+	if *rules.SelectPolicy == autoscalingv2.DisabledPolicySelect || len(rules.Policies) == 0 {
+		return currentReplicas
+	}
+
+	proposals := make([]int32, 0, len(rules.Policies))
+	for _, policy := range rules.Policies {
+		if policy.Type == autoscalingv2.PodsScalingPolicy {
+			proposals = append(proposals, currentReplicas+policy.Value)
+		} else {
+			proposals = append(proposals, int32(math.Ceil(float64(currentReplicas)*(1+float64(policy.Value)/100))))
+		}
+	}
+	if *rules.SelectPolicy == autoscalingv2.MinChangePolicySelect {
+		return slices.Min(proposals)
+	}
+	return slices.Max(proposals)
+}
+
+// scaleDownLimit returns the smallest replica count the scaling rules
+// allow, assuming no scaling happened during their periods.
+func scaleDownLimit(currentReplicas int32, rules *autoscalingv2.HPAScalingRules) int32 {
+	if *rules.SelectPolicy == autoscalingv2.DisabledPolicySelect || len(rules.Policies) == 0 {
+		return currentReplicas
+	}
+
+	proposals := make([]int32, 0, len(rules.Policies))
+	for _, policy := range rules.Policies {
+		if policy.Type == autoscalingv2.PodsScalingPolicy {
+			proposals = append(proposals, currentReplicas-policy.Value)
+		} else {
+			proposals = append(proposals, int32(float64(currentReplicas)*(1-float64(policy.Value)/100)))
+		}
+	}
+	// the largest change is the smallest replica count
+	if *rules.SelectPolicy == autoscalingv2.MinChangePolicySelect {
+		return slices.Max(proposals)
+	}
+	return slices.Min(proposals)
+}
+
+// limitReplicas applies the scaling rules and the replica bounds of the HPA
+// to the replica count proposed by the metrics.  It returns the desired
+// replica count, and the reason it differs from the proposed one.
+func limitReplicas(hpa *autoscalingv2.HorizontalPodAutoscaler, currentReplicas, proposedReplicas int32) (int32, string) {
+	minReplicas := int32(1)
+	if hpa.Spec.MinReplicas != nil {
+		minReplicas = *hpa.Spec.MinReplicas
+	}
+	maxReplicas := hpa.Spec.MaxReplicas
+	scaleUp, scaleDown := scalingRules(hpa.Spec.Behavior)
+
+	switch {
+	case proposedReplicas > currentReplicas:
+		limit := max(scaleUpLimit(currentReplicas, scaleUp), currentReplicas)
+		maximumAllowed, reason := maxReplicas, ReasonTooManyReplicas
+		if maximumAllowed > limit {
+			maximumAllowed, reason = limit, ReasonScaleUpLimit
+		}
+		if proposedReplicas > maximumAllowed {
+			return maximumAllowed, reason
+		}
+	case proposedReplicas < currentReplicas:
+		limit := min(scaleDownLimit(currentReplicas, scaleDown), currentReplicas)
+		minimumAllowed, reason := minReplicas, ReasonTooFewReplicas
+		if minimumAllowed < limit {
+			minimumAllowed, reason = limit, ReasonScaleDownLimit
+		}
+		if proposedReplicas < minimumAllowed {
+			return minimumAllowed, reason
+		}
+	}
+	return proposedReplicas, ReasonDesiredWithinRange
+}
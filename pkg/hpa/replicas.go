@@ -0,0 +1,210 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hpa
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// DefaultTolerance is the tolerance of the horizontal pod autoscaler when
+// the HPA doesn't configure one: usage ratios within 10% of the target
+// don't cause any scaling.
+const DefaultTolerance = 0.1
+
+// tolerances are the ratios of usage to target within which the replica
+// count doesn't change.
+type tolerances struct {
+	scaleDown float64
+	scaleUp   float64
+}
+
+func (t tolerances) isWithin(usageRatio float64) bool {
+	return (1.0-t.scaleDown) <= usageRatio && usageRatio <= (1.0+t.scaleUp)
+}
+
+// hpaTolerances returns the tolerances configured by the HPA behavior,
+// falling back to the given default.
+func hpaTolerances(behavior *autoscalingv2.HorizontalPodAutoscalerBehavior, defaultTolerance float64) tolerances {
+	t := tolerances{scaleDown: defaultTolerance, scaleUp: defaultTolerance}
+	if behavior == nil {
+		return t
+	}
+	if behavior.ScaleDown != nil && behavior.ScaleDown.Tolerance != nil {
+		t.scaleDown = behavior.ScaleDown.Tolerance.AsApproximateFloat64()
+	}
+	if behavior.ScaleUp != nil && behavior.ScaleUp.Tolerance != nil {
+		t.scaleUp = behavior.ScaleUp.Tolerance.AsApproximateFloat64()
+	}
+	return t
+}
+
+// usageRatio returns the average of the given values (in milli-units),
+// and its ratio to the target.
+func usageRatio(values map[string]int64, targetUsage int64) (float64, int64) {
+	var total int64
+	for _, value := range values {
+		total += value
+	}
+	usage := total / int64(len(values))
+	return float64(usage) / float64(targetUsage), usage
+}
+
+// groupPods sorts the pods of the scale target the way the HPA controller
+// does for metrics other than CPU usage.
+func groupPods(pods []corev1.Pod, values map[string]int64) (readyPodCount int, unreadyPods, missingPods, ignoredPods sets.Set[string]) {
+	unreadyPods, missingPods, ignoredPods = sets.New[string](), sets.New[string](), sets.New[string]()
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodFailed {
+			ignoredPods.Insert(pod.Name)
+			continue
+		}
+		if pod.Status.Phase == corev1.PodPending {
+			unreadyPods.Insert(pod.Name)
+			continue
+		}
+		if _, found := values[pod.Name]; !found {
+			missingPods.Insert(pod.Name)
+			continue
+		}
+		readyPodCount++
+	}
+	return readyPodCount, unreadyPods, missingPods, ignoredPods
+}
+
+// plainMetricReplicas computes the replica count for per-pod values (in
+// milli-units) averaged over the pods, as for Pods metrics.  It returns the
+// count along with the average usage.
+func plainMetricReplicas(values map[string]int64, pods []corev1.Pod, currentReplicas int32, targetUsage int64, t tolerances) (int32, int64, error) {
+	if len(pods) == 0 {
+		return 0, 0, errors.New("no pods returned by selector while calculating replica count")
+	}
+
+	// leave the given values untouched, as they're adjusted below
+	metrics := make(map[string]int64, len(values))
+	for pod, value := range values {
+		metrics[pod] = value
+	}
+
+	readyPodCount, unreadyPods, missingPods, ignoredPods := groupPods(pods, metrics)
+	for pod := range ignoredPods.Union(unreadyPods) {
+		delete(metrics, pod)
+	}
+	if len(metrics) == 0 {
+		return 0, 0, errors.New("did not receive metrics for targeted pods (pods might be unready)")
+	}
+
+	ratio, usage := usageRatio(metrics, targetUsage)
+
+	scaleUpWithUnready := unreadyPods.Len() > 0 && ratio > 1.0
+	if !scaleUpWithUnready && missingPods.Len() == 0 {
+		if t.isWithin(ratio) {
+			return currentReplicas, usage, nil
+		}
+		// values are only kept for the ready pods
+		return int32(math.Ceil(ratio * float64(readyPodCount))), usage, nil
+	}
+
+	// conservatively assume the pods without values are at the target when
+	// scaling down, and idle when scaling up, so as to dampen the change
+	if missingPods.Len() > 0 {
+		for pod := range missingPods {
+			if ratio < 1.0 {
+				metrics[pod] = targetUsage
+			} else {
+				metrics[pod] = 0
+			}
+		}
+	}
+	if scaleUpWithUnready {
+		for pod := range unreadyPods {
+			metrics[pod] = 0
+		}
+	}
+
+	newRatio, _ := usageRatio(metrics, targetUsage)
+	if t.isWithin(newRatio) || (ratio < 1.0 && newRatio > 1.0) || (ratio > 1.0 && newRatio < 1.0) {
+		// the assumptions reversed the direction of the change
+		return currentReplicas, usage, nil
+	}
+
+	newReplicas := int32(math.Ceil(newRatio * float64(len(metrics))))
+	if (newRatio < 1.0 && newReplicas > currentReplicas) || (newRatio > 1.0 && newReplicas < currentReplicas) {
+		return currentReplicas, usage, nil
+	}
+	return newReplicas, usage, nil
+}
+
+// readyPodsCount counts the running and ready pods.
+func readyPodsCount(pods []corev1.Pod) (int, error) {
+	if len(pods) == 0 {
+		return 0, errors.New("no pods returned by selector while calculating replica count")
+	}
+
+	count := 0
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+// usageRatioReplicaCount computes the replica count for a ratio of usage to
+// target which isn't per pod, as for Object and External metrics with Value
+// targets.
+func usageRatioReplicaCount(currentReplicas int32, ratio float64, readyPodCount func() (int, error), t tolerances) (int32, error) {
+	if currentReplicas == 0 {
+		// scaling up from zero
+		return int32(math.Ceil(ratio)), nil
+	}
+	if t.isWithin(ratio) {
+		return currentReplicas, nil
+	}
+	count, err := readyPodCount()
+	if err != nil {
+		return 0, fmt.Errorf("unable to calculate ready pods: %v", err)
+	}
+	return int32(math.Ceil(ratio * float64(count))), nil
+}
+
+// perPodReplicaCount computes the replica count for a total usage spread
+// over the pods, as for Object and External metrics with AverageValue
+// targets.  It returns the count along with the usage per pod.
+func perPodReplicaCount(statusReplicas int32, usage, targetAverageUsage int64, t tolerances) (int32, int64) {
+	if statusReplicas == 0 {
+		// scaling up from zero
+		return int32(math.Ceil(float64(usage) / float64(targetAverageUsage))), usage
+	}
+	replicaCount := statusReplicas
+	ratio := float64(usage) / (float64(targetAverageUsage) * float64(replicaCount))
+	if !t.isWithin(ratio) {
+		replicaCount = int32(math.Ceil(float64(usage) / float64(targetAverageUsage)))
+	}
+	return replicaCount, int64(math.Ceil(float64(usage) / float64(statusReplicas)))
+}
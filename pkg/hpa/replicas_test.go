@@ -0,0 +1,190 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hpa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func pod(name string, phase corev1.PodPhase, ready bool) corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.PodStatus{
+			Phase:      phase,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestPlainMetricReplicas(t *testing.T) {
+	defaultTolerances := tolerances{scaleDown: DefaultTolerance, scaleUp: DefaultTolerance}
+
+	cases := map[string]struct {
+		values   map[string]int64
+		pods     []corev1.Pod
+		current  int32
+		target   int64
+		expected int32
+		err      bool
+	}{
+		"scale up": {
+			values:   map[string]int64{"a": 300, "b": 300},
+			pods:     []corev1.Pod{pod("a", corev1.PodRunning, true), pod("b", corev1.PodRunning, true)},
+			current:  2,
+			target:   100,
+			expected: 6,
+		},
+		"within tolerance": {
+			values:   map[string]int64{"a": 105, "b": 105},
+			pods:     []corev1.Pod{pod("a", corev1.PodRunning, true), pod("b", corev1.PodRunning, true)},
+			current:  2,
+			target:   100,
+			expected: 2,
+		},
+		"missing pods assumed at target when scaling down": {
+			values:   map[string]int64{"a": 50, "b": 50},
+			pods:     []corev1.Pod{pod("a", corev1.PodRunning, true), pod("b", corev1.PodRunning, true), pod("c", corev1.PodRunning, true), pod("d", corev1.PodRunning, true)},
+			current:  4,
+			target:   100,
+			expected: 3,
+		},
+		"missing pods reversing the change": {
+			values:   map[string]int64{"a": 120},
+			pods:     []corev1.Pod{pod("a", corev1.PodRunning, true), pod("b", corev1.PodRunning, true)},
+			current:  2,
+			target:   100,
+			expected: 2,
+		},
+		"unready pods assumed idle when scaling up": {
+			values:   map[string]int64{"a": 400, "b": 400},
+			pods:     []corev1.Pod{pod("a", corev1.PodRunning, true), pod("b", corev1.PodPending, false)},
+			current:  2,
+			target:   100,
+			expected: 4,
+		},
+		"failed pods ignored": {
+			values:   map[string]int64{"a": 200, "b": 1000},
+			pods:     []corev1.Pod{pod("a", corev1.PodRunning, true), pod("b", corev1.PodFailed, false)},
+			current:  2,
+			target:   100,
+			expected: 2,
+		},
+		"no pods": {
+			values: map[string]int64{"a": 200},
+			target: 100,
+			err:    true,
+		},
+		"no values for the ready pods": {
+			values: map[string]int64{"b": 200},
+			pods:   []corev1.Pod{pod("b", corev1.PodPending, false)},
+			target: 100,
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			replicas, _, err := plainMetricReplicas(tc.values, tc.pods, tc.current, tc.target, defaultTolerances)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, replicas)
+		})
+	}
+}
+
+func TestHPATolerances(t *testing.T) {
+	behavior := &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleDown: &autoscalingv2.HPAScalingRules{Tolerance: ptr.To(resource.MustParse("0.05"))},
+	}
+	tol := hpaTolerances(behavior, DefaultTolerance)
+
+	assert.Equal(t, tolerances{scaleDown: 0.05, scaleUp: DefaultTolerance}, tol)
+	assert.True(t, tol.isWithin(1.09))
+	assert.False(t, tol.isWithin(0.94))
+	assert.Equal(t, tolerances{scaleDown: 0.2, scaleUp: 0.2}, hpaTolerances(nil, 0.2))
+}
+
+func TestLimitReplicas(t *testing.T) {
+	hpa := func(behavior *autoscalingv2.HorizontalPodAutoscalerBehavior) *autoscalingv2.HorizontalPodAutoscaler {
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{MinReplicas: ptr.To[int32](2), MaxReplicas: 50, Behavior: behavior},
+		}
+	}
+	disabledScaleDown := &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleDown: &autoscalingv2.HPAScalingRules{SelectPolicy: ptr.To(autoscalingv2.DisabledPolicySelect)},
+	}
+	slowScaleDown := &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleDown: &autoscalingv2.HPAScalingRules{Policies: []autoscalingv2.HPAScalingPolicy{
+			{Type: autoscalingv2.PercentScalingPolicy, Value: 10, PeriodSeconds: 60},
+			{Type: autoscalingv2.PodsScalingPolicy, Value: 1, PeriodSeconds: 60},
+		}},
+	}
+
+	cases := map[string]struct {
+		hpa            *autoscalingv2.HorizontalPodAutoscaler
+		current        int32
+		proposed       int32
+		expected       int32
+		expectedReason string
+	}{
+		"within range": {
+			hpa: hpa(nil), current: 10, proposed: 15, expected: 15, expectedReason: ReasonDesiredWithinRange,
+		},
+		"doubled scale up": {
+			hpa: hpa(nil), current: 10, proposed: 40, expected: 20, expectedReason: ReasonScaleUpLimit,
+		},
+		"four pods scale up": {
+			hpa: hpa(nil), current: 2, proposed: 40, expected: 6, expectedReason: ReasonScaleUpLimit,
+		},
+		"above max replicas": {
+			hpa: hpa(nil), current: 40, proposed: 70, expected: 50, expectedReason: ReasonTooManyReplicas,
+		},
+		"below min replicas": {
+			hpa: hpa(nil), current: 10, proposed: 1, expected: 2, expectedReason: ReasonTooFewReplicas,
+		},
+		"scale down disabled": {
+			hpa: hpa(disabledScaleDown), current: 10, proposed: 5, expected: 10, expectedReason: ReasonScaleDownLimit,
+		},
+		"largest scale down policy": {
+			hpa: hpa(slowScaleDown), current: 30, proposed: 5, expected: 27, expectedReason: ReasonScaleDownLimit,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			replicas, reason := limitReplicas(tc.hpa, tc.current, tc.proposed)
+			assert.Equal(t, tc.expected, replicas)
+			assert.Equal(t, tc.expectedReason, reason)
+		})
+	}
+}
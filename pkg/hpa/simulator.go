@@ -0,0 +1,329 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hpa simulates the horizontal pod autoscaler: it queries the
+// metrics APIs the way the HPA controller does, and computes the replica
+// count it would scale to.
+package hpa
+
+import (
+	"errors"
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	customclient "k8s.io/metrics/pkg/client/custom_metrics"
+	externalclient "k8s.io/metrics/pkg/client/external_metrics"
+)
+
+// ReasonScalingDisabled is the reason reported when the scale target has
+// no replicas, which disables the HPA.
+const ReasonScalingDisabled = "ScalingDisabled"
+
+// Input describes an HPA and the current state of its scale target.
+type Input struct {
+	HPA *autoscalingv2.HorizontalPodAutoscaler
+	// CurrentReplicas is the replica count of the scale target.
+	CurrentReplicas int32
+	// Selector selects the pods of the scale target.  It is required for
+	// Pods metrics.
+	Selector labels.Selector
+	// Pods are the pods of the scale target.  If nil, the pods are assumed
+	// to be running and ready, and to be the ones with metric values.
+	Pods []corev1.Pod
+}
+
+// MetricResult describes the replica count proposed for one of the metrics
+// of an HPA.
+type MetricResult struct {
+	Type       autoscalingv2.MetricSourceType `json:"type"`
+	Name       string                         `json:"name,omitempty"`
+	TargetType autoscalingv2.MetricTargetType `json:"targetType,omitempty"`
+	Target     *resource.Quantity             `json:"target,omitempty"`
+	// Current is the value compared to the target: the value of the
+	// metric for Value targets, or its average over the pods for
+	// AverageValue targets.
+	Current  *resource.Quantity `json:"current,omitempty"`
+	Replicas int32              `json:"replicas"`
+	// Error is the reason the metric couldn't be evaluated.
+	Error string `json:"error,omitempty"`
+}
+
+// Result describes the scaling decision of an HPA.
+type Result struct {
+	CurrentReplicas int32          `json:"currentReplicas"`
+	Metrics         []MetricResult `json:"metrics"`
+	// ProposedReplicas is the largest of the replica counts proposed by
+	// the metrics.
+	ProposedReplicas int32 `json:"proposedReplicas"`
+	// DesiredReplicas is the replica count the HPA would scale to, once
+	// its scaling rules and replica bounds are applied.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	// Reason explains how DesiredReplicas was bounded, using the reasons
+	// of the ScalingLimited condition of HPAs.
+	Reason string `json:"reason"`
+}
+
+// Simulator computes the replica counts HPAs would scale to.  Scaling
+// decisions only depend on the current metrics: the stabilization windows
+// and the scaling policies are applied as if no scaling happened recently.
+type Simulator struct {
+	CustomMetrics   customclient.CustomMetricsClient
+	ExternalMetrics externalclient.ExternalMetricsClient
+	// Tolerance applies to the HPAs which don't configure one, like the
+	// --horizontal-pod-autoscaler-tolerance flag of the controller manager.
+	Tolerance float64
+}
+
+// NewForConfig creates a Simulator with the clients the HPA controller
+// uses.  The RESTMapper maps the kinds of the objects described by Object
+// metrics to their resources.
+func NewForConfig(config *rest.Config, mapper apimeta.RESTMapper) (*Simulator, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to construct discovery client: %v", err)
+	}
+	externalMetrics, err := externalclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to construct external metrics client: %v", err)
+	}
+	return &Simulator{
+		CustomMetrics:   customclient.NewForConfig(config, mapper, customclient.NewAvailableAPIsGetter(discoveryClient)),
+		ExternalMetrics: externalMetrics,
+		Tolerance:       DefaultTolerance,
+	}, nil
+}
+
+// Simulate computes the replica count the HPA would scale to.  If the HPA
+// wouldn't scale because of invalid metrics, the result is returned along
+// with an error.
+func (s *Simulator) Simulate(input Input) (*Result, error) {
+	hpa := input.HPA
+	currentReplicas := input.CurrentReplicas
+	result := &Result{CurrentReplicas: currentReplicas}
+
+	minReplicas := int32(1)
+	if hpa.Spec.MinReplicas != nil {
+		minReplicas = *hpa.Spec.MinReplicas
+	}
+	switch {
+	case currentReplicas == 0 && minReplicas != 0:
+		result.Reason = ReasonScalingDisabled
+		return result, nil
+	case currentReplicas > hpa.Spec.MaxReplicas:
+		result.ProposedReplicas, result.DesiredReplicas, result.Reason = currentReplicas, hpa.Spec.MaxReplicas, ReasonTooManyReplicas
+		return result, nil
+	case currentReplicas < minReplicas:
+		result.ProposedReplicas, result.DesiredReplicas, result.Reason = currentReplicas, minReplicas, ReasonTooFewReplicas
+		return result, nil
+	}
+
+	t := hpaTolerances(hpa.Spec.Behavior, s.Tolerance)
+	var firstErr error
+	invalidMetrics := 0
+	for _, spec := range hpa.Spec.Metrics {
+		metric, err := s.simulateMetric(input, spec, t)
+		if err != nil {
+			metric.Error = err.Error()
+			invalidMetrics++
+			if firstErr == nil {
+				firstErr = err
+			}
+		} else if metric.Replicas > result.ProposedReplicas {
+			result.ProposedReplicas = metric.Replicas
+		}
+		result.Metrics = append(result.Metrics, metric)
+	}
+
+	// like the HPA, don't scale down when some metrics are invalid, as they
+	// might call for more replicas
+	if invalidMetrics >= len(hpa.Spec.Metrics) || (invalidMetrics > 0 && result.ProposedReplicas < currentReplicas) {
+		result.ProposedReplicas, result.DesiredReplicas = currentReplicas, currentReplicas
+		return result, fmt.Errorf("invalid metrics (%d invalid out of %d), first error is: %v", invalidMetrics, len(hpa.Spec.Metrics), firstErr)
+	}
+
+	result.DesiredReplicas, result.Reason = limitReplicas(hpa, currentReplicas, result.ProposedReplicas)
+	return result, nil
+}
+
+func (s *Simulator) simulateMetric(input Input, spec autoscalingv2.MetricSpec, t tolerances) (MetricResult, error) {
+	metric := MetricResult{Type: spec.Type}
+	switch spec.Type {
+	case autoscalingv2.ObjectMetricSourceType:
+		if spec.Object == nil {
+			return metric, errors.New("invalid object metric source: no object metric set")
+		}
+		metric.setTarget(spec.Object.Metric.Name, spec.Object.Target)
+		replicas, usage, err := s.objectReplicas(input, spec.Object, t)
+		return metric.setProposal(replicas, usage, err)
+	case autoscalingv2.PodsMetricSourceType:
+		if spec.Pods == nil {
+			return metric, errors.New("invalid pods metric source: no pods metric set")
+		}
+		metric.setTarget(spec.Pods.Metric.Name, spec.Pods.Target)
+		replicas, usage, err := s.podsReplicas(input, spec.Pods, t)
+		return metric.setProposal(replicas, usage, err)
+	case autoscalingv2.ExternalMetricSourceType:
+		if spec.External == nil {
+			return metric, errors.New("invalid external metric source: no external metric set")
+		}
+		metric.setTarget(spec.External.Metric.Name, spec.External.Target)
+		replicas, usage, err := s.externalReplicas(input, spec.External, t)
+		return metric.setProposal(replicas, usage, err)
+	case autoscalingv2.ResourceMetricSourceType, autoscalingv2.ContainerResourceMetricSourceType:
+		return metric, fmt.Errorf("%s metrics are served by the resource metrics API, which isn't simulated", spec.Type)
+	default:
+		return metric, fmt.Errorf("unknown metric source type %q", spec.Type)
+	}
+}
+
+func (m *MetricResult) setTarget(name string, target autoscalingv2.MetricTarget) {
+	m.Name = name
+	m.TargetType = target.Type
+	switch target.Type {
+	case autoscalingv2.ValueMetricType:
+		m.Target = target.Value
+	case autoscalingv2.AverageValueMetricType:
+		m.Target = target.AverageValue
+	}
+}
+
+func (m MetricResult) setProposal(replicas int32, usage int64, err error) (MetricResult, error) {
+	if err != nil {
+		return m, err
+	}
+	m.Replicas = replicas
+	m.Current = resource.NewMilliQuantity(usage, resource.DecimalSI)
+	return m, nil
+}
+
+// readyPodCount returns the count of ready pods used to scale on the ratio
+// of a metric to its target.
+func (input Input) readyPodCount() (int, error) {
+	if input.Pods == nil {
+		return int(input.CurrentReplicas), nil
+	}
+	return readyPodsCount(input.Pods)
+}
+
+func (s *Simulator) objectReplicas(input Input, source *autoscalingv2.ObjectMetricSource, t tolerances) (int32, int64, error) {
+	metricSelector, err := metav1.LabelSelectorAsSelector(source.Metric.Selector)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	namespace := input.HPA.Namespace
+	gvk := schema.FromAPIVersionAndKind(source.DescribedObject.APIVersion, source.DescribedObject.Kind)
+	metrics := s.CustomMetrics.NamespacedMetrics(namespace)
+	name := source.DescribedObject.Name
+	if gvk.Kind == "Namespace" && gvk.Group == "" {
+		// the HPA may only describe its own namespace
+		metrics, name = s.CustomMetrics.RootScopedMetrics(), namespace
+	}
+	value, err := metrics.GetForObject(gvk.GroupKind(), name, source.Metric.Name, metricSelector)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to fetch metrics from custom metrics API: %v", err)
+	}
+	usage := value.Value.MilliValue()
+
+	switch {
+	case source.Target.Type == autoscalingv2.ValueMetricType && source.Target.Value != nil:
+		ratio := float64(usage) / float64(source.Target.Value.MilliValue())
+		replicas, err := usageRatioReplicaCount(input.CurrentReplicas, ratio, input.readyPodCount, t)
+		return replicas, usage, err
+	case source.Target.Type == autoscalingv2.AverageValueMetricType && source.Target.AverageValue != nil:
+		replicas, usagePerPod := perPodReplicaCount(input.CurrentReplicas, usage, source.Target.AverageValue.MilliValue(), t)
+		return replicas, usagePerPod, nil
+	default:
+		return 0, 0, errors.New("invalid object metric source: neither a value target nor an average value target was set")
+	}
+}
+
+func (s *Simulator) podsReplicas(input Input, source *autoscalingv2.PodsMetricSource, t tolerances) (int32, int64, error) {
+	if source.Target.Type != autoscalingv2.AverageValueMetricType || source.Target.AverageValue == nil {
+		return 0, 0, errors.New("invalid pods metric source: no average value target was set")
+	}
+	if input.Selector == nil {
+		return 0, 0, errors.New("the selector of the pods of the scale target is required for pods metrics")
+	}
+	metricSelector, err := metav1.LabelSelectorAsSelector(source.Metric.Selector)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	list, err := s.CustomMetrics.NamespacedMetrics(input.HPA.Namespace).
+		GetForObjects(schema.GroupKind{Kind: "Pod"}, input.Selector, source.Metric.Name, metricSelector)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to fetch metrics from custom metrics API: %v", err)
+	}
+	if len(list.Items) == 0 {
+		return 0, 0, errors.New("no metrics returned from custom metrics API")
+	}
+
+	values := make(map[string]int64, len(list.Items))
+	for _, value := range list.Items {
+		values[value.DescribedObject.Name] = value.Value.MilliValue()
+	}
+
+	pods := input.Pods
+	if pods == nil {
+		for name := range values {
+			pods = append(pods, corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			})
+		}
+	}
+	return plainMetricReplicas(values, pods, input.CurrentReplicas, source.Target.AverageValue.MilliValue(), t)
+}
+
+func (s *Simulator) externalReplicas(input Input, source *autoscalingv2.ExternalMetricSource, t tolerances) (int32, int64, error) {
+	metricSelector, err := metav1.LabelSelectorAsSelector(source.Metric.Selector)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	list, err := s.ExternalMetrics.NamespacedMetrics(input.HPA.Namespace).List(source.Metric.Name, metricSelector)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to fetch metrics from external metrics API: %v", err)
+	}
+	if len(list.Items) == 0 {
+		return 0, 0, errors.New("no metrics returned from external metrics API")
+	}
+	var usage int64
+	for _, value := range list.Items {
+		usage += value.Value.MilliValue()
+	}
+
+	switch {
+	case source.Target.Type == autoscalingv2.ValueMetricType && source.Target.Value != nil:
+		ratio := float64(usage) / float64(source.Target.Value.MilliValue())
+		replicas, err := usageRatioReplicaCount(input.CurrentReplicas, ratio, input.readyPodCount, t)
+		return replicas, usage, err
+	case source.Target.Type == autoscalingv2.AverageValueMetricType && source.Target.AverageValue != nil:
+		replicas, usagePerPod := perPodReplicaCount(input.CurrentReplicas, usage, source.Target.AverageValue.MilliValue(), t)
+		return replicas, usagePerPod, nil
+	default:
+		return 0, 0, errors.New("invalid external metric source: neither a value target nor an average value target was set")
+	}
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hpa
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"k8s.io/utils/ptr"
+
+	apiservertesting "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/testing"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/defaults"
+)
+
+// testProvider serves fixed metric values: objectValues are keyed by
+// group resource, object name and metric, podValues by pod name.
+type testProvider struct {
+	defaults.DefaultCustomMetricsProvider
+	defaults.DefaultExternalMetricsProvider
+
+	objectValues   map[string]string
+	podValues      map[string]string
+	podLabels      labels.Set
+	externalValues []string
+}
+
+func (p *testProvider) GetMetricByName(_ context.Context, name types.NamespacedName, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValue, error) {
+	key := info.GroupResource.String() + "/" + name.Name + "/" + info.Metric
+	value, ok := p.objectValues[key]
+	if !ok {
+		return nil, provider.NewMetricNotFoundForError(info.GroupResource, info.Metric, name.Name)
+	}
+	return &custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{Namespace: name.Namespace, Name: name.Name},
+		Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+		Value:           resource.MustParse(value),
+	}, nil
+}
+
+func (p *testProvider) GetMetricBySelector(_ context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValueList, error) {
+	list := &custom_metrics.MetricValueList{}
+	if !selector.Matches(p.podLabels) {
+		return list, nil
+	}
+	for name, value := range p.podValues {
+		list.Items = append(list.Items, custom_metrics.MetricValue{
+			DescribedObject: custom_metrics.ObjectReference{Kind: "Pod", Namespace: namespace, Name: name},
+			Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+			Value:           resource.MustParse(value),
+		})
+	}
+	return list, nil
+}
+
+func (p *testProvider) GetExternalMetric(_ context.Context, _ string, _ labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	list := &external_metrics.ExternalMetricValueList{}
+	for _, value := range p.externalValues {
+		list.Items = append(list.Items, external_metrics.ExternalMetricValue{MetricName: info.Metric, Value: resource.MustParse(value)})
+	}
+	return list, nil
+}
+
+func restMapper() apimeta.RESTMapper {
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion, appsv1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), apimeta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), apimeta.RESTScopeRoot)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), apimeta.RESTScopeNamespace)
+	return mapper
+}
+
+func objectMetric(kind, name, metric string, target autoscalingv2.MetricTarget) autoscalingv2.MetricSpec {
+	apiVersion := "apps/v1"
+	if kind == "Namespace" {
+		apiVersion = "v1"
+	}
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ObjectMetricSourceType,
+		Object: &autoscalingv2.ObjectMetricSource{
+			DescribedObject: autoscalingv2.CrossVersionObjectReference{APIVersion: apiVersion, Kind: kind, Name: name},
+			Metric:          autoscalingv2.MetricIdentifier{Name: metric},
+			Target:          target,
+		},
+	}
+}
+
+func podsMetric(metric string, averageValue string) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.PodsMetricSourceType,
+		Pods: &autoscalingv2.PodsMetricSource{
+			Metric: autoscalingv2.MetricIdentifier{Name: metric},
+			Target: averageValueTarget(averageValue),
+		},
+	}
+}
+
+func externalMetric(metric string, target autoscalingv2.MetricTarget) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ExternalMetricSourceType,
+		External: &autoscalingv2.ExternalMetricSource{
+			Metric: autoscalingv2.MetricIdentifier{Name: metric, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"queue": "orders"}}},
+			Target: target,
+		},
+	}
+}
+
+func valueTarget(value string) autoscalingv2.MetricTarget {
+	return autoscalingv2.MetricTarget{Type: autoscalingv2.ValueMetricType, Value: ptr.To(resource.MustParse(value))}
+}
+
+func averageValueTarget(value string) autoscalingv2.MetricTarget {
+	return autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: ptr.To(resource.MustParse(value))}
+}
+
+func testHPA(maxReplicas int32, metrics ...autoscalingv2.MetricSpec) *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "frontend"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "frontend"},
+			MaxReplicas:    maxReplicas,
+			Metrics:        metrics,
+		},
+	}
+}
+
+func runningPods(count int, withMetrics int) []corev1.Pod {
+	var pods []corev1.Pod
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("frontend-%d", i)
+		if i >= withMetrics {
+			name = fmt.Sprintf("frontend-new-%d", i)
+		}
+		pods = append(pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		})
+	}
+	return pods
+}
+
+func TestSimulate(t *testing.T) {
+	prov := &testProvider{
+		objectValues: map[string]string{
+			"deployments.apps/frontend/requests_per_second": "300",
+			"deployments.apps/frontend/queue_length":        "500",
+			"deployments.apps/frontend/sessions":            "420",
+			"namespaces/default/pending_jobs":               "30",
+		},
+		podValues: map[string]string{
+			"frontend-0": "150",
+			"frontend-1": "150",
+			"frontend-2": "150",
+			"frontend-3": "150",
+		},
+		podLabels:      labels.Set{"app": "frontend"},
+		externalValues: []string{"30", "30"},
+	}
+	server := apiservertesting.StartTestServer(t, prov, prov)
+	simulator, err := NewForConfig(server.ClientConfig, restMapper())
+	require.NoError(t, err)
+
+	selector := labels.SelectorFromSet(labels.Set{"app": "frontend"})
+	scaleUpTolerance := autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleUp: &autoscalingv2.HPAScalingRules{Tolerance: ptr.To(resource.MustParse("0.5"))},
+	}
+	fastScaleUp := autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleUp: &autoscalingv2.HPAScalingRules{Policies: []autoscalingv2.HPAScalingPolicy{{Type: autoscalingv2.PodsScalingPolicy, Value: 100, PeriodSeconds: 15}}},
+	}
+
+	cases := map[string]struct {
+		input    Input
+		behavior *autoscalingv2.HorizontalPodAutoscalerBehavior
+		expected Result
+		err      bool
+	}{
+		"object value scaled up by the default policies": {
+			input: Input{CurrentReplicas: 4, HPA: testHPA(20, objectMetric("Deployment", "frontend", "requests_per_second", valueTarget("100")))},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 12, DesiredReplicas: 8, Reason: ReasonScaleUpLimit,
+				Metrics: []MetricResult{{Type: autoscalingv2.ObjectMetricSourceType, Name: "requests_per_second", TargetType: autoscalingv2.ValueMetricType, Target: ptr.To(resource.MustParse("100")), Current: resource.NewMilliQuantity(300000, resource.DecimalSI), Replicas: 12}},
+			},
+		},
+		"object value with ready pods": {
+			input: Input{CurrentReplicas: 4, Pods: runningPods(2, 2), HPA: testHPA(20, objectMetric("Deployment", "frontend", "requests_per_second", valueTarget("100")))},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 6, DesiredReplicas: 6, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{{Type: autoscalingv2.ObjectMetricSourceType, Name: "requests_per_second", TargetType: autoscalingv2.ValueMetricType, Target: ptr.To(resource.MustParse("100")), Current: resource.NewMilliQuantity(300000, resource.DecimalSI), Replicas: 6}},
+			},
+		},
+		"object average value": {
+			input: Input{CurrentReplicas: 4, HPA: testHPA(20, objectMetric("Deployment", "frontend", "queue_length", averageValueTarget("100")))},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 5, DesiredReplicas: 5, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{{Type: autoscalingv2.ObjectMetricSourceType, Name: "queue_length", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("100")), Current: resource.NewMilliQuantity(125000, resource.DecimalSI), Replicas: 5}},
+			},
+		},
+		"object average value within tolerance": {
+			input: Input{CurrentReplicas: 4, HPA: testHPA(20, objectMetric("Deployment", "frontend", "sessions", averageValueTarget("100")))},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 4, DesiredReplicas: 4, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{{Type: autoscalingv2.ObjectMetricSourceType, Name: "sessions", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("100")), Current: resource.NewMilliQuantity(105000, resource.DecimalSI), Replicas: 4}},
+			},
+		},
+		"namespace value": {
+			input: Input{CurrentReplicas: 4, HPA: testHPA(20, objectMetric("Namespace", "ignored", "pending_jobs", valueTarget("20")))},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 6, DesiredReplicas: 6, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{{Type: autoscalingv2.ObjectMetricSourceType, Name: "pending_jobs", TargetType: autoscalingv2.ValueMetricType, Target: ptr.To(resource.MustParse("20")), Current: resource.NewMilliQuantity(30000, resource.DecimalSI), Replicas: 6}},
+			},
+		},
+		"pods average value": {
+			input: Input{CurrentReplicas: 4, Selector: selector, HPA: testHPA(20, podsMetric("http_requests", "100"))},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 6, DesiredReplicas: 6, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{{Type: autoscalingv2.PodsMetricSourceType, Name: "http_requests", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("100")), Current: resource.NewMilliQuantity(150000, resource.DecimalSI), Replicas: 6}},
+			},
+		},
+		"pods average value with a pod missing metrics": {
+			input: Input{CurrentReplicas: 5, Selector: selector, Pods: runningPods(5, 4), HPA: testHPA(20, podsMetric("http_requests", "100"))},
+			expected: Result{
+				CurrentReplicas: 5, ProposedReplicas: 6, DesiredReplicas: 6, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{{Type: autoscalingv2.PodsMetricSourceType, Name: "http_requests", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("100")), Current: resource.NewMilliQuantity(150000, resource.DecimalSI), Replicas: 6}},
+			},
+		},
+		"pods average value with the configured tolerance": {
+			input:    Input{CurrentReplicas: 4, Selector: selector, HPA: testHPA(20, podsMetric("http_requests", "100"))},
+			behavior: &scaleUpTolerance,
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 4, DesiredReplicas: 4, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{{Type: autoscalingv2.PodsMetricSourceType, Name: "http_requests", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("100")), Current: resource.NewMilliQuantity(150000, resource.DecimalSI), Replicas: 4}},
+			},
+		},
+		"external value bounded by max replicas": {
+			input:    Input{CurrentReplicas: 4, HPA: testHPA(10, externalMetric("queue_length", valueTarget("20")))},
+			behavior: &fastScaleUp,
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 12, DesiredReplicas: 10, Reason: ReasonTooManyReplicas,
+				Metrics: []MetricResult{{Type: autoscalingv2.ExternalMetricSourceType, Name: "queue_length", TargetType: autoscalingv2.ValueMetricType, Target: ptr.To(resource.MustParse("20")), Current: resource.NewMilliQuantity(60000, resource.DecimalSI), Replicas: 12}},
+			},
+		},
+		"external average value": {
+			input: Input{CurrentReplicas: 4, HPA: testHPA(10, externalMetric("queue_length", averageValueTarget("20")))},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 3, DesiredReplicas: 3, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{{Type: autoscalingv2.ExternalMetricSourceType, Name: "queue_length", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("20")), Current: resource.NewMilliQuantity(15000, resource.DecimalSI), Replicas: 3}},
+			},
+		},
+		"largest proposal": {
+			input: Input{CurrentReplicas: 4, Selector: selector, HPA: testHPA(20,
+				externalMetric("queue_length", averageValueTarget("20")),
+				podsMetric("http_requests", "100"),
+			)},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 6, DesiredReplicas: 6, Reason: ReasonDesiredWithinRange,
+				Metrics: []MetricResult{
+					{Type: autoscalingv2.ExternalMetricSourceType, Name: "queue_length", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("20")), Current: resource.NewMilliQuantity(15000, resource.DecimalSI), Replicas: 3},
+					{Type: autoscalingv2.PodsMetricSourceType, Name: "http_requests", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("100")), Current: resource.NewMilliQuantity(150000, resource.DecimalSI), Replicas: 6},
+				},
+			},
+		},
+		"no scale down with invalid metrics": {
+			input: Input{CurrentReplicas: 4, HPA: testHPA(20,
+				externalMetric("queue_length", averageValueTarget("20")),
+				objectMetric("Deployment", "frontend", "unknown", valueTarget("1")),
+			)},
+			expected: Result{
+				CurrentReplicas: 4, ProposedReplicas: 4, DesiredReplicas: 4,
+				Metrics: []MetricResult{
+					{Type: autoscalingv2.ExternalMetricSourceType, Name: "queue_length", TargetType: autoscalingv2.AverageValueMetricType, Target: ptr.To(resource.MustParse("20")), Current: resource.NewMilliQuantity(15000, resource.DecimalSI), Replicas: 3},
+					{Type: autoscalingv2.ObjectMetricSourceType, Name: "unknown", TargetType: autoscalingv2.ValueMetricType, Target: ptr.To(resource.MustParse("1"))},
+				},
+			},
+			err: true,
+		},
+		"scaling disabled": {
+			input:    Input{CurrentReplicas: 0, HPA: testHPA(20, podsMetric("http_requests", "100"))},
+			expected: Result{Reason: ReasonScalingDisabled},
+		},
+		"above max replicas": {
+			input:    Input{CurrentReplicas: 30, HPA: testHPA(20, podsMetric("http_requests", "100"))},
+			expected: Result{CurrentReplicas: 30, ProposedReplicas: 30, DesiredReplicas: 20, Reason: ReasonTooManyReplicas},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tc.input.HPA.Spec.Behavior = tc.behavior
+			result, err := simulator.Simulate(tc.input)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.NotNil(t, result)
+			// errors are checked separately, as they carry API responses
+			for i := range result.Metrics {
+				if result.Metrics[i].Error != "" {
+					result.Metrics[i].Error = ""
+				}
+			}
+			assert.Equal(t, tc.expected, *result)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package faults injects faults into the answers of metrics providers, to
+// test how clients such as the horizontal pod autoscaler behave when an
+// adapter fails or answers slowly.
+package faults
+
+import (
+	"fmt"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reasons are the status reasons of the errors which can be injected.
+var Reasons = []metav1.StatusReason{
+	metav1.StatusReasonNotFound,
+	metav1.StatusReasonServiceUnavailable,
+	metav1.StatusReasonTimeout,
+}
+
+// Fault describes the faults injected into the calls for a metric.
+type Fault struct {
+	// Metric is the name of the metric the fault applies to, or empty for
+	// all the metrics.
+	Metric string `json:"metric,omitempty"`
+	// Namespace is the namespace the fault applies to, or empty for all the
+	// namespaces, including cluster-scoped objects.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Latency delays the calls.
+	Latency metav1.Duration `json:"latency,omitzero"`
+	// ErrorRates are the rates of the calls failing with errors of the
+	// given status reasons, between 0 and 1.
+	ErrorRates map[metav1.StatusReason]float64 `json:"errorRates,omitempty"`
+	// EmptyRate is the rate of the calls returning no values, between 0 and
+	// 1.  Calls for the metric of a single object fail with a NotFound error
+	// instead.
+	EmptyRate float64 `json:"emptyRate,omitempty"`
+	// Staleness moves the timestamps of the values back by the given
+	// duration.
+	Staleness metav1.Duration `json:"staleness,omitzero"`
+}
+
+// Config lists the faults to inject.  Each call gets the faults of the first
+// fault matching its metric and namespace.
+type Config struct {
+	Faults []Fault `json:"faults"`
+}
+
+// Validate checks that the rates and durations of the faults are valid.
+func (c *Config) Validate() error {
+	for i, fault := range c.Faults {
+		if err := fault.validate(); err != nil {
+			return fmt.Errorf("invalid fault %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (f *Fault) validate() error {
+	if f.Latency.Duration < 0 {
+		return fmt.Errorf("negative latency %s", f.Latency.Duration)
+	}
+	if f.Staleness.Duration < 0 {
+		return fmt.Errorf("negative staleness %s", f.Staleness.Duration)
+	}
+	if f.EmptyRate < 0 || f.EmptyRate > 1 {
+		return fmt.Errorf("empty rate %v is not between 0 and 1", f.EmptyRate)
+	}
+	total := 0.0
+	for reason, rate := range f.ErrorRates {
+		if !slices.Contains(Reasons, reason) {
+			return fmt.Errorf("unsupported error reason %q, expected one of %v", reason, Reasons)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s error rate %v is not between 0 and 1", reason, rate)
+		}
+		total += rate
+	}
+	if total > 1 {
+		return fmt.Errorf("error rates add up to %v, more than 1", total)
+	}
+	return nil
+}
+
+// matches returns whether the fault applies to the calls for metric in
+// namespace.
+func (f *Fault) matches(namespace, metric string) bool {
+	return (f.Metric == "" || f.Metric == metric) && (f.Namespace == "" || f.Namespace == namespace)
+}
+
+// errorReason returns the reason of the error to inject for a random draw
+// between 0 and 1, if any.
+func (f *Fault) errorReason(draw float64) (metav1.StatusReason, bool) {
+	total := 0.0
+	// the reasons are iterated in a fixed order, for the draws to be
+	// reproducible
+	for _, reason := range Reasons {
+		total += f.ErrorRates[reason]
+		if draw < total {
+			return reason, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faults
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		fault Fault
+		err   string
+	}{
+		"valid": {
+			fault: Fault{
+				Metric:     "http_requests",
+				Latency:    metav1.Duration{Duration: time.Second},
+				ErrorRates: map[metav1.StatusReason]float64{metav1.StatusReasonNotFound: 0.5, metav1.StatusReasonTimeout: 0.5},
+				EmptyRate:  1,
+				Staleness:  metav1.Duration{Duration: time.Minute},
+			},
+		},
+		"negative latency": {
+			fault: Fault{Latency: metav1.Duration{Duration: -time.Second}},
+			err:   "invalid fault 0: negative latency -1s",
+		},
+		"negative staleness": {
+			fault: Fault{Staleness: metav1.Duration{Duration: -time.Second}},
+			err:   "invalid fault 0: negative staleness -1s",
+		},
+		"empty rate above 1": {
+			fault: Fault{EmptyRate: 1.5},
+			err:   "invalid fault 0: empty rate 1.5 is not between 0 and 1",
+		},
+		"negative error rate": {
+			fault: Fault{ErrorRates: map[metav1.StatusReason]float64{metav1.StatusReasonNotFound: -0.1}},
+			err:   "invalid fault 0: NotFound error rate -0.1 is not between 0 and 1",
+		},
+		"unsupported reason": {
+			fault: Fault{ErrorRates: map[metav1.StatusReason]float64{metav1.StatusReasonForbidden: 0.1}},
+			err:   `invalid fault 0: unsupported error reason "Forbidden", expected one of [NotFound ServiceUnavailable Timeout]`,
+		},
+		"error rates above 1": {
+			fault: Fault{ErrorRates: map[metav1.StatusReason]float64{metav1.StatusReasonNotFound: 0.75, metav1.StatusReasonServiceUnavailable: 0.75}},
+			err:   "invalid fault 0: error rates add up to 1.5, more than 1",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := Config{Faults: []Fault{tc.fault}}
+			err := config.Validate()
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	fault := Fault{ErrorRates: map[metav1.StatusReason]float64{
+		metav1.StatusReasonNotFound: 0.25,
+		metav1.StatusReasonTimeout:  0.25,
+	}}
+
+	cases := map[float64]metav1.StatusReason{
+		0:    metav1.StatusReasonNotFound,
+		0.2:  metav1.StatusReasonNotFound,
+		0.25: metav1.StatusReasonTimeout,
+		0.49: metav1.StatusReasonTimeout,
+		0.5:  "",
+		0.99: "",
+	}
+	for draw, expected := range cases {
+		reason, found := fault.errorReason(draw)
+		assert.Equal(t, expected, reason, "draw %v", draw)
+		assert.Equal(t, expected != "", found, "draw %v", draw)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	assert.True(t, (&Fault{}).matches("default", "http_requests"))
+	assert.True(t, (&Fault{}).matches("", "temperature"))
+	assert.True(t, (&Fault{Metric: "http_requests"}).matches("default", "http_requests"))
+	assert.False(t, (&Fault{Metric: "http_requests"}).matches("default", "temperature"))
+	assert.True(t, (&Fault{Namespace: "default"}).matches("default", "http_requests"))
+	assert.False(t, (&Fault{Namespace: "default"}).matches("", "temperature"))
+	assert.False(t, (&Fault{Metric: "http_requests", Namespace: "default"}).matches("other", "http_requests"))
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// Path is the path the configuration of injectors is usually served at.
+const Path = "/debug/faults"
+
+// injectedMessage is the message of the injected errors.
+const injectedMessage = "injected fault"
+
+// Injector is a metrics provider injecting faults into the answers of the
+// provider it wraps.  It serves its configuration as JSON over HTTP, and
+// replaces it with the configuration PUT to it, or clears it on DELETE; it
+// should only be mounted on authorized routes.
+//
+// Faults are only injected into the calls serving metrics: the
+// injector is a provider.WrappingProvider, so the optional provider
+// interfaces and cmd.Runnable are looked up on the wrapped provider.
+type Injector struct {
+	provider provider.MetricsProvider
+	clock    clock.Clock
+	// random draws the numbers deciding whether to inject errors and empty
+	// results.
+	random func() float64
+
+	lock   sync.RWMutex
+	config Config
+}
+
+var (
+	_ provider.MetricsProvider  = &Injector{}
+	_ provider.WrappingProvider = &Injector{}
+	_ http.Handler              = &Injector{}
+)
+
+// NewInjector returns a provider injecting the faults of config into the
+// answers of prov.
+func NewInjector(prov provider.MetricsProvider, config Config) (*Injector, error) {
+	i := &Injector{
+		provider: prov,
+		clock:    clock.RealClock{},
+		random:   rand.Float64,
+	}
+	if err := i.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Config returns the current configuration of the injector.
+func (i *Injector) Config() Config {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	config := i.config
+	config.Faults = slices.Clone(config.Faults)
+	return config
+}
+
+// SetConfig replaces the configuration of the injector, if it is valid.
+func (i *Injector) SetConfig(config Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if config.Faults == nil {
+		config.Faults = []Fault{}
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.config = config
+	return nil
+}
+
+// faultFor returns the fault to inject into the calls for metric in
+// namespace, if any.
+func (i *Injector) faultFor(namespace, metric string) (Fault, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	for _, fault := range i.config.Faults {
+		if fault.matches(namespace, metric) {
+			return fault, true
+		}
+	}
+	return Fault{}, false
+}
+
+// inject waits for the latency of fault, then returns the error to inject,
+// made by notFound for NotFound errors, and whether to return empty results.
+func (i *Injector) inject(ctx context.Context, fault Fault, notFound func() error) (bool, error) {
+	if fault.Latency.Duration > 0 {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-i.clock.After(fault.Latency.Duration):
+		}
+	}
+
+	if reason, found := fault.errorReason(i.random()); found {
+		klog.V(4).InfoS("Injecting error", "metric", fault.Metric, "namespace", fault.Namespace, "reason", reason)
+		switch reason {
+		case metav1.StatusReasonNotFound:
+			return false, notFound()
+		case metav1.StatusReasonServiceUnavailable:
+			return false, apierrors.NewServiceUnavailable(injectedMessage)
+		default:
+			return false, apierrors.NewTimeoutError(injectedMessage, 0)
+		}
+	}
+	return fault.EmptyRate > 0 && i.random() < fault.EmptyRate, nil
+}
+
+func (i *Injector) GetMetricByName(ctx context.Context, name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	fault, found := i.faultFor(name.Namespace, info.Metric)
+	if !found {
+		return i.provider.GetMetricByName(ctx, name, info, metricSelector)
+	}
+
+	notFound := func() error {
+		return provider.NewMetricNotFoundForError(info.GroupResource, info.Metric, name.Name)
+	}
+	empty, err := i.inject(ctx, fault, notFound)
+	if err != nil {
+		return nil, err
+	}
+	if empty {
+		return nil, notFound()
+	}
+
+	value, err := i.provider.GetMetricByName(ctx, name, info, metricSelector)
+	if err != nil || value == nil || fault.Staleness.Duration == 0 {
+		return value, err
+	}
+	value = value.DeepCopy()
+	value.Timestamp = staleTimestamp(value.Timestamp, fault)
+	return value, nil
+}
+
+func (i *Injector) GetMetricBySelector(ctx context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	fault, found := i.faultFor(namespace, info.Metric)
+	if !found {
+		return i.provider.GetMetricBySelector(ctx, namespace, selector, info, metricSelector)
+	}
+
+	empty, err := i.inject(ctx, fault, func() error {
+		return provider.NewMetricNotFoundError(info.GroupResource, info.Metric)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if empty {
+		return &custom_metrics.MetricValueList{}, nil
+	}
+
+	list, err := i.provider.GetMetricBySelector(ctx, namespace, selector, info, metricSelector)
+	if err != nil || list == nil || fault.Staleness.Duration == 0 {
+		return list, err
+	}
+	list = list.DeepCopy()
+	for j := range list.Items {
+		list.Items[j].Timestamp = staleTimestamp(list.Items[j].Timestamp, fault)
+	}
+	return list, nil
+}
+
+func (i *Injector) ListAllMetrics() []provider.CustomMetricInfo {
+	return i.provider.ListAllMetrics()
+}
+
+func (i *Injector) GetExternalMetric(ctx context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	fault, found := i.faultFor(namespace, info.Metric)
+	if !found {
+		return i.provider.GetExternalMetric(ctx, namespace, metricSelector, info)
+	}
+
+	empty, err := i.inject(ctx, fault, func() error {
+		return apierrors.NewNotFound(schema.GroupResource{Group: external_metrics.GroupName}, info.Metric)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if empty {
+		return &external_metrics.ExternalMetricValueList{}, nil
+	}
+
+	list, err := i.provider.GetExternalMetric(ctx, namespace, metricSelector, info)
+	if err != nil || list == nil || fault.Staleness.Duration == 0 {
+		return list, err
+	}
+	list = list.DeepCopy()
+	for j := range list.Items {
+		list.Items[j].Timestamp = staleTimestamp(list.Items[j].Timestamp, fault)
+	}
+	return list, nil
+}
+
+func (i *Injector) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	return i.provider.ListAllExternalMetrics()
+}
+
+func staleTimestamp(timestamp metav1.Time, fault Fault) metav1.Time {
+	return metav1.NewTime(timestamp.Add(-fault.Staleness.Duration))
+}
+
+// Unwrap returns the wrapped provider.
+func (i *Injector) Unwrap() provider.MetricsProvider {
+	return i.provider
+}
+
+// ServeHTTP serves the configuration of the injector as JSON, and replaces
+// it on PUT requests, or clears it on DELETE requests.
+func (i *Injector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		var config Config
+		decoder := json.NewDecoder(req.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			http.Error(w, fmt.Sprintf("unable to decode the fault injection configuration: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := i.SetConfig(config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		klog.InfoS("Updated fault injection configuration", "faults", len(config.Faults))
+	case http.MethodDelete:
+		_ = i.SetConfig(Config{})
+		klog.InfoS("Cleared fault injection configuration")
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, private")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(i.Config()); err != nil {
+		klog.ErrorS(err, "Unable to write the fault injection configuration")
+	}
+}
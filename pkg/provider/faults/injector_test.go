@@ -0,0 +1,245 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faults
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/defaults"
+)
+
+var (
+	testTimestamp = metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	requestsInfo    = provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "pods"}, Namespaced: true, Metric: "http_requests"}
+	temperatureInfo = provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "nodes"}, Metric: "temperature"}
+	queueInfo       = provider.ExternalMetricInfo{Metric: "queue_length"}
+)
+
+// testProvider serves a value of 1 for every metric and object.
+type testProvider struct {
+	defaults.DefaultCustomMetricsProvider
+	defaults.DefaultExternalMetricsProvider
+}
+
+func (testProvider) GetMetricByName(_ context.Context, name types.NamespacedName, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValue, error) {
+	return &custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{Namespace: name.Namespace, Name: name.Name},
+		Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+		Timestamp:       testTimestamp,
+		Value:           resource.MustParse("1"),
+	}, nil
+}
+
+func (p testProvider) GetMetricBySelector(ctx context.Context, namespace string, _ labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	value, _ := p.GetMetricByName(ctx, types.NamespacedName{Namespace: namespace, Name: "pod-1"}, info, metricSelector)
+	return &custom_metrics.MetricValueList{Items: []custom_metrics.MetricValue{*value}}, nil
+}
+
+func (testProvider) GetExternalMetric(_ context.Context, _ string, _ labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	return &external_metrics.ExternalMetricValueList{Items: []external_metrics.ExternalMetricValue{{
+		MetricName: info.Metric,
+		Timestamp:  testTimestamp,
+		Value:      resource.MustParse("1"),
+	}}}, nil
+}
+
+// newTestInjector returns an injector drawing the given number.
+func newTestInjector(t *testing.T, draw float64, faults ...Fault) *Injector {
+	t.Helper()
+	injector, err := NewInjector(testProvider{}, Config{Faults: faults})
+	require.NoError(t, err)
+	injector.random = func() float64 { return draw }
+	return injector
+}
+
+func TestInjectErrors(t *testing.T) {
+	ctx := context.Background()
+	pod := types.NamespacedName{Namespace: "default", Name: "pod-1"}
+	injector := newTestInjector(t, 0.5,
+		Fault{Metric: "http_requests", Namespace: "other", ErrorRates: map[metav1.StatusReason]float64{metav1.StatusReasonTimeout: 1}},
+		Fault{Metric: "http_requests", ErrorRates: map[metav1.StatusReason]float64{metav1.StatusReasonNotFound: 0.75}},
+		Fault{Metric: "queue_length", ErrorRates: map[metav1.StatusReason]float64{metav1.StatusReasonServiceUnavailable: 0.75}},
+	)
+
+	_, err := injector.GetMetricByName(ctx, pod, requestsInfo, labels.Everything())
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+	assert.EqualError(t, err, "the server could not find the metric http_requests for pods pod-1")
+	_, err = injector.GetMetricBySelector(ctx, "default", labels.Everything(), requestsInfo, labels.Everything())
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+	_, err = injector.GetMetricBySelector(ctx, "other", labels.Everything(), requestsInfo, labels.Everything())
+	assert.True(t, apierrors.IsTimeout(err), "unexpected error %v", err)
+	_, err = injector.GetExternalMetric(ctx, "default", labels.Everything(), queueInfo)
+	assert.True(t, apierrors.IsServiceUnavailable(err), "unexpected error %v", err)
+
+	// metrics without faults are served as is
+	value, err := injector.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, temperatureInfo, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, "1", value.Value.String())
+
+	// draws above the error rates don't fail
+	injector.random = func() float64 { return 0.8 }
+	_, err = injector.GetMetricByName(ctx, pod, requestsInfo, labels.Everything())
+	assert.NoError(t, err)
+	_, err = injector.GetExternalMetric(ctx, "default", labels.Everything(), queueInfo)
+	assert.NoError(t, err)
+}
+
+func TestInjectEmptyResults(t *testing.T) {
+	ctx := context.Background()
+	injector := newTestInjector(t, 0.5, Fault{EmptyRate: 0.6})
+
+	_, err := injector.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, temperatureInfo, labels.Everything())
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+	list, err := injector.GetMetricBySelector(ctx, "default", labels.Everything(), requestsInfo, labels.Everything())
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+	externalList, err := injector.GetExternalMetric(ctx, "default", labels.Everything(), queueInfo)
+	require.NoError(t, err)
+	assert.Empty(t, externalList.Items)
+
+	require.NoError(t, injector.SetConfig(Config{Faults: []Fault{{EmptyRate: 0.4}}}))
+	list, err = injector.GetMetricBySelector(ctx, "default", labels.Everything(), requestsInfo, labels.Everything())
+	require.NoError(t, err)
+	assert.Len(t, list.Items, 1)
+}
+
+func TestInjectStaleness(t *testing.T) {
+	ctx := context.Background()
+	injector := newTestInjector(t, 0, Fault{Staleness: metav1.Duration{Duration: time.Hour}})
+	stale := testTimestamp.Add(-time.Hour)
+
+	value, err := injector.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, temperatureInfo, labels.Everything())
+	require.NoError(t, err)
+	assert.WithinDuration(t, stale, value.Timestamp.Time, 0)
+	list, err := injector.GetMetricBySelector(ctx, "default", labels.Everything(), requestsInfo, labels.Everything())
+	require.NoError(t, err)
+	assert.WithinDuration(t, stale, list.Items[0].Timestamp.Time, 0)
+	externalList, err := injector.GetExternalMetric(ctx, "default", labels.Everything(), queueInfo)
+	require.NoError(t, err)
+	assert.WithinDuration(t, stale, externalList.Items[0].Timestamp.Time, 0)
+}
+
+func TestInjectLatency(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktesting.NewFakeClock(time.Now())
+	injector := newTestInjector(t, 0, Fault{Namespace: "default", Latency: metav1.Duration{Duration: time.Second}})
+	injector.clock = clock
+
+	done := make(chan error)
+	go func() {
+		_, err := injector.GetExternalMetric(ctx, "default", labels.Everything(), queueInfo)
+		done <- err
+	}()
+	require.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("the call returned before the injected latency")
+	default:
+	}
+	clock.Step(time.Second)
+	assert.NoError(t, <-done)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := injector.GetExternalMetric(ctx, "default", labels.Everything(), queueInfo)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = injector.GetExternalMetric(ctx, "other", labels.Everything(), queueInfo)
+	assert.NoError(t, err)
+}
+
+// reloadableTestProvider is a test provider which can be reloaded.
+type reloadableTestProvider struct {
+	testProvider
+}
+
+func (reloadableTestProvider) Reload(context.Context, []byte) error {
+	return nil
+}
+
+func TestOptionalInterfaces(t *testing.T) {
+	injector := newTestInjector(t, 0)
+	_, ok := any(injector).(provider.ReloadableProvider)
+	assert.False(t, ok, "the injector shouldn't be reloadable itself")
+	_, ok = provider.As[provider.ReloadableProvider](injector)
+	assert.False(t, ok, "the wrapped provider isn't reloadable")
+
+	injector, err := NewInjector(reloadableTestProvider{}, Config{})
+	require.NoError(t, err)
+	_, ok = provider.As[provider.ReloadableProvider](injector)
+	assert.True(t, ok, "the wrapped provider should be reloadable")
+}
+
+func TestServeHTTP(t *testing.T) {
+	injector := newTestInjector(t, 0)
+
+	serve := func(method, body string) (int, string) {
+		recorder := httptest.NewRecorder()
+		injector.ServeHTTP(recorder, httptest.NewRequest(method, Path, strings.NewReader(body)))
+		return recorder.Code, recorder.Body.String()
+	}
+
+	code, body := serve(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"faults": []}`, body)
+
+	config := `{"faults": [{"metric": "http_requests", "latency": "1s", "errorRates": {"Timeout": 0.5}, "emptyRate": 0.1, "staleness": "1m0s"}]}`
+	code, body = serve(http.MethodPut, config)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, config, body)
+	assert.Equal(t, []Fault{{
+		Metric:     "http_requests",
+		Latency:    metav1.Duration{Duration: time.Second},
+		ErrorRates: map[metav1.StatusReason]float64{metav1.StatusReasonTimeout: 0.5},
+		EmptyRate:  0.1,
+		Staleness:  metav1.Duration{Duration: time.Minute},
+	}}, injector.Config().Faults)
+
+	code, body = serve(http.MethodPut, `{"faults": [{"emptyRate": 2}]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "invalid fault 0: empty rate 2 is not between 0 and 1\n", body)
+	code, _ = serve(http.MethodPut, `{"faults": [{"errorRate": 1}]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Len(t, injector.Config().Faults, 1)
+
+	code, body = serve(http.MethodDelete, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"faults": []}`, body)
+	assert.Empty(t, injector.Config().Faults)
+
+	code, _ = serve(http.MethodPost, "{}")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
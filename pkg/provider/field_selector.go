@@ -0,0 +1,220 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+)
+
+// Field selector labels supported for custom metric values.
+//
+// Field selectors only support equality operators, so range comparisons are
+// expressed by suffixing the field with '>' or '<': a selector written as
+// "value>=10" is parsed as the field "value>" with the value "10", and
+// selects values greater than or equal to 10.
+const (
+	MetricFieldName                     = "metadata.name"
+	MetricFieldDescribedObjectName      = "describedObject.name"
+	MetricFieldDescribedObjectNamespace = "describedObject.namespace"
+	MetricFieldDescribedObjectKind      = "describedObject.kind"
+	MetricFieldValue                    = "value"
+	MetricFieldValueMin                 = "value>"
+	MetricFieldValueMax                 = "value<"
+	MetricFieldTimestampMin             = "timestamp>"
+	MetricFieldTimestampMax             = "timestamp<"
+)
+
+var supportedMetricFields = []string{
+	MetricFieldName,
+	MetricFieldDescribedObjectName,
+	MetricFieldDescribedObjectNamespace,
+	MetricFieldDescribedObjectKind,
+	MetricFieldValue,
+	MetricFieldValueMin,
+	MetricFieldValueMax,
+	MetricFieldTimestampMin,
+	MetricFieldTimestampMax,
+}
+
+// ConvertMetricFieldLabel validates a field selector label and value for
+// custom metric values.  It has the signature of runtime.FieldLabelConversionFunc
+// so that it can be registered with a scheme, which causes unsupported fields
+// to be rejected by the API handlers instead of being silently ignored.
+func ConvertMetricFieldLabel(label, value string) (string, string, error) {
+	switch label {
+	case MetricFieldName, MetricFieldDescribedObjectName, MetricFieldDescribedObjectNamespace, MetricFieldDescribedObjectKind:
+		return label, value, nil
+	case MetricFieldValue, MetricFieldValueMin, MetricFieldValueMax:
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return "", "", fmt.Errorf("invalid value %q for field selector %q: %v", value, label, err)
+		}
+		return label, value, nil
+	case MetricFieldTimestampMin, MetricFieldTimestampMax:
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "", "", fmt.Errorf("invalid value %q for field selector %q: %v", value, label, err)
+		}
+		return label, value, nil
+	default:
+		return "", "", fmt.Errorf("%q is not a known field selector: only %q", label, supportedMetricFields)
+	}
+}
+
+// MetricValueFilter filters custom metric values according to a field selector.
+// It is passed to providers through the request context as a pushdown hint, and
+// is applied to the results by the API server regardless of whether the provider
+// made use of it.
+type MetricValueFilter struct {
+	requirements fields.Requirements
+
+	// parsed forms of the range requirements, indexed like requirements
+	quantities []resource.Quantity
+	timestamps []time.Time
+}
+
+// NewMetricValueFilter constructs a MetricValueFilter from the given field selector,
+// which must only use the fields accepted by ConvertMetricFieldLabel.  Range fields
+// (those ending in '>' or '<') must use an equality operator.
+func NewMetricValueFilter(selector fields.Selector) (*MetricValueFilter, error) {
+	f := &MetricValueFilter{}
+	if selector == nil {
+		return f, nil
+	}
+
+	for _, req := range selector.Requirements() {
+		if _, _, err := ConvertMetricFieldLabel(req.Field, req.Value); err != nil {
+			return nil, err
+		}
+		// the name is taken from the request path, and is handled by selecting
+		// between fetching a single object and fetching by label selector.
+		if req.Field == MetricFieldName {
+			continue
+		}
+		f.requirements = append(f.requirements, req)
+	}
+
+	f.quantities = make([]resource.Quantity, len(f.requirements))
+	f.timestamps = make([]time.Time, len(f.requirements))
+	for i, req := range f.requirements {
+		switch req.Field {
+		case MetricFieldValueMin, MetricFieldValueMax, MetricFieldTimestampMin, MetricFieldTimestampMax:
+			if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals {
+				return nil, fmt.Errorf("field selector %q only supports the %q operator", req.Field, selection.Equals)
+			}
+		}
+		switch req.Field {
+		case MetricFieldValue, MetricFieldValueMin, MetricFieldValueMax:
+			f.quantities[i] = resource.MustParse(req.Value)
+		case MetricFieldTimestampMin, MetricFieldTimestampMax:
+			f.timestamps[i], _ = time.Parse(time.RFC3339, req.Value)
+		}
+	}
+
+	return f, nil
+}
+
+// Empty returns true if the filter matches every metric value.
+func (f *MetricValueFilter) Empty() bool {
+	return f == nil || len(f.requirements) == 0
+}
+
+// Requirements returns the field requirements making up this filter, so that
+// providers may translate them into queries against their backend.
+func (f *MetricValueFilter) Requirements() fields.Requirements {
+	if f == nil {
+		return nil
+	}
+	return f.requirements
+}
+
+// Matches returns true if the given metric value satisfies every requirement
+// of the filter.
+func (f *MetricValueFilter) Matches(value *custom_metrics.MetricValue) bool {
+	if f.Empty() {
+		return true
+	}
+
+	for i, req := range f.requirements {
+		var matches bool
+		switch req.Field {
+		case MetricFieldDescribedObjectName:
+			matches = value.DescribedObject.Name == req.Value
+		case MetricFieldDescribedObjectNamespace:
+			matches = value.DescribedObject.Namespace == req.Value
+		case MetricFieldDescribedObjectKind:
+			matches = value.DescribedObject.Kind == req.Value
+		case MetricFieldValue:
+			matches = value.Value.Cmp(f.quantities[i]) == 0
+		case MetricFieldValueMin:
+			matches = value.Value.Cmp(f.quantities[i]) >= 0
+		case MetricFieldValueMax:
+			matches = value.Value.Cmp(f.quantities[i]) <= 0
+		case MetricFieldTimestampMin:
+			matches = !value.Timestamp.Time.Before(f.timestamps[i])
+		case MetricFieldTimestampMax:
+			matches = !value.Timestamp.Time.After(f.timestamps[i])
+		}
+
+		if req.Operator == selection.NotEquals {
+			matches = !matches
+		}
+		if !matches {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Filter returns a new list containing only the items matching the filter.
+func (f *MetricValueFilter) Filter(list *custom_metrics.MetricValueList) *custom_metrics.MetricValueList {
+	if f.Empty() || list == nil {
+		return list
+	}
+
+	res := &custom_metrics.MetricValueList{
+		ListMeta: list.ListMeta,
+		Items:    make([]custom_metrics.MetricValue, 0, len(list.Items)),
+	}
+	for i := range list.Items {
+		if f.Matches(&list.Items[i]) {
+			res.Items = append(res.Items, list.Items[i])
+		}
+	}
+	return res
+}
+
+type metricValueFilterKey struct{}
+
+// WithMetricValueFilter returns a copy of the context carrying the given filter.
+func WithMetricValueFilter(ctx context.Context, filter *MetricValueFilter) context.Context {
+	return context.WithValue(ctx, metricValueFilterKey{}, filter)
+}
+
+// MetricValueFilterFrom returns the field selector filter for the current request,
+// if any.  Providers may use it to push the filtering down to their backend; the
+// API server filters the returned values again, so doing so is optional.
+func MetricValueFilterFrom(ctx context.Context) (*MetricValueFilter, bool) {
+	filter, ok := ctx.Value(metricValueFilterKey{}).(*MetricValueFilter)
+	return filter, ok && !filter.Empty()
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+)
+
+func TestMetricValueFilter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	list := &custom_metrics.MetricValueList{
+		Items: []custom_metrics.MetricValue{
+			{
+				DescribedObject: custom_metrics.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "a"},
+				Timestamp:       metav1.NewTime(now.Add(-time.Minute)),
+				Value:           resource.MustParse("5"),
+			},
+			{
+				DescribedObject: custom_metrics.ObjectReference{Kind: "Pod", Namespace: "ns", Name: "b"},
+				Timestamp:       metav1.NewTime(now),
+				Value:           resource.MustParse("10"),
+			},
+			{
+				DescribedObject: custom_metrics.ObjectReference{Kind: "Pod", Namespace: "other", Name: "c"},
+				Timestamp:       metav1.NewTime(now),
+				Value:           resource.MustParse("500m"),
+			},
+		},
+	}
+
+	cases := []struct {
+		selector string
+		expected []string
+	}{
+		{selector: "", expected: []string{"a", "b", "c"}},
+		{selector: "metadata.name=*", expected: []string{"a", "b", "c"}},
+		{selector: "describedObject.name=b", expected: []string{"b"}},
+		{selector: "describedObject.namespace!=ns", expected: []string{"c"}},
+		{selector: "describedObject.kind=Pod", expected: []string{"a", "b", "c"}},
+		{selector: "value=10", expected: []string{"b"}},
+		{selector: "value>=5", expected: []string{"a", "b"}},
+		{selector: "value<=5", expected: []string{"a", "c"}},
+		{selector: "value>=1,value<=9", expected: []string{"a"}},
+		{selector: "timestamp>=" + now.Format(time.RFC3339), expected: []string{"b", "c"}},
+		{selector: "timestamp<=" + now.Add(-time.Second).Format(time.RFC3339), expected: []string{"a"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.selector, func(t *testing.T) {
+			sel, err := fields.ParseSelector(c.selector)
+			require.NoError(t, err)
+			filter, err := NewMetricValueFilter(sel)
+			require.NoError(t, err)
+
+			var names []string
+			for _, item := range filter.Filter(list).Items {
+				names = append(names, item.DescribedObject.Name)
+			}
+			assert.Equal(t, c.expected, names)
+		})
+	}
+}
+
+func TestMetricValueFilterRejectsInvalidSelectors(t *testing.T) {
+	for _, selector := range []string{
+		"metadata.namespace=ns",
+		"spec.foo=bar",
+		"value=abc",
+		"value>!=5",
+		"timestamp>=yesterday",
+	} {
+		sel, err := fields.ParseSelector(selector)
+		require.NoError(t, err)
+		_, err = NewMetricValueFilter(sel)
+		assert.Error(t, err, "selector %q should have been rejected", selector)
+	}
+}
+
+func TestMetricValueFilterContext(t *testing.T) {
+	_, ok := MetricValueFilterFrom(context.Background())
+	assert.False(t, ok)
+
+	empty, err := NewMetricValueFilter(fields.Everything())
+	require.NoError(t, err)
+	_, ok = MetricValueFilterFrom(WithMetricValueFilter(context.Background(), empty))
+	assert.False(t, ok, "empty filters should not be surfaced to providers")
+
+	filter, err := NewMetricValueFilter(fields.OneTermEqualSelector(MetricFieldValueMin, "1"))
+	require.NoError(t, err)
+	fromCtx, ok := MetricValueFilterFrom(WithMetricValueFilter(context.Background(), filter))
+	assert.True(t, ok)
+	assert.Equal(t, filter.Requirements(), fromCtx.Requirements())
+}
@@ -19,6 +19,8 @@ package provider
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"time"
 
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
@@ -93,7 +95,8 @@ type CustomMetricsProvider interface {
 	// ListAllMetrics provides a list of all available metrics at
 	// the current time.  Note that this is not allowed to return
 	// an error, so it is recommended that implementors use the
-	// default implementation provided by DefaultCustomMetricsProvider.
+	// default implementation provided by DefaultCustomMetricsProvider,
+	// or implement CustomMetricsListerWithError.
 	ListAllMetrics() []CustomMetricInfo
 }
 
@@ -108,7 +111,8 @@ type ExternalMetricsProvider interface {
 	// external metrics at the current time.
 	// Note that this is not allowed to return an error, so it is
 	// recommended that implementors use the default implementation
-	// provided by DefaultExternalMetricsProvider.
+	// provided by DefaultExternalMetricsProvider, or implement
+	// ExternalMetricsListerWithError.
 	ListAllExternalMetrics() []ExternalMetricInfo
 }
 
@@ -116,3 +120,150 @@ type MetricsProvider interface {
 	CustomMetricsProvider
 	ExternalMetricsProvider
 }
+
+// CustomMetricsListerWithError is an optional interface which custom metrics
+// providers can implement to report the failures to list their metrics.  The
+// API server then prefers it to ListAllMetrics, and keeps serving the metrics
+// it last listed successfully when it fails, instead of losing them all.
+type CustomMetricsListerWithError interface {
+	// ListAllMetricsWithError provides a list of all available metrics at
+	// the current time, or an error if they can't be listed.
+	ListAllMetricsWithError(ctx context.Context) ([]CustomMetricInfo, error)
+}
+
+// ExternalMetricsListerWithError is an optional interface which external
+// metrics providers can implement to report the failures to list their
+// metrics, like CustomMetricsListerWithError.
+type ExternalMetricsListerWithError interface {
+	// ListAllExternalMetricsWithError provides a list of all available
+	// external metrics at the current time, or an error if they can't be
+	// listed.
+	ListAllExternalMetricsWithError(ctx context.Context) ([]ExternalMetricInfo, error)
+}
+
+// ListCustomMetrics lists the metrics of the given provider, with
+// ListAllMetricsWithError if it implements CustomMetricsListerWithError, or
+// wraps a provider implementing it.
+func ListCustomMetrics(ctx context.Context, provider CustomMetricsProvider) ([]CustomMetricInfo, error) {
+	if lister, ok := As[CustomMetricsListerWithError](provider); ok {
+		return lister.ListAllMetricsWithError(ctx)
+	}
+	return provider.ListAllMetrics(), nil
+}
+
+// ListExternalMetrics lists the metrics of the given provider, with
+// ListAllExternalMetricsWithError if it implements
+// ExternalMetricsListerWithError, or wraps a provider implementing it.
+func ListExternalMetrics(ctx context.Context, provider ExternalMetricsProvider) ([]ExternalMetricInfo, error) {
+	if lister, ok := As[ExternalMetricsListerWithError](provider); ok {
+		return lister.ListAllExternalMetricsWithError(ctx)
+	}
+	return provider.ListAllExternalMetrics(), nil
+}
+
+// SameProvider returns true if both providers are the same object, which is
+// common for adapters serving both custom and external metrics.
+func SameProvider(a, b interface{}) bool {
+	typ := reflect.TypeOf(a)
+	return typ != nil && typ == reflect.TypeOf(b) && typ.Comparable() && a == b
+}
+
+// HealthCheckingProvider is an optional interface which metrics providers can
+// implement to report whether their backend is reachable.  When a provider
+// implements it, the API server only reports itself ready while the check
+// succeeds, so that clients stop routing requests to an adapter which cannot
+// serve metrics.
+type HealthCheckingProvider interface {
+	// HealthCheck returns an error if the provider is currently unable to
+	// serve metrics.  The context is cancelled once the probe times out.
+	HealthCheck(ctx context.Context) error
+}
+
+// ReloadableProvider is an optional interface which metrics providers can
+// implement to have their configuration, such as the metrics they serve and
+// the backend queries used to compute them, reloaded without a restart.
+type ReloadableProvider interface {
+	// Reload validates the given configuration and switches to it.  If an
+	// error is returned, the provider must keep serving with its previous
+	// configuration.
+	Reload(ctx context.Context, config []byte) error
+}
+
+// CacheStats describes a cache kept by a metrics provider.
+type CacheStats struct {
+	// Name identifies the cache among the ones of the provider.
+	Name string `json:"name"`
+	// Entries is the number of entries currently in the cache.
+	Entries int `json:"entries"`
+	// Hits and Misses count the lookups served from the cache, and the
+	// ones which weren't.
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// LastRefreshTime is the time at which the cache was last refreshed,
+	// if it is refreshed as a whole.
+	LastRefreshTime time.Time `json:"lastRefreshTime,omitzero"`
+	// LastRefreshError is the error of the last refresh, if it failed.
+	LastRefreshError string `json:"lastRefreshError,omitempty"`
+}
+
+// CacheStatsProvider is an optional interface which metrics providers can
+// implement to report the state of their caches in the metrics inventory
+// served by the adapter for debugging.
+type CacheStatsProvider interface {
+	// CacheStats returns the current state of the caches of the provider.
+	CacheStats() []CacheStats
+}
+
+// MetricsChangeNotifier is an optional interface which metrics providers can
+// implement to notify the API server when the metrics they list change.  The
+// API server then lists them again right away when it caches them for
+// discovery, instead of waiting for its next periodic refresh.
+type MetricsChangeNotifier interface {
+	// OnMetricsChange registers a function to call whenever the metrics
+	// listed by ListAllMetrics or ListAllExternalMetrics change.  The
+	// function doesn't block, and can be called from any goroutine.
+	OnMetricsChange(notify func())
+}
+
+// WrappingProvider is implemented by metrics providers wrapping another
+// provider, such as the recording and fault injecting providers.  The
+// optional provider interfaces are looked up through the wrapped providers
+// with As, so that the API server only relies on the interfaces the wrapped
+// provider actually implements.  Wrappers only implement them to observe
+// their calls, like the recording provider implementing the listers with
+// errors to record the listings: the API server then looks at the innermost
+// provider, see Innermost, for the behavior depending on the wrapped
+// provider, such as the listing readiness checks.
+type WrappingProvider interface {
+	// Unwrap returns the wrapped provider.
+	Unwrap() MetricsProvider
+}
+
+// As returns the first provider implementing T in the chain of providers
+// wrapped by prov, starting with prov itself, if any.
+func As[T any](prov any) (T, bool) {
+	for prov != nil {
+		if found, ok := prov.(T); ok {
+			return found, true
+		}
+		wrapper, ok := prov.(WrappingProvider)
+		if !ok {
+			break
+		}
+		prov = wrapper.Unwrap()
+	}
+	var none T
+	return none, false
+}
+
+// Innermost returns the provider at the end of the chain of providers
+// wrapped by prov, or prov itself if it doesn't wrap any.
+func Innermost(prov any) any {
+	for {
+		wrapper, ok := prov.(WrappingProvider)
+		if !ok {
+			return prov
+		}
+		prov = wrapper.Unwrap()
+	}
+}
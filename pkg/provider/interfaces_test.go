@@ -17,6 +17,7 @@ limitations under the License.
 package provider
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -69,3 +70,40 @@ func TestNormalizeMetricInfoDealsWithPluralization(t *testing.T) {
 	assert.Equal(t, singularRes, pluralSingularRes, "the plural and singular MetricInfo should have the same singularized resource")
 	assert.Equal(t, singularNormalized, pluralNormalized, "the plural and singular MetricInfo should have the same normailzed form")
 }
+
+type reloadableProvider struct {
+	MetricsProvider
+}
+
+func (p *reloadableProvider) Reload(context.Context, []byte) error {
+	return nil
+}
+
+type wrappingProvider struct {
+	MetricsProvider
+	wrapped MetricsProvider
+}
+
+func (p *wrappingProvider) Unwrap() MetricsProvider {
+	return p.wrapped
+}
+
+func TestAs(t *testing.T) {
+	reloadable := &reloadableProvider{}
+	wrapper := &wrappingProvider{wrapped: &wrappingProvider{wrapped: reloadable}}
+
+	found, ok := As[ReloadableProvider](wrapper)
+	require.True(t, ok, "should have found the reloadable provider wrapped twice")
+	assert.Same(t, reloadable, found)
+
+	_, ok = As[HealthCheckingProvider](wrapper)
+	assert.False(t, ok, "wrappers shouldn't implement the optional interfaces of the providers they wrap")
+	_, ok = As[ReloadableProvider](&wrappingProvider{})
+	assert.False(t, ok)
+	_, ok = As[ReloadableProvider](nil)
+	assert.False(t, ok)
+
+	assert.Same(t, reloadable, Innermost(wrapper))
+	assert.Same(t, reloadable, Innermost(reloadable))
+	assert.Nil(t, Innermost(nil))
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// invalidationGapRatio is the ratio of the refresh interval to the minimum
+// gap between a refresh and the next one triggered by an invalidation.
+const invalidationGapRatio = 10
+
+// ListingCache caches the metrics listed by a provider, so that discovery
+// requests don't list them from the provider each time.  The metrics are
+// listed again once they are older than the refresh interval, either in the
+// background while the cache runs, or on the next request otherwise, so that
+// the provider is listed at most once per interval on behalf of clients.
+// Providers implementing MetricsChangeNotifier can have the cache
+// invalidated as soon as their metrics change; the invalidations are
+// coalesced so that the metrics are listed at most once per tenth of the
+// interval on their behalf.
+//
+// When listing the metrics fails, which providers implementing
+// CustomMetricsListerWithError or ExternalMetricsListerWithError can report,
+// the cache keeps serving the metrics it last listed successfully.
+type ListingCache[T any] struct {
+	name     string
+	list     func(context.Context) ([]T, error)
+	interval time.Duration
+	clock    clock.WithTicker
+	observe  func(error)
+
+	// listLock serializes the listings, so that concurrent requests
+	// waiting for the metrics to be listed only list them once.
+	listLock sync.Mutex
+	// invalidated wakes up the background refreshes.
+	invalidated chan struct{}
+
+	lock        sync.RWMutex
+	metrics     []T
+	refreshTime time.Time
+	err         error
+	stale       bool
+	hits        int64
+	misses      int64
+}
+
+var _ CacheStatsProvider = &ListingCache[CustomMetricInfo]{}
+
+// NewCustomMetricsListingCache returns a cache of the metrics listed by the
+// given provider, refreshed at the given interval.  If the interval is zero,
+// the metrics are listed on each request, and the cache only serves the ones
+// last listed successfully when listing them fails.
+func NewCustomMetricsListingCache(provider CustomMetricsProvider, interval time.Duration) *ListingCache[CustomMetricInfo] {
+	list := func(ctx context.Context) ([]CustomMetricInfo, error) {
+		return ListCustomMetrics(ctx, provider)
+	}
+	return newListingCache("custom-metrics-listing", list, interval, clock.RealClock{})
+}
+
+// NewExternalMetricsListingCache returns a cache of the metrics listed by
+// the given provider, refreshed at the given interval, like
+// NewCustomMetricsListingCache.
+func NewExternalMetricsListingCache(provider ExternalMetricsProvider, interval time.Duration) *ListingCache[ExternalMetricInfo] {
+	list := func(ctx context.Context) ([]ExternalMetricInfo, error) {
+		return ListExternalMetrics(ctx, provider)
+	}
+	return newListingCache("external-metrics-listing", list, interval, clock.RealClock{})
+}
+
+func newListingCache[T any](name string, list func(context.Context) ([]T, error), interval time.Duration, clock clock.WithTicker) *ListingCache[T] {
+	return &ListingCache[T]{
+		name:        name,
+		list:        list,
+		interval:    interval,
+		clock:       clock,
+		invalidated: make(chan struct{}, 1),
+		stale:       true,
+	}
+}
+
+// WithObserver sets a function called with the outcome of each listing of
+// the metrics.
+func (c *ListingCache[T]) WithObserver(observe func(err error)) *ListingCache[T] {
+	c.observe = observe
+	return c
+}
+
+// List returns the cached metrics, listing them first if they are stale.
+func (c *ListingCache[T]) List() []T {
+	c.lock.Lock()
+	fresh, metrics := c.freshLocked(), c.metrics
+	if fresh {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.lock.Unlock()
+
+	if !fresh {
+		// discovery requests don't carry their context to the listers
+		metrics, _ = c.refresh(context.Background(), false)
+	}
+	return slices.Clone(metrics)
+}
+
+// Refresh lists the metrics from the provider right away, and returns the
+// error of the listing, if any.
+func (c *ListingCache[T]) Refresh(ctx context.Context) error {
+	_, err := c.refresh(ctx, true)
+	return err
+}
+
+// Err returns the error of the last listing of the metrics, if it failed.
+func (c *ListingCache[T]) Err() error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.err
+}
+
+// Invalidate marks the cached metrics as stale, so that they are listed
+// again in the background if the cache runs, or on the next request
+// otherwise.  It can be registered with providers implementing
+// MetricsChangeNotifier.
+func (c *ListingCache[T]) Invalidate() {
+	c.lock.Lock()
+	c.stale = true
+	c.lock.Unlock()
+
+	select {
+	case c.invalidated <- struct{}{}:
+	default:
+		// a refresh is already pending
+	}
+}
+
+// Run refreshes the cached metrics every interval, which must be positive,
+// and when the cache is invalidated, until the context is done.  The
+// refreshes triggered by invalidations wait for a tenth of the interval
+// since the previous refresh, coalescing the invalidations in between.
+func (c *ListingCache[T]) Run(ctx context.Context) {
+	_, _ = c.refresh(ctx, true)
+
+	ticker := c.clock.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			_, _ = c.refresh(ctx, true)
+		case <-c.invalidated:
+			if !c.waitForInvalidationGap(ctx) {
+				return
+			}
+			_, _ = c.refresh(ctx, false)
+		}
+	}
+}
+
+// waitForInvalidationGap waits until the minimum gap between refreshes
+// triggered by invalidations has passed since the last refresh, and returns
+// false if the context is done first.
+func (c *ListingCache[T]) waitForInvalidationGap(ctx context.Context) bool {
+	c.lock.RLock()
+	wait := c.interval/invalidationGapRatio - c.clock.Since(c.refreshTime)
+	c.lock.RUnlock()
+	if wait <= 0 {
+		return true
+	}
+
+	timer := c.clock.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
+
+// CacheStats implements CacheStatsProvider.
+func (c *ListingCache[T]) CacheStats() []CacheStats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	stats := CacheStats{
+		Name:            c.name,
+		Entries:         len(c.metrics),
+		Hits:            c.hits,
+		Misses:          c.misses,
+		LastRefreshTime: c.refreshTime,
+	}
+	if c.err != nil {
+		stats.LastRefreshError = c.err.Error()
+	}
+	return []CacheStats{stats}
+}
+
+// refresh lists the metrics from the provider, unless they were listed by
+// a concurrent refresh and force isn't set, and returns the metrics served
+// from then on, along with the error of the listing.
+func (c *ListingCache[T]) refresh(ctx context.Context, force bool) ([]T, error) {
+	c.listLock.Lock()
+	defer c.listLock.Unlock()
+
+	if !force {
+		c.lock.RLock()
+		fresh, metrics, err := c.freshLocked(), c.metrics, c.err
+		c.lock.RUnlock()
+		if fresh {
+			return metrics, err
+		}
+	}
+
+	// the cache is marked fresh before listing, so that an invalidation
+	// during the listing triggers another one
+	c.lock.Lock()
+	c.stale = false
+	c.lock.Unlock()
+
+	metrics, err := c.list(ctx)
+	now := c.clock.Now()
+	if c.observe != nil {
+		c.observe(err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// failed listings are only retried after the interval too, so that a
+	// failing backend isn't listed on each request
+	c.refreshTime = now
+	c.err = err
+	if err != nil {
+		klog.ErrorS(err, "Unable to list the metrics, serving the ones listed last", "cache", c.name)
+		return c.metrics, err
+	}
+	c.metrics = metrics
+	return metrics, nil
+}
+
+func (c *ListingCache[T]) freshLocked() bool {
+	return !c.stale && c.clock.Since(c.refreshTime) < c.interval
+}
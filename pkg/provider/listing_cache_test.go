@@ -0,0 +1,179 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// countingLister lists the metrics it is given, or fails with the error it
+// is given, and counts the listings.
+type countingLister struct {
+	lock     sync.Mutex
+	metrics  []ExternalMetricInfo
+	err      error
+	listings int
+}
+
+func (l *countingLister) set(metrics ...string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.metrics = nil
+	for _, metric := range metrics {
+		l.metrics = append(l.metrics, ExternalMetricInfo{Metric: metric})
+	}
+}
+
+func (l *countingLister) fail(err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.err = err
+}
+
+func (l *countingLister) list(_ context.Context) ([]ExternalMetricInfo, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.listings++
+	if l.err != nil {
+		return nil, l.err
+	}
+	return l.metrics, nil
+}
+
+func (l *countingLister) count() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.listings
+}
+
+func TestListingCacheList(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	lister := &countingLister{}
+	lister.set("queue_length")
+	cache := newListingCache("test", lister.list, time.Minute, clock)
+
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_length"}}, cache.List())
+	assert.Equal(t, 1, lister.count(), "the first request should list the metrics")
+
+	lister.set("queue_length", "queue_age")
+	clock.Step(30 * time.Second)
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_length"}}, cache.List())
+	assert.Equal(t, 1, lister.count(), "the requests should be served from the cache within the interval")
+
+	clock.Step(30 * time.Second)
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_length"}, {Metric: "queue_age"}}, cache.List())
+	assert.Equal(t, 2, lister.count(), "the metrics should be listed again once the interval elapsed")
+
+	assert.Equal(t, []CacheStats{{
+		Name:            "test",
+		Entries:         2,
+		Hits:            1,
+		Misses:          2,
+		LastRefreshTime: clock.Now(),
+	}}, cache.CacheStats())
+}
+
+func TestListingCacheInvalidate(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	lister := &countingLister{}
+	lister.set("queue_length")
+	cache := newListingCache("test", lister.list, time.Minute, clock)
+	cache.List()
+
+	lister.set("queue_age")
+	cache.Invalidate()
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_age"}}, cache.List(), "an invalidated cache should list the metrics again")
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_age"}}, cache.List())
+	assert.Equal(t, 2, lister.count())
+}
+
+func TestListingCacheErrors(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	lister := &countingLister{}
+	lister.set("queue_length")
+	var observed []error
+	cache := newListingCache("test", lister.list, 0, clock).WithObserver(func(err error) {
+		observed = append(observed, err)
+	})
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_length"}}, cache.List())
+
+	listErr := errors.New("backend unavailable")
+	lister.fail(listErr)
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_length"}}, cache.List(), "the metrics last listed should be served when listing fails")
+	assert.Equal(t, listErr, cache.Err())
+	assert.Equal(t, listErr, cache.Refresh(context.Background()))
+	assert.Equal(t, "backend unavailable", cache.CacheStats()[0].LastRefreshError)
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_length"}}, cache.List())
+	assert.Equal(t, 4, lister.count(), "the metrics should be listed on each request without an interval")
+
+	lister.fail(nil)
+	lister.set("queue_age")
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_age"}}, cache.List())
+	assert.NoError(t, cache.Err())
+	assert.Empty(t, cache.CacheStats()[0].LastRefreshError)
+	assert.Equal(t, []error{nil, listErr, listErr, listErr, nil}, observed)
+}
+
+func TestListingCacheRun(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	lister := &countingLister{}
+	lister.set("queue_length")
+	cache := newListingCache("test", lister.list, time.Minute, clock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond, "the cache should wait for its next refresh")
+	assert.Equal(t, 1, lister.count(), "the metrics should be listed when the cache starts")
+
+	lister.set("queue_age")
+	clock.Step(time.Minute)
+	assert.Eventually(t, func() bool { return lister.count() == 2 }, time.Second, time.Millisecond, "the metrics should be listed again every interval")
+	assert.Equal(t, []ExternalMetricInfo{{Metric: "queue_age"}}, cache.List())
+	assert.Equal(t, 2, lister.count(), "the requests should be served from the cache")
+
+	lister.set("queue_length")
+	cache.Invalidate()
+	cache.Invalidate()
+	assert.Never(t, func() bool { return lister.count() > 2 }, 100*time.Millisecond, time.Millisecond, "the invalidations right after a refresh should wait")
+	cache.Invalidate()
+	clock.Step(time.Minute / invalidationGapRatio)
+	assert.Eventually(t, func() bool { return lister.count() == 3 }, time.Second, time.Millisecond, "the metrics should be listed again once invalidated")
+	// the listing may still be in progress, during which the previous
+	// metrics are served
+	assert.Eventually(t, func() bool {
+		return slices.Equal([]ExternalMetricInfo{{Metric: "queue_length"}}, cache.List())
+	}, time.Second, time.Millisecond)
+	assert.Never(t, func() bool { return lister.count() > 3 }, 100*time.Millisecond, time.Millisecond, "the invalidations should be coalesced")
+}
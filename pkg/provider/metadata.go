@@ -0,0 +1,117 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MetricKind is the kind of the values of a metric.
+type MetricKind string
+
+const (
+	// MetricKindGauge metrics have values which go up and down, like a
+	// queue length or a temperature.
+	MetricKindGauge MetricKind = "gauge"
+	// MetricKindRate metrics have values which are changes per second, like
+	// a number of requests per second.
+	MetricKindRate MetricKind = "rate"
+	// MetricKindCounter metrics have values which only go up, until they
+	// are reset, like a total number of requests.
+	MetricKindCounter MetricKind = "counter"
+)
+
+// MetricKinds lists the supported kinds of metrics.
+var MetricKinds = []MetricKind{MetricKindGauge, MetricKindRate, MetricKindCounter}
+
+// The categories of the discovery resources of the metrics describe their
+// metadata, as a prefix and a value.
+const (
+	kindCategoryPrefix = "kind:"
+	unitCategoryPrefix = "unit:"
+)
+
+// MetricMetadata describes a metric to its consumers.  All its fields are
+// optional.
+type MetricMetadata struct {
+	// Unit is the unit of the values of the metric, such as "bytes" or
+	// "requests/s".
+	Unit string `json:"unit,omitempty"`
+	// Kind is the kind of the values of the metric.
+	Kind MetricKind `json:"kind,omitempty"`
+	// Description describes what the metric measures.
+	Description string `json:"description,omitempty"`
+	// SelectableLabels are the labels which can be used in the metric
+	// selectors of the queries of the metric.
+	SelectableLabels []string `json:"selectableLabels,omitempty"`
+}
+
+// MetricMetadataProvider is an optional interface which metrics providers
+// can implement to describe the metrics they list.  The kind and unit of the
+// metrics are served as categories of their discovery resources, and their
+// whole metadata by the metadata endpoint of the adapter.
+type MetricMetadataProvider interface {
+	// CustomMetricMetadata returns the metadata of a custom metric listed
+	// by ListAllMetrics, or nil if it has none.
+	CustomMetricMetadata(info CustomMetricInfo) *MetricMetadata
+	// ExternalMetricMetadata returns the metadata of an external metric
+	// listed by ListAllExternalMetrics, or nil if it has none.
+	ExternalMetricMetadata(info ExternalMetricInfo) *MetricMetadata
+}
+
+// Validate checks that the kind of the metric is a supported one.
+func (m *MetricMetadata) Validate() error {
+	if m.Kind != "" && !slices.Contains(MetricKinds, m.Kind) {
+		return fmt.Errorf("unsupported metric kind %q, expected one of %v", m.Kind, MetricKinds)
+	}
+	return nil
+}
+
+// Categories returns the categories describing the kind and unit of the
+// metric in its discovery resource, as "kind:<kind>" and "unit:<unit>".
+func (m *MetricMetadata) Categories() []string {
+	if m == nil {
+		return nil
+	}
+	var categories []string
+	if m.Kind != "" {
+		categories = append(categories, kindCategoryPrefix+string(m.Kind))
+	}
+	if m.Unit != "" {
+		categories = append(categories, unitCategoryPrefix+m.Unit)
+	}
+	return categories
+}
+
+// MetricMetadataFromCategories returns the metadata described by the
+// categories of a discovery resource, or nil if they describe none.
+func MetricMetadataFromCategories(categories []string) *MetricMetadata {
+	metadata := MetricMetadata{}
+	for _, category := range categories {
+		if kind, found := strings.CutPrefix(category, kindCategoryPrefix); found {
+			metadata.Kind = MetricKind(kind)
+		} else if unit, found := strings.CutPrefix(category, unitCategoryPrefix); found {
+			metadata.Unit = unit
+		}
+	}
+	if metadata.Kind == "" && metadata.Unit == "" {
+		return nil
+	}
+	return &metadata
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricMetadataCategories(t *testing.T) {
+	var none *MetricMetadata
+	assert.Empty(t, none.Categories())
+	assert.Nil(t, MetricMetadataFromCategories(nil))
+	assert.Nil(t, MetricMetadataFromCategories([]string{"all"}))
+
+	metadata := &MetricMetadata{Unit: "requests/s", Kind: MetricKindRate, Description: "Requests served"}
+	categories := metadata.Categories()
+	assert.Equal(t, []string{"kind:rate", "unit:requests/s"}, categories)
+	// descriptions and selectable labels are only served by the metadata
+	// endpoint
+	assert.Equal(t, &MetricMetadata{Unit: "requests/s", Kind: MetricKindRate}, MetricMetadataFromCategories(append(categories, "all")))
+
+	assert.Equal(t, []string{"unit:bytes"}, (&MetricMetadata{Unit: "bytes"}).Categories())
+}
+
+func TestMetricMetadataValidate(t *testing.T) {
+	for _, kind := range append(MetricKinds, "") {
+		assert.NoError(t, (&MetricMetadata{Kind: kind}).Validate(), "kind %q", kind)
+	}
+	assert.Error(t, (&MetricMetadata{Kind: "histogram"}).Validate())
+}
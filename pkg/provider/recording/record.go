@@ -0,0 +1,172 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package recording records the calls to metrics providers, and replays them
+// to reproduce the behaviour of an adapter offline.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	emv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// Method identifies the provider method of a recorded call.
+type Method string
+
+// The methods of the provider interfaces.
+const (
+	GetMetricByName        Method = "GetMetricByName"
+	GetMetricBySelector    Method = "GetMetricBySelector"
+	ListAllMetrics         Method = "ListAllMetrics"
+	GetExternalMetric      Method = "GetExternalMetric"
+	ListAllExternalMetrics Method = "ListAllExternalMetrics"
+)
+
+// MetricInfo describes a metric, in the same way as
+// provider.CustomMetricInfo and provider.ExternalMetricInfo.  Resource is empty
+// for external metrics.
+type MetricInfo struct {
+	Resource   string `json:"resource,omitempty"`
+	Namespaced bool   `json:"namespaced,omitempty"`
+	Metric     string `json:"metric"`
+}
+
+// ListedMetric is a metric listed by a provider, along with its metadata if
+// the provider implements provider.MetricMetadataProvider.
+type ListedMetric struct {
+	MetricInfo `json:",inline"`
+	Metadata   *provider.MetricMetadata `json:"metadata,omitempty"`
+}
+
+// Call holds the inputs of a provider call.  Replays match recorded calls
+// by all of its fields, so the selectors are recorded in their canonical
+// string form.
+type Call struct {
+	Method         Method `json:"method"`
+	Namespace      string `json:"namespace,omitempty"`
+	Name           string `json:"name,omitempty"`
+	MetricInfo     `json:",inline"`
+	Selector       string `json:"selector,omitempty"`
+	MetricSelector string `json:"metricSelector,omitempty"`
+}
+
+// Error is an error returned by a provider.  Code and Reason are only set for
+// API status errors, so that replays return the same status to clients.
+type Error struct {
+	Message string              `json:"message"`
+	Reason  metav1.StatusReason `json:"reason,omitempty"`
+	Code    int32               `json:"code,omitempty"`
+}
+
+// Record is a recorded provider call.  Records are written as one JSON
+// object per line; metric values use the custom.metrics.k8s.io/v1beta2 and
+// external.metrics.k8s.io/v1beta1 representations served by the adapter.
+type Record struct {
+	// Time is the time the call started at.
+	Time time.Time `json:"time"`
+	// Latency is the time the provider took to answer.
+	Latency metav1.Duration `json:"latency"`
+	Call    Call            `json:"call"`
+
+	CustomMetrics   []cmv1beta2.MetricValue         `json:"customMetrics,omitempty"`
+	ExternalMetrics []emv1beta1.ExternalMetricValue `json:"externalMetrics,omitempty"`
+	// Metrics holds the metrics listed by the ListAll methods.
+	Metrics []ListedMetric `json:"metrics,omitempty"`
+	Error   *Error         `json:"error,omitempty"`
+}
+
+// errorFor returns the recorded form of err, or nil.
+func errorFor(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	recorded := &Error{Message: err.Error()}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		recorded.Reason = status.Status().Reason
+		recorded.Code = status.Status().Code
+	}
+	return recorded
+}
+
+// Err returns the error e was recorded from, as a status error when it was
+// one.
+func (e *Error) Err() error {
+	if e == nil {
+		return nil
+	}
+	if e.Reason == "" && e.Code == 0 {
+		return errors.New(e.Message)
+	}
+	code := e.Code
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    code,
+		Reason:  e.Reason,
+		Message: e.Message,
+	}}
+}
+
+// ReadRecords reads the records written to r by a Recorder.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var record Record
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return nil, fmt.Errorf("unable to decode record %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// ReadFiles reads the records of the given files, such as a recording file
+// and its rotated backups.  Records are returned in the order of the files.
+func ReadFiles(paths ...string) ([]Record, error) {
+	var records []Record
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		fileRecords, err := ReadRecords(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read recording %s: %w", path, err)
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, nil
+}
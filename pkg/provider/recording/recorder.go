@@ -0,0 +1,298 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	emv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// FileOptions configures the file a Recorder writes to.  The file is rotated
+// once it reaches MaxSizeMB, keeping at most MaxBackups rotated files for at
+// most MaxAgeDays; zero values keep the lumberjack defaults: 100 megabytes,
+// and all the backups.
+type FileOptions struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	// Compress gzips the rotated files.
+	Compress bool
+}
+
+// Recorder is a metrics provider recording every call to the provider it
+// wraps, along with its results and latency.
+//
+// It is a provider.WrappingProvider, so the optional provider interfaces are
+// looked up on the wrapped provider, and their calls aren't recorded, except
+// for the listers with errors, which it implements to record the listings
+// along with their errors.  It
+// implements the Start method of cmd.Runnable, running the wrapped provider
+// if it has a Start method, and closing its output once its context is done.
+type Recorder struct {
+	provider provider.MetricsProvider
+	clock    clock.PassiveClock
+
+	lock    sync.Mutex
+	out     io.Writer
+	encoder *json.Encoder
+}
+
+var (
+	_ provider.MetricsProvider  = &Recorder{}
+	_ provider.WrappingProvider = &Recorder{}
+
+	_ provider.CustomMetricsListerWithError   = &Recorder{}
+	_ provider.ExternalMetricsListerWithError = &Recorder{}
+)
+
+// NewRecorder returns a provider recording the calls to prov to out.  It
+// writes each record with a single call to out.Write.
+func NewRecorder(prov provider.MetricsProvider, out io.Writer) *Recorder {
+	return &Recorder{
+		provider: prov,
+		clock:    clock.RealClock{},
+		out:      out,
+		encoder:  json.NewEncoder(out),
+	}
+}
+
+// NewFileRecorder returns a provider recording the calls to prov to a
+// rotated file.
+func NewFileRecorder(prov provider.MetricsProvider, options FileOptions) (*Recorder, error) {
+	if options.Path == "" {
+		return nil, fmt.Errorf("no recording file configured")
+	}
+	return NewRecorder(prov, &lumberjack.Logger{
+		Filename:   options.Path,
+		MaxSize:    options.MaxSizeMB,
+		MaxBackups: options.MaxBackups,
+		MaxAge:     options.MaxAgeDays,
+		Compress:   options.Compress,
+	}), nil
+}
+
+// Close closes the output of the recorder, when it is an io.Closer.
+func (r *Recorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if closer, ok := r.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// record writes the record of a call started at start.
+func (r *Recorder) record(start time.Time, record Record) {
+	record.Time = start
+	record.Latency = metav1.Duration{Duration: r.clock.Since(start)}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err := r.encoder.Encode(&record); err != nil {
+		klog.ErrorS(err, "Unable to record metrics provider call", "method", record.Call.Method)
+	}
+}
+
+func customMetricInfo(info provider.CustomMetricInfo) MetricInfo {
+	return MetricInfo{Resource: info.GroupResource.String(), Namespaced: info.Namespaced, Metric: info.Metric}
+}
+
+func selectorString(selector labels.Selector) string {
+	if selector == nil {
+		return ""
+	}
+	return selector.String()
+}
+
+func (r *Recorder) GetMetricByName(ctx context.Context, name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	start := r.clock.Now()
+	value, err := r.provider.GetMetricByName(ctx, name, info, metricSelector)
+
+	record := Record{
+		Call: Call{
+			Method:         GetMetricByName,
+			Namespace:      name.Namespace,
+			Name:           name.Name,
+			MetricInfo:     customMetricInfo(info),
+			MetricSelector: selectorString(metricSelector),
+		},
+		Error: errorFor(err),
+	}
+	if value != nil {
+		record.CustomMetrics = customMetricsFor(*value)
+	}
+	r.record(start, record)
+	return value, err
+}
+
+func (r *Recorder) GetMetricBySelector(ctx context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	start := r.clock.Now()
+	list, err := r.provider.GetMetricBySelector(ctx, namespace, selector, info, metricSelector)
+
+	record := Record{
+		Call: Call{
+			Method:         GetMetricBySelector,
+			Namespace:      namespace,
+			MetricInfo:     customMetricInfo(info),
+			Selector:       selectorString(selector),
+			MetricSelector: selectorString(metricSelector),
+		},
+		Error: errorFor(err),
+	}
+	if list != nil {
+		record.CustomMetrics = customMetricsFor(list.Items...)
+	}
+	r.record(start, record)
+	return list, err
+}
+
+func (r *Recorder) ListAllMetrics() []provider.CustomMetricInfo {
+	start := r.clock.Now()
+	infos := r.provider.ListAllMetrics()
+	r.recordCustomMetricsListing(start, infos, nil)
+	return infos
+}
+
+// ListAllMetricsWithError lists the metrics of the wrapped provider, with
+// ListAllMetricsWithError if it implements it, and records the listing.
+func (r *Recorder) ListAllMetricsWithError(ctx context.Context) ([]provider.CustomMetricInfo, error) {
+	start := r.clock.Now()
+	infos, err := provider.ListCustomMetrics(ctx, r.provider)
+	r.recordCustomMetricsListing(start, infos, err)
+	return infos, err
+}
+
+func (r *Recorder) recordCustomMetricsListing(start time.Time, infos []provider.CustomMetricInfo, err error) {
+	record := Record{Call: Call{Method: ListAllMetrics}, Error: errorFor(err)}
+	metadata, described := provider.As[provider.MetricMetadataProvider](r.provider)
+	for _, info := range infos {
+		listed := ListedMetric{MetricInfo: customMetricInfo(info)}
+		if described {
+			listed.Metadata = metadata.CustomMetricMetadata(info)
+		}
+		record.Metrics = append(record.Metrics, listed)
+	}
+	r.record(start, record)
+}
+
+func (r *Recorder) GetExternalMetric(ctx context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	start := r.clock.Now()
+	list, err := r.provider.GetExternalMetric(ctx, namespace, metricSelector, info)
+
+	record := Record{
+		Call: Call{
+			Method:         GetExternalMetric,
+			Namespace:      namespace,
+			MetricInfo:     MetricInfo{Metric: info.Metric},
+			MetricSelector: selectorString(metricSelector),
+		},
+		Error: errorFor(err),
+	}
+	if list != nil {
+		record.ExternalMetrics = externalMetricsFor(list.Items)
+	}
+	r.record(start, record)
+	return list, err
+}
+
+func (r *Recorder) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	start := r.clock.Now()
+	infos := r.provider.ListAllExternalMetrics()
+	r.recordExternalMetricsListing(start, infos, nil)
+	return infos
+}
+
+// ListAllExternalMetricsWithError lists the metrics of the wrapped provider,
+// with ListAllExternalMetricsWithError if it implements it, and records the
+// listing.
+func (r *Recorder) ListAllExternalMetricsWithError(ctx context.Context) ([]provider.ExternalMetricInfo, error) {
+	start := r.clock.Now()
+	infos, err := provider.ListExternalMetrics(ctx, r.provider)
+	r.recordExternalMetricsListing(start, infos, err)
+	return infos, err
+}
+
+func (r *Recorder) recordExternalMetricsListing(start time.Time, infos []provider.ExternalMetricInfo, err error) {
+	record := Record{Call: Call{Method: ListAllExternalMetrics}, Error: errorFor(err)}
+	metadata, described := provider.As[provider.MetricMetadataProvider](r.provider)
+	for _, info := range infos {
+		listed := ListedMetric{MetricInfo: MetricInfo{Metric: info.Metric}}
+		if described {
+			listed.Metadata = metadata.ExternalMetricMetadata(info)
+		}
+		record.Metrics = append(record.Metrics, listed)
+	}
+	r.record(start, record)
+}
+
+// customMetricsFor converts values to their recorded form.
+func customMetricsFor(values ...custom_metrics.MetricValue) []cmv1beta2.MetricValue {
+	recorded := make([]cmv1beta2.MetricValue, len(values))
+	for i := range values {
+		// the conversion of metric values never fails
+		_ = cmv1beta2.Convert_custom_metrics_MetricValue_To_v1beta2_MetricValue(&values[i], &recorded[i], nil)
+	}
+	return recorded
+}
+
+// externalMetricsFor converts values to their recorded form.
+func externalMetricsFor(values []external_metrics.ExternalMetricValue) []emv1beta1.ExternalMetricValue {
+	recorded := make([]emv1beta1.ExternalMetricValue, len(values))
+	for i := range values {
+		_ = emv1beta1.Convert_external_metrics_ExternalMetricValue_To_v1beta1_ExternalMetricValue(&values[i], &recorded[i], nil)
+	}
+	return recorded
+}
+
+// Unwrap returns the wrapped provider.
+func (r *Recorder) Unwrap() provider.MetricsProvider {
+	return r.provider
+}
+
+// Start runs the wrapped provider, if it has a Start method, and closes the
+// output of the recorder once ctx is done.
+func (r *Recorder) Start(ctx context.Context) error {
+	defer func() {
+		if err := r.Close(); err != nil {
+			klog.ErrorS(err, "Unable to close the metrics provider recording")
+		}
+	}()
+	if runnable, ok := r.provider.(interface{ Start(context.Context) error }); ok {
+		return runnable.Start(ctx)
+	}
+	<-ctx.Done()
+	return nil
+}
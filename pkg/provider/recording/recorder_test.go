@@ -0,0 +1,277 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recording
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+var (
+	testTimestamp = metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	podsResource = schema.GroupResource{Resource: "pods"}
+	requestsInfo = provider.CustomMetricInfo{GroupResource: podsResource, Namespaced: true, Metric: "http_requests"}
+	// requestsMetadata describes the http_requests metric
+	requestsMetadata = provider.MetricMetadata{Unit: "requests/s", Kind: provider.MetricKindRate}
+)
+
+// testProvider serves the http_requests metric of the pods of the default
+// namespace, and the queue_length external metric.  Its calls take a second
+// of its clock.
+type testProvider struct {
+	clock   *clocktesting.FakeClock
+	pods    map[string]labels.Set
+	value   int64
+	healthy error
+	listErr error
+}
+
+func newTestProvider(clock *clocktesting.FakeClock) *testProvider {
+	return &testProvider{
+		clock: clock,
+		pods: map[string]labels.Set{
+			"frontend-1": {"app": "frontend"},
+			"backend-1":  {"app": "backend"},
+		},
+		value: 1,
+	}
+}
+
+func (p *testProvider) metricValue(name string, info provider.CustomMetricInfo) custom_metrics.MetricValue {
+	return custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: "default", Name: name},
+		Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+		Timestamp:       testTimestamp,
+		Value:           *resource.NewQuantity(p.value, resource.DecimalSI),
+	}
+}
+
+func (p *testProvider) GetMetricByName(_ context.Context, name types.NamespacedName, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValue, error) {
+	p.clock.Step(time.Second)
+	if _, found := p.pods[name.Name]; !found || info.Metric != requestsInfo.Metric {
+		return nil, provider.NewMetricNotFoundForError(info.GroupResource, info.Metric, name.Name)
+	}
+	value := p.metricValue(name.Name, info)
+	return &value, nil
+}
+
+func (p *testProvider) GetMetricBySelector(_ context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValueList, error) {
+	p.clock.Step(time.Second)
+	if namespace != "default" {
+		return nil, errors.New("backend unavailable")
+	}
+	list := &custom_metrics.MetricValueList{}
+	for name, podLabels := range p.pods {
+		if selector.Matches(podLabels) {
+			list.Items = append(list.Items, p.metricValue(name, info))
+		}
+	}
+	return list, nil
+}
+
+func (p *testProvider) ListAllMetrics() []provider.CustomMetricInfo {
+	return []provider.CustomMetricInfo{requestsInfo}
+}
+
+func (p *testProvider) GetExternalMetric(_ context.Context, _ string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	p.clock.Step(time.Second)
+	list := &external_metrics.ExternalMetricValueList{}
+	metricLabels := map[string]string{"queue": "orders"}
+	if metricSelector.Matches(labels.Set(metricLabels)) {
+		list.Items = append(list.Items, external_metrics.ExternalMetricValue{
+			MetricName:   info.Metric,
+			MetricLabels: metricLabels,
+			Timestamp:    testTimestamp,
+			Value:        *resource.NewQuantity(p.value, resource.DecimalSI),
+		})
+	}
+	return list, nil
+}
+
+func (p *testProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	return []provider.ExternalMetricInfo{{Metric: "queue_length"}}
+}
+
+func (p *testProvider) ListAllExternalMetricsWithError(context.Context) ([]provider.ExternalMetricInfo, error) {
+	if p.listErr != nil {
+		return nil, p.listErr
+	}
+	return p.ListAllExternalMetrics(), nil
+}
+
+func (p *testProvider) CustomMetricMetadata(info provider.CustomMetricInfo) *provider.MetricMetadata {
+	if info != requestsInfo {
+		return nil
+	}
+	metadata := requestsMetadata
+	return &metadata
+}
+
+func (p *testProvider) ExternalMetricMetadata(provider.ExternalMetricInfo) *provider.MetricMetadata {
+	return nil
+}
+
+func (p *testProvider) HealthCheck(context.Context) error {
+	return p.healthy
+}
+
+// record records calls to a test provider, and returns the records.
+func record(t *testing.T, calls func(recorder *Recorder, prov *testProvider)) []Record {
+	t.Helper()
+	clock := clocktesting.NewFakeClock(testTimestamp.Time)
+	prov := newTestProvider(clock)
+	out := &bytes.Buffer{}
+	recorder := NewRecorder(prov, out)
+	recorder.clock = clock
+
+	calls(recorder, prov)
+	records, err := ReadRecords(out)
+	require.NoError(t, err)
+	return records
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	appSelector, err := labels.Parse("app in (frontend)")
+	require.NoError(t, err)
+
+	records := record(t, func(recorder *Recorder, _ *testProvider) {
+		value, err := recorder.GetMetricByName(ctx, types.NamespacedName{Namespace: "default", Name: "backend-1"}, requestsInfo, labels.Everything())
+		require.NoError(t, err)
+		assert.Equal(t, "1", value.Value.String())
+
+		list, err := recorder.GetMetricBySelector(ctx, "default", appSelector, requestsInfo, labels.Everything())
+		require.NoError(t, err)
+		assert.Len(t, list.Items, 1)
+
+		_, err = recorder.GetMetricByName(ctx, types.NamespacedName{Namespace: "default", Name: "missing"}, requestsInfo, labels.Everything())
+		assert.True(t, apierrors.IsNotFound(err))
+		_, err = recorder.GetMetricBySelector(ctx, "other", labels.Everything(), requestsInfo, labels.Everything())
+		assert.EqualError(t, err, "backend unavailable")
+
+		externalList, err := recorder.GetExternalMetric(ctx, "default", labels.SelectorFromSet(labels.Set{"queue": "orders"}), provider.ExternalMetricInfo{Metric: "queue_length"})
+		require.NoError(t, err)
+		assert.Len(t, externalList.Items, 1)
+
+		assert.Equal(t, []provider.CustomMetricInfo{requestsInfo}, recorder.ListAllMetrics())
+		assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_length"}}, recorder.ListAllExternalMetrics())
+	})
+	require.Len(t, records, 7)
+
+	for i, record := range records[:5] {
+		assert.WithinDuration(t, testTimestamp.Add(time.Duration(i)*time.Second), record.Time, 0)
+		assert.Equal(t, time.Second, record.Latency.Duration)
+	}
+	assert.Zero(t, records[5].Latency.Duration)
+
+	assert.Equal(t, Call{
+		Method:     GetMetricByName,
+		Namespace:  "default",
+		Name:       "backend-1",
+		MetricInfo: MetricInfo{Resource: "pods", Namespaced: true, Metric: "http_requests"},
+	}, records[0].Call)
+	require.Len(t, records[0].CustomMetrics, 1)
+	assert.Equal(t, "backend-1", records[0].CustomMetrics[0].DescribedObject.Name)
+	assert.Equal(t, "1", records[0].CustomMetrics[0].Value.String())
+	assert.Nil(t, records[0].Error)
+
+	assert.Equal(t, "app in (frontend)", records[1].Call.Selector)
+	require.Len(t, records[1].CustomMetrics, 1)
+	assert.Equal(t, "frontend-1", records[1].CustomMetrics[0].DescribedObject.Name)
+
+	assert.Equal(t, &Error{
+		Message: "the server could not find the metric http_requests for pods missing",
+		Reason:  metav1.StatusReasonNotFound,
+		Code:    404,
+	}, records[2].Error)
+	assert.Empty(t, records[2].CustomMetrics)
+	assert.Equal(t, &Error{Message: "backend unavailable"}, records[3].Error)
+
+	assert.Equal(t, Call{
+		Method:         GetExternalMetric,
+		Namespace:      "default",
+		MetricInfo:     MetricInfo{Metric: "queue_length"},
+		MetricSelector: "queue=orders",
+	}, records[4].Call)
+	require.Len(t, records[4].ExternalMetrics, 1)
+	assert.Equal(t, map[string]string{"queue": "orders"}, records[4].ExternalMetrics[0].MetricLabels)
+
+	assert.Equal(t, []ListedMetric{{MetricInfo: MetricInfo{Resource: "pods", Namespaced: true, Metric: "http_requests"}, Metadata: &requestsMetadata}}, records[5].Metrics)
+	assert.Equal(t, []ListedMetric{{MetricInfo: MetricInfo{Metric: "queue_length"}}}, records[6].Metrics)
+}
+
+func TestRecorderOptionalInterfaces(t *testing.T) {
+	ctx := context.Background()
+	prov := newTestProvider(clocktesting.NewFakeClock(testTimestamp.Time))
+	recorder := NewRecorder(prov, &bytes.Buffer{})
+
+	checker, ok := provider.As[provider.HealthCheckingProvider](recorder)
+	require.True(t, ok, "the health check of the wrapped provider should be found")
+	prov.healthy = errors.New("unhealthy")
+	assert.EqualError(t, checker.HealthCheck(ctx), "unhealthy")
+	_, ok = any(recorder).(provider.ReloadableProvider)
+	assert.False(t, ok, "the recorder shouldn't be reloadable itself")
+	_, ok = provider.As[provider.ReloadableProvider](recorder)
+	assert.False(t, ok, "the wrapped provider isn't reloadable")
+	_, ok = provider.As[provider.CacheStatsProvider](recorder)
+	assert.False(t, ok, "the wrapped provider has no caches")
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.NoError(t, recorder.Start(ctx))
+}
+
+func TestFileRecorder(t *testing.T) {
+	path := t.TempDir() + "/recording.json"
+	recorder, err := NewFileRecorder(newTestProvider(clocktesting.NewFakeClock(testTimestamp.Time)), FileOptions{Path: path, MaxSizeMB: 1})
+	require.NoError(t, err)
+	recorder.ListAllMetrics()
+	recorder.ListAllExternalMetrics()
+	require.NoError(t, recorder.Close())
+
+	records, err := ReadFiles(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, ListAllMetrics, records[0].Call.Method)
+	assert.Equal(t, ListAllExternalMetrics, records[1].Call.Method)
+
+	_, err = NewFileRecorder(newTestProvider(nil), FileOptions{})
+	assert.Error(t, err)
+}
+
+func testPod(name string) types.NamespacedName {
+	return types.NamespacedName{Namespace: "default", Name: name}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recording
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	emv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// Replayer is a metrics provider serving recorded calls, to reproduce the
+// behaviour of an adapter offline, for instance with the test server of
+// pkg/apiserver/testing.
+//
+// Calls are matched to the records of the same method and inputs, and to
+// the time elapsed since the start of the replay: a call made d after the
+// replay started gets the answer of the last record made at most d after the
+// first record.  Calls made before their first record get its answer, and
+// calls which were never recorded fail with a NotFound error.  The metrics
+// listings are derived from the recorded metrics when no listing was
+// recorded.
+type Replayer struct {
+	// Latency makes replayed calls take as long as the recorded calls.
+	Latency bool
+
+	clock clock.Clock
+	// origin is the time of the first record.
+	origin time.Time
+	calls  map[Call][]Record
+
+	lock  sync.RWMutex
+	start time.Time
+}
+
+var (
+	_ provider.MetricsProvider                = &Replayer{}
+	_ provider.CustomMetricsListerWithError   = &Replayer{}
+	_ provider.ExternalMetricsListerWithError = &Replayer{}
+	_ provider.MetricMetadataProvider         = &Replayer{}
+)
+
+// NewReplayer returns a provider replaying records, starting now.
+func NewReplayer(records []Record) *Replayer {
+	return newReplayer(records, clock.RealClock{})
+}
+
+func newReplayer(records []Record, clock clock.Clock) *Replayer {
+	r := &Replayer{
+		clock: clock,
+		calls: map[Call][]Record{},
+		start: clock.Now(),
+	}
+	for _, record := range records {
+		if r.origin.IsZero() || record.Time.Before(r.origin) {
+			r.origin = record.Time
+		}
+		r.calls[record.Call] = append(r.calls[record.Call], record)
+	}
+	for _, callRecords := range r.calls {
+		sort.SliceStable(callRecords, func(i, j int) bool {
+			return callRecords[i].Time.Before(callRecords[j].Time)
+		})
+	}
+	return r
+}
+
+// Restart restarts the replay from the first record.
+func (r *Replayer) Restart() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.start = r.clock.Now()
+}
+
+// lookup returns the record answering call at the current offset of the
+// replay.
+func (r *Replayer) lookup(call Call) (Record, bool) {
+	records := r.calls[call]
+	if len(records) == 0 {
+		return Record{}, false
+	}
+
+	r.lock.RLock()
+	recordTime := r.origin.Add(r.clock.Since(r.start))
+	r.lock.RUnlock()
+
+	// index of the first record made after recordTime
+	i := sort.Search(len(records), func(i int) bool {
+		return records[i].Time.After(recordTime)
+	})
+	return records[max(i-1, 0)], true
+}
+
+// replay returns the record answering call, after its recorded latency if
+// requested.
+func (r *Replayer) replay(ctx context.Context, call Call) (Record, error) {
+	record, found := r.lookup(call)
+	if !found {
+		return Record{}, &apierrors.StatusError{ErrStatus: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    int32(http.StatusNotFound),
+			Reason:  metav1.StatusReasonNotFound,
+			Message: fmt.Sprintf("no recorded %s call for the metric %s", call.Method, call.Metric),
+		}}
+	}
+	if r.Latency && record.Latency.Duration > 0 {
+		select {
+		case <-ctx.Done():
+			return Record{}, ctx.Err()
+		case <-r.clock.After(record.Latency.Duration):
+		}
+	}
+	return record, record.Error.Err()
+}
+
+func (r *Replayer) GetMetricByName(ctx context.Context, name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	record, err := r.replay(ctx, Call{
+		Method:         GetMetricByName,
+		Namespace:      name.Namespace,
+		Name:           name.Name,
+		MetricInfo:     customMetricInfo(info),
+		MetricSelector: selectorString(metricSelector),
+	})
+	if err != nil || len(record.CustomMetrics) == 0 {
+		return nil, err
+	}
+	return &replayedCustomMetrics(record.CustomMetrics)[0], nil
+}
+
+func (r *Replayer) GetMetricBySelector(ctx context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	record, err := r.replay(ctx, Call{
+		Method:         GetMetricBySelector,
+		Namespace:      namespace,
+		MetricInfo:     customMetricInfo(info),
+		Selector:       selectorString(selector),
+		MetricSelector: selectorString(metricSelector),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &custom_metrics.MetricValueList{Items: replayedCustomMetrics(record.CustomMetrics)}, nil
+}
+
+func (r *Replayer) GetExternalMetric(ctx context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	record, err := r.replay(ctx, Call{
+		Method:         GetExternalMetric,
+		Namespace:      namespace,
+		MetricInfo:     MetricInfo{Metric: info.Metric},
+		MetricSelector: selectorString(metricSelector),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &external_metrics.ExternalMetricValueList{Items: replayedExternalMetrics(record.ExternalMetrics)}, nil
+}
+
+func (r *Replayer) ListAllMetrics() []provider.CustomMetricInfo {
+	infos, _ := r.ListAllMetricsWithError(context.Background())
+	return infos
+}
+
+// ListAllMetricsWithError replays the recorded listing of the custom
+// metrics, including its error.
+func (r *Replayer) ListAllMetricsWithError(_ context.Context) ([]provider.CustomMetricInfo, error) {
+	listing, err := r.listing(ListAllMetrics, GetMetricByName, GetMetricBySelector)
+	if err != nil {
+		return nil, err
+	}
+	var infos []provider.CustomMetricInfo
+	for _, info := range listing {
+		infos = append(infos, provider.CustomMetricInfo{
+			GroupResource: schema.ParseGroupResource(info.Resource),
+			Namespaced:    info.Namespaced,
+			Metric:        info.Metric,
+		})
+	}
+	return infos, nil
+}
+
+func (r *Replayer) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	infos, _ := r.ListAllExternalMetricsWithError(context.Background())
+	return infos
+}
+
+// ListAllExternalMetricsWithError replays the recorded listing of the
+// external metrics, including its error.
+func (r *Replayer) ListAllExternalMetricsWithError(_ context.Context) ([]provider.ExternalMetricInfo, error) {
+	listing, err := r.listing(ListAllExternalMetrics, GetExternalMetric)
+	if err != nil {
+		return nil, err
+	}
+	var infos []provider.ExternalMetricInfo
+	for _, info := range listing {
+		infos = append(infos, provider.ExternalMetricInfo{Metric: info.Metric})
+	}
+	return infos, nil
+}
+
+// CustomMetricMetadata replays the metadata recorded with the listing of the
+// custom metrics.
+func (r *Replayer) CustomMetricMetadata(info provider.CustomMetricInfo) *provider.MetricMetadata {
+	return r.metadata(ListAllMetrics, customMetricInfo(info))
+}
+
+// ExternalMetricMetadata replays the metadata recorded with the listing of
+// the external metrics.
+func (r *Replayer) ExternalMetricMetadata(info provider.ExternalMetricInfo) *provider.MetricMetadata {
+	return r.metadata(ListAllExternalMetrics, MetricInfo{Metric: info.Metric})
+}
+
+// metadata returns the metadata of a metric in the recorded listing, if any.
+func (r *Replayer) metadata(method Method, info MetricInfo) *provider.MetricMetadata {
+	record, found := r.lookup(Call{Method: method})
+	if !found {
+		return nil
+	}
+	for _, listed := range record.Metrics {
+		if listed.MetricInfo == info {
+			return listed.Metadata
+		}
+	}
+	return nil
+}
+
+// listing returns the metrics of the recorded listing, and its error, or
+// else the sorted metrics of the recorded calls to the getters.
+func (r *Replayer) listing(method Method, getters ...Method) ([]ListedMetric, error) {
+	if record, found := r.lookup(Call{Method: method}); found {
+		return record.Metrics, record.Error.Err()
+	}
+
+	var infos []ListedMetric
+	for call := range r.calls {
+		if slices.Contains(getters, call.Method) && !slices.ContainsFunc(infos, func(listed ListedMetric) bool { return listed.MetricInfo == call.MetricInfo }) {
+			infos = append(infos, ListedMetric{MetricInfo: call.MetricInfo})
+		}
+	}
+	slices.SortFunc(infos, func(a, b ListedMetric) int {
+		return cmp.Or(cmp.Compare(a.Resource, b.Resource), cmp.Compare(a.Metric, b.Metric))
+	})
+	return infos, nil
+}
+
+// replayedCustomMetrics converts recorded values back to the values returned
+// by providers.
+func replayedCustomMetrics(recorded []cmv1beta2.MetricValue) []custom_metrics.MetricValue {
+	values := make([]custom_metrics.MetricValue, len(recorded))
+	for i := range recorded {
+		_ = cmv1beta2.Convert_v1beta2_MetricValue_To_custom_metrics_MetricValue(&recorded[i], &values[i], nil)
+	}
+	return values
+}
+
+// replayedExternalMetrics converts recorded values back to the values
+// returned by providers.
+func replayedExternalMetrics(recorded []emv1beta1.ExternalMetricValue) []external_metrics.ExternalMetricValue {
+	values := make([]external_metrics.ExternalMetricValue, len(recorded))
+	for i := range recorded {
+		_ = emv1beta1.Convert_v1beta1_ExternalMetricValue_To_external_metrics_ExternalMetricValue(&recorded[i], &values[i], nil)
+	}
+	return values
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recording
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	apiservertesting "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/testing"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/query"
+)
+
+// incident records the metrics of the default namespace growing from 1 to 5
+// after a minute, and a failing backend in the other namespace.
+func incident(t *testing.T) []Record {
+	t.Helper()
+	ctx := context.Background()
+	queueSelector := labels.SelectorFromSet(labels.Set{"queue": "orders"})
+	return record(t, func(recorder *Recorder, prov *testProvider) {
+		for _, value := range []int64{1, 5} {
+			prov.value = value
+			_, _ = recorder.GetMetricBySelector(ctx, "default", labels.Everything(), requestsInfo, labels.Everything())
+			_, _ = recorder.GetExternalMetric(ctx, "default", queueSelector, provider.ExternalMetricInfo{Metric: "queue_length"})
+			prov.clock.Step(time.Minute)
+		}
+		_, _ = recorder.GetMetricBySelector(ctx, "other", labels.Everything(), requestsInfo, labels.Everything())
+	})
+}
+
+func TestReplayThroughServer(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	replayer := newReplayer(incident(t), clock)
+	server := apiservertesting.StartTestServer(t, replayer, replayer)
+	client, err := query.NewForConfig(server.ClientConfig)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	values := func() map[string]string {
+		list, err := client.CustomMetrics(ctx, query.CustomMetricsRequest{Namespace: "default", Resource: podsResource, Metric: "http_requests"})
+		require.NoError(t, err)
+		actual := map[string]string{}
+		for _, value := range list.Items {
+			actual[value.DescribedObject.Name] = value.Value.String()
+		}
+		return actual
+	}
+	externalValue := func() string {
+		list, err := client.ExternalMetrics(ctx, query.ExternalMetricsRequest{Namespace: "default", Metric: "queue_length", MetricSelector: "queue=orders"})
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+		return list.Items[0].Value.String()
+	}
+
+	assert.Equal(t, map[string]string{"frontend-1": "1", "backend-1": "1"}, values())
+	assert.Equal(t, "1", externalValue())
+
+	// the second values were recorded at most a minute and 3 seconds after the first
+	// ones
+	clock.Step(time.Minute)
+	assert.Equal(t, map[string]string{"frontend-1": "1", "backend-1": "1"}, values())
+	clock.Step(3 * time.Second)
+	assert.Equal(t, map[string]string{"frontend-1": "5", "backend-1": "5"}, values())
+	assert.Equal(t, "5", externalValue())
+
+	// the last answers keep being served after the end of the recording
+	clock.Step(time.Hour)
+	assert.Equal(t, "5", externalValue())
+
+	replayer.Restart()
+	assert.Equal(t, "1", externalValue())
+
+	_, err = client.CustomMetrics(ctx, query.CustomMetricsRequest{Namespace: "other", Resource: podsResource, Metric: "http_requests"})
+	assert.ErrorContains(t, err, "backend unavailable")
+	_, err = client.CustomMetrics(ctx, query.CustomMetricsRequest{Namespace: "default", Resource: podsResource, Metric: "http_errors"})
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+	_, err = client.ExternalMetrics(ctx, query.ExternalMetricsRequest{Namespace: "default", Metric: "queue_length"})
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+}
+
+func TestReplayErrors(t *testing.T) {
+	ctx := context.Background()
+	records := record(t, func(recorder *Recorder, _ *testProvider) {
+		_, _ = recorder.GetMetricByName(ctx, testPod("missing"), requestsInfo, labels.Everything())
+		_, _ = recorder.GetMetricBySelector(ctx, "other", labels.Everything(), requestsInfo, labels.Everything())
+	})
+	replayer := newReplayer(records, clocktesting.NewFakeClock(time.Now()))
+
+	_, err := replayer.GetMetricByName(ctx, testPod("missing"), requestsInfo, labels.Everything())
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+	assert.EqualError(t, err, "the server could not find the metric http_requests for pods missing")
+
+	_, err = replayer.GetMetricBySelector(ctx, "other", labels.Everything(), requestsInfo, labels.Everything())
+	assert.EqualError(t, err, "backend unavailable")
+	assert.False(t, apierrors.IsNotFound(err))
+}
+
+func TestReplayLatency(t *testing.T) {
+	ctx := context.Background()
+	records := record(t, func(recorder *Recorder, _ *testProvider) {
+		_, _ = recorder.GetMetricByName(ctx, testPod("backend-1"), requestsInfo, labels.Everything())
+	})
+	clock := clocktesting.NewFakeClock(time.Now())
+	replayer := newReplayer(records, clock)
+	replayer.Latency = true
+
+	done := make(chan error)
+	go func() {
+		_, err := replayer.GetMetricByName(ctx, testPod("backend-1"), requestsInfo, labels.Everything())
+		done <- err
+	}()
+	require.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("the call returned before its recorded latency")
+	default:
+	}
+	clock.Step(time.Second)
+	assert.NoError(t, <-done)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := replayer.GetMetricByName(ctx, testPod("backend-1"), requestsInfo, labels.Everything())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReplayListings(t *testing.T) {
+	replayer := newReplayer(incident(t), clocktesting.NewFakeClock(time.Now()))
+	assert.Equal(t, []provider.CustomMetricInfo{requestsInfo}, replayer.ListAllMetrics())
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_length"}}, replayer.ListAllExternalMetrics())
+
+	records := record(t, func(recorder *Recorder, _ *testProvider) {
+		recorder.ListAllMetrics()
+		recorder.ListAllExternalMetrics()
+	})
+	records[0].Metrics = append(records[0].Metrics, ListedMetric{MetricInfo: MetricInfo{Resource: "deployments.apps", Namespaced: true, Metric: "replicas"}})
+	replayer = newReplayer(records, clocktesting.NewFakeClock(time.Now()))
+	assert.Equal(t, []provider.CustomMetricInfo{
+		requestsInfo,
+		{GroupResource: schema.GroupResource{Group: "apps", Resource: "deployments"}, Namespaced: true, Metric: "replicas"},
+	}, replayer.ListAllMetrics())
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_length"}}, replayer.ListAllExternalMetrics())
+	assert.Equal(t, &requestsMetadata, replayer.CustomMetricMetadata(requestsInfo), "the recorded metadata should be replayed")
+	assert.Nil(t, replayer.ExternalMetricMetadata(provider.ExternalMetricInfo{Metric: "queue_length"}))
+}
+
+func TestReplayListingErrors(t *testing.T) {
+	ctx := context.Background()
+	records := record(t, func(recorder *Recorder, prov *testProvider) {
+		prov.listErr = errors.New("backend unavailable")
+		_, err := recorder.ListAllExternalMetricsWithError(ctx)
+		assert.EqualError(t, err, "backend unavailable")
+		infos, err := recorder.ListAllMetricsWithError(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []provider.CustomMetricInfo{requestsInfo}, infos)
+	})
+	require.Len(t, records, 2)
+	assert.Equal(t, &Error{Message: "backend unavailable"}, records[0].Error)
+
+	replayer := newReplayer(records, clocktesting.NewFakeClock(time.Now()))
+	_, err := replayer.ListAllExternalMetricsWithError(ctx)
+	assert.EqualError(t, err, "backend unavailable")
+	assert.Empty(t, replayer.ListAllExternalMetrics())
+	infos, err := replayer.ListAllMetricsWithError(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []provider.CustomMetricInfo{requestsInfo}, infos)
+}
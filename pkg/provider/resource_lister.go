@@ -22,22 +22,35 @@ import (
 )
 
 type customMetricsResourceLister struct {
-	provider CustomMetricsProvider
+	cache    *ListingCache[CustomMetricInfo]
+	metadata MetricMetadataProvider
 }
 
 type externalMetricsResourceLister struct {
-	provider ExternalMetricsProvider
+	cache    *ListingCache[ExternalMetricInfo]
+	metadata MetricMetadataProvider
 }
 
 // NewCustomMetricResourceLister creates APIResourceLister for provided CustomMetricsProvider.
+// The metrics are listed on each call, and the ones last listed successfully are
+// served when listing them fails.
 func NewCustomMetricResourceLister(provider CustomMetricsProvider) discovery.APIResourceLister {
+	metadata, _ := As[MetricMetadataProvider](provider)
+	return NewCachedCustomMetricResourceLister(NewCustomMetricsListingCache(provider, 0), metadata)
+}
+
+// NewCachedCustomMetricResourceLister creates APIResourceLister listing the
+// custom metrics cached by the given cache, described by metadata when it
+// isn't nil.
+func NewCachedCustomMetricResourceLister(cache *ListingCache[CustomMetricInfo], metadata MetricMetadataProvider) discovery.APIResourceLister {
 	return &customMetricsResourceLister{
-		provider: provider,
+		cache:    cache,
+		metadata: metadata,
 	}
 }
 
 func (l *customMetricsResourceLister) ListAPIResources() []metav1.APIResource {
-	metrics := l.provider.ListAllMetrics()
+	metrics := l.cache.List()
 	resources := make([]metav1.APIResource, len(metrics))
 
 	for i, metric := range metrics {
@@ -47,21 +60,35 @@ func (l *customMetricsResourceLister) ListAPIResources() []metav1.APIResource {
 			Kind:       "MetricValueList",
 			Verbs:      metav1.Verbs{"get"}, // TODO: support "watch"
 		}
+		if l.metadata != nil {
+			resources[i].Categories = l.metadata.CustomMetricMetadata(metric).Categories()
+		}
 	}
 
 	return resources
 }
 
 // NewExternalMetricResourceLister creates APIResourceLister for provided CustomMetricsProvider.
+// The metrics are listed on each call, and the ones last listed successfully are
+// served when listing them fails.
 func NewExternalMetricResourceLister(provider ExternalMetricsProvider) discovery.APIResourceLister {
+	metadata, _ := As[MetricMetadataProvider](provider)
+	return NewCachedExternalMetricResourceLister(NewExternalMetricsListingCache(provider, 0), metadata)
+}
+
+// NewCachedExternalMetricResourceLister creates APIResourceLister listing
+// the external metrics cached by the given cache, described by metadata when
+// it isn't nil.
+func NewCachedExternalMetricResourceLister(cache *ListingCache[ExternalMetricInfo], metadata MetricMetadataProvider) discovery.APIResourceLister {
 	return &externalMetricsResourceLister{
-		provider: provider,
+		cache:    cache,
+		metadata: metadata,
 	}
 }
 
 // ListAPIResources lists all supported custom metrics.
 func (l *externalMetricsResourceLister) ListAPIResources() []metav1.APIResource {
-	metrics := l.provider.ListAllExternalMetrics()
+	metrics := l.cache.List()
 	resources := make([]metav1.APIResource, len(metrics))
 
 	for i, metric := range metrics {
@@ -71,6 +98,9 @@ func (l *externalMetricsResourceLister) ListAPIResources() []metav1.APIResource
 			Kind:       "ExternalMetricValueList",
 			Verbs:      metav1.Verbs{"get"},
 		}
+		if l.metadata != nil {
+			resources[i].Categories = l.metadata.ExternalMetricMetadata(metric).Categories()
+		}
 	}
 
 	return resources
@@ -0,0 +1,76 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectors
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var promLabelNameRE = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+// RenderPromQL renders the matchers as a comma-separated list of PromQL label
+// matchers, suitable for placing between the braces of a vector selector, e.g.
+// `app="web",tier=~"backend|frontend"`.
+//
+// Label names must be valid Prometheus label names, so Kubernetes label keys
+// containing prefixes or dashes should be renamed with Matchers.Map first.
+// Since Prometheus does not distinguish between absent and empty labels,
+// "exists" renders as a non-empty match, and "!" as an empty match.
+// Integer comparisons cannot be expressed as label matchers, and result in an error.
+func RenderPromQL(m Matchers) (string, error) {
+	parts := make([]string, 0, len(m))
+	for _, matcher := range m {
+		if !promLabelNameRE.MatchString(matcher.Label) {
+			return "", fmt.Errorf("%q is not a valid Prometheus label name", matcher.Label)
+		}
+
+		var op, value string
+		switch matcher.Type {
+		case MatchEqual:
+			op, value = "=", matcher.Values[0]
+		case MatchNotEqual:
+			op, value = "!=", matcher.Values[0]
+		case MatchIn:
+			op, value = "=~", promRegexAlternation(matcher.Values)
+		case MatchNotIn:
+			op, value = "!~", promRegexAlternation(matcher.Values)
+		case MatchExists:
+			op, value = "!=", ""
+		case MatchDoesNotExist:
+			op, value = "=", ""
+		default:
+			return "", fmt.Errorf("operator %q on label %q cannot be expressed in PromQL", matcher.Type, matcher.Label)
+		}
+
+		parts = append(parts, matcher.Label+op+strconv.Quote(value))
+	}
+
+	return strings.Join(parts, ","), nil
+}
+
+// promRegexAlternation builds a (fully-anchored, in Prometheus) regular
+// expression matching exactly the given values.
+func promRegexAlternation(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = regexp.QuoteMeta(value)
+	}
+	return strings.Join(quoted, "|")
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package selectors translates Kubernetes label selectors, such as the object
+// selector and the metric selector passed to providers, into backend query
+// languages.
+//
+// A selector is first converted into Matchers, a backend-neutral list of label
+// matchers which must all match, and then rendered with one of the renderers
+// in this package (PromQL label matchers, SQL WHERE clauses, or a Go predicate).
+package selectors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// ErrUnselectable is returned when converting a selector which cannot match
+// anything (such as labels.Nothing()).
+var ErrUnselectable = errors.New("selector does not match anything")
+
+// MatchType is the type of comparison performed by a Matcher.
+type MatchType int
+
+const (
+	// MatchEqual matches labels present and equal to the single value.
+	MatchEqual MatchType = iota
+	// MatchNotEqual matches labels absent or not equal to the single value.
+	MatchNotEqual
+	// MatchIn matches labels present and equal to one of the values.
+	MatchIn
+	// MatchNotIn matches labels absent or not equal to any of the values.
+	MatchNotIn
+	// MatchExists matches labels which are present.
+	MatchExists
+	// MatchDoesNotExist matches labels which are absent.
+	MatchDoesNotExist
+	// MatchGreaterThan matches labels whose integer value is greater than the single value.
+	MatchGreaterThan
+	// MatchLessThan matches labels whose integer value is less than the single value.
+	MatchLessThan
+)
+
+func (t MatchType) String() string {
+	switch t {
+	case MatchEqual:
+		return "="
+	case MatchNotEqual:
+		return "!="
+	case MatchIn:
+		return "in"
+	case MatchNotIn:
+		return "notin"
+	case MatchExists:
+		return "exists"
+	case MatchDoesNotExist:
+		return "!"
+	case MatchGreaterThan:
+		return "gt"
+	case MatchLessThan:
+		return "lt"
+	default:
+		return fmt.Sprintf("MatchType(%d)", int(t))
+	}
+}
+
+// Matcher is a single requirement on a label.
+type Matcher struct {
+	Label string
+	Type  MatchType
+	// Values holds the sorted values to compare against.  It is empty
+	// for MatchExists and MatchDoesNotExist, and holds exactly one value for
+	// MatchEqual, MatchNotEqual, MatchGreaterThan and MatchLessThan.
+	Values []string
+}
+
+// Matchers is a list of label matchers, all of which must match.
+// An empty list matches everything.
+type Matchers []Matcher
+
+// FromSelector converts a label selector into Matchers.  It returns
+// ErrUnselectable if the selector can never match.
+func FromSelector(selector labels.Selector) (Matchers, error) {
+	if selector == nil {
+		return nil, nil
+	}
+
+	reqs, selectable := selector.Requirements()
+	if !selectable {
+		return nil, ErrUnselectable
+	}
+
+	matchers := make(Matchers, 0, len(reqs))
+	for i := range reqs {
+		matcher, err := fromRequirement(&reqs[i])
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+func fromRequirement(req *labels.Requirement) (Matcher, error) {
+	values := req.ValuesUnsorted()
+	sort.Strings(values)
+	matcher := Matcher{
+		Label:  req.Key(),
+		Values: values,
+	}
+
+	switch req.Operator() {
+	case selection.Equals, selection.DoubleEquals:
+		matcher.Type = MatchEqual
+	case selection.NotEquals:
+		matcher.Type = MatchNotEqual
+	case selection.In:
+		matcher.Type = MatchIn
+	case selection.NotIn:
+		matcher.Type = MatchNotIn
+	case selection.Exists:
+		matcher.Type = MatchExists
+	case selection.DoesNotExist:
+		matcher.Type = MatchDoesNotExist
+	case selection.GreaterThan:
+		matcher.Type = MatchGreaterThan
+	case selection.LessThan:
+		matcher.Type = MatchLessThan
+	default:
+		return Matcher{}, fmt.Errorf("unsupported operator %q for label %q", req.Operator(), req.Key())
+	}
+
+	return matcher, nil
+}
+
+// Map returns a copy of the matchers with each label renamed by the given
+// function, for backends whose label names differ from the Kubernetes ones.
+func (m Matchers) Map(fn func(label string) string) Matchers {
+	res := make(Matchers, len(m))
+	for i, matcher := range m {
+		res[i] = matcher
+		res[i].Label = fn(matcher.Label)
+	}
+	return res
+}
+
+// Matches returns true if the given set of labels satisfies every matcher.
+func (m Matchers) Matches(lbls map[string]string) bool {
+	for _, matcher := range m {
+		if !matcher.Matches(lbls) {
+			return false
+		}
+	}
+	return true
+}
+
+// Predicate returns a plain Go predicate equivalent to the matchers.
+func (m Matchers) Predicate() func(map[string]string) bool {
+	return m.Matches
+}
+
+// Matches returns true if the given set of labels satisfies the matcher.
+func (m Matcher) Matches(lbls map[string]string) bool {
+	value, present := lbls[m.Label]
+
+	switch m.Type {
+	case MatchEqual, MatchIn:
+		return present && m.hasValue(value)
+	case MatchNotEqual, MatchNotIn:
+		return !present || !m.hasValue(value)
+	case MatchExists:
+		return present
+	case MatchDoesNotExist:
+		return !present
+	case MatchGreaterThan, MatchLessThan:
+		if !present || len(m.Values) != 1 {
+			return false
+		}
+		actual, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		expected, err := strconv.ParseInt(m.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if m.Type == MatchGreaterThan {
+			return actual > expected
+		}
+		return actual < expected
+	default:
+		return false
+	}
+}
+
+func (m Matcher) hasValue(value string) bool {
+	for _, v := range m.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
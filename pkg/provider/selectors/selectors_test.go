@@ -0,0 +1,130 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func mustMatchers(t *testing.T, selector string) Matchers {
+	t.Helper()
+	sel, err := labels.Parse(selector)
+	require.NoError(t, err)
+	matchers, err := FromSelector(sel)
+	require.NoError(t, err)
+	return matchers
+}
+
+func TestFromSelector(t *testing.T) {
+	matchers := mustMatchers(t, "a=b,c!=d,e in (y,x),f notin (z),g,!h,i>5")
+	assert.Equal(t, Matchers{
+		{Label: "a", Type: MatchEqual, Values: []string{"b"}},
+		{Label: "c", Type: MatchNotEqual, Values: []string{"d"}},
+		{Label: "e", Type: MatchIn, Values: []string{"x", "y"}},
+		{Label: "f", Type: MatchNotIn, Values: []string{"z"}},
+		{Label: "g", Type: MatchExists, Values: []string{}},
+		{Label: "h", Type: MatchDoesNotExist, Values: []string{}},
+		{Label: "i", Type: MatchGreaterThan, Values: []string{"5"}},
+	}, matchers)
+
+	everything, err := FromSelector(labels.Everything())
+	require.NoError(t, err)
+	assert.Empty(t, everything)
+
+	_, err = FromSelector(labels.Nothing())
+	assert.ErrorIs(t, err, ErrUnselectable)
+}
+
+func TestPredicateMatchesSelector(t *testing.T) {
+	sets := []labels.Set{
+		{},
+		{"a": "b"},
+		{"a": "b", "e": "x"},
+		{"a": "b", "e": "x", "g": ""},
+		{"a": "b", "e": "y", "f": "z", "g": "1"},
+		{"a": "b", "c": "d", "e": "x", "g": "1"},
+		{"a": "b", "e": "x", "g": "1", "h": "1"},
+		{"a": "b", "e": "x", "g": "1", "i": "4"},
+		{"a": "b", "e": "x", "g": "1", "i": "6"},
+	}
+
+	for _, selector := range []string{
+		"",
+		"a=b",
+		"a=b,c!=d,e in (x,y),f notin (z),g,!h",
+		"a=b,e in (x),g,i>5",
+		"i<5",
+	} {
+		sel, err := labels.Parse(selector)
+		require.NoError(t, err)
+		predicate := mustMatchers(t, selector).Predicate()
+		for _, set := range sets {
+			assert.Equal(t, sel.Matches(set), predicate(set), "selector %q on labels %v", selector, set)
+		}
+	}
+}
+
+func TestRenderPromQL(t *testing.T) {
+	rendered, err := RenderPromQL(mustMatchers(t, "a=b,c!=d,e in (x.y,z),f notin (z),g,!h"))
+	require.NoError(t, err)
+	assert.Equal(t, `a="b",c!="d",e=~"x\\.y|z",f!~"z",g!="",h=""`, rendered)
+
+	rendered, err = RenderPromQL(Matchers{{Label: "a", Type: MatchEqual, Values: []string{"quote\"back\\slash"}}})
+	require.NoError(t, err)
+	assert.Equal(t, `a="quote\"back\\slash"`, rendered)
+
+	_, err = RenderPromQL(mustMatchers(t, "app.kubernetes.io/name=web"))
+	assert.Error(t, err, "invalid label names should be rejected")
+
+	rendered, err = RenderPromQL(mustMatchers(t, "app.kubernetes.io/name=web").Map(func(label string) string {
+		return "app_kubernetes_io_name"
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, `app_kubernetes_io_name="web"`, rendered)
+
+	_, err = RenderPromQL(mustMatchers(t, "a>1"))
+	assert.Error(t, err, "integer comparisons should be rejected")
+}
+
+func TestRenderSQL(t *testing.T) {
+	clause, args, err := RenderSQL(mustMatchers(t, "a=b,c!=d,e in (x,y),f notin (z),g,!h,i>5"), SQLOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `"a" = ? AND ("c" IS NULL OR "c" <> ?) AND "e" IN (?, ?) AND ("f" IS NULL OR "f" NOT IN (?)) AND "g" IS NOT NULL AND "h" IS NULL AND "i" > ?`, clause)
+	assert.Equal(t, []interface{}{"b", "d", "x", "y", "z", int64(5)}, args)
+
+	clause, args, err = RenderSQL(mustMatchers(t, "a=b,c in (d)"), SQLOptions{
+		Column: func(label string) string {
+			return "labels->>'" + label + "'"
+		},
+		Placeholder: DollarPlaceholder,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `labels->>'a' = $1 AND labels->>'c' IN ($2)`, clause)
+	assert.Equal(t, []interface{}{"b", "d"}, args)
+
+	clause, args, err = RenderSQL(nil, SQLOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "1 = 1", clause)
+	assert.Empty(t, args)
+
+	assert.Equal(t, `"we""ird"`, QuoteIdentifier(`we"ird`))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SQLOptions customizes how matchers are rendered into SQL.
+type SQLOptions struct {
+	// Column returns the SQL expression holding the value of the given label,
+	// with NULL representing an absent label.  It defaults to QuoteIdentifier,
+	// which treats each label as a column of the same name.
+	Column func(label string) string
+
+	// Placeholder returns the bind parameter placeholder for the n-th argument,
+	// counting from 1.  It defaults to "?"; use DollarPlaceholder for PostgreSQL.
+	Placeholder func(n int) string
+}
+
+// QuoteIdentifier quotes the given name as a standard SQL delimited identifier.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// DollarPlaceholder returns PostgreSQL-style numbered placeholders ($1, $2, ...).
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// RenderSQL renders the matchers as a SQL boolean expression for use in a
+// WHERE clause.  Values are never inlined: they are returned as arguments
+// to bind to the placeholders in the expression, in order.  Integer
+// comparisons bind int64 arguments, and every other comparison binds strings.
+func RenderSQL(m Matchers, opts SQLOptions) (string, []interface{}, error) {
+	column := opts.Column
+	if column == nil {
+		column = QuoteIdentifier
+	}
+	placeholder := opts.Placeholder
+	if placeholder == nil {
+		placeholder = func(int) string { return "?" }
+	}
+
+	if len(m) == 0 {
+		return "1 = 1", nil, nil
+	}
+
+	var args []interface{}
+	bind := func(value interface{}) string {
+		args = append(args, value)
+		return placeholder(len(args))
+	}
+	bindAll := func(values []string) string {
+		placeholders := make([]string, len(values))
+		for i, value := range values {
+			placeholders[i] = bind(value)
+		}
+		return strings.Join(placeholders, ", ")
+	}
+
+	clauses := make([]string, 0, len(m))
+	for _, matcher := range m {
+		col := column(matcher.Label)
+
+		var clause string
+		switch matcher.Type {
+		case MatchEqual:
+			clause = fmt.Sprintf("%s = %s", col, bind(matcher.Values[0]))
+		case MatchNotEqual:
+			clause = fmt.Sprintf("(%s IS NULL OR %s <> %s)", col, col, bind(matcher.Values[0]))
+		case MatchIn:
+			clause = fmt.Sprintf("%s IN (%s)", col, bindAll(matcher.Values))
+		case MatchNotIn:
+			clause = fmt.Sprintf("(%s IS NULL OR %s NOT IN (%s))", col, col, bindAll(matcher.Values))
+		case MatchExists:
+			clause = fmt.Sprintf("%s IS NOT NULL", col)
+		case MatchDoesNotExist:
+			clause = fmt.Sprintf("%s IS NULL", col)
+		case MatchGreaterThan, MatchLessThan:
+			value, err := strconv.ParseInt(matcher.Values[0], 10, 64)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid integer value %q for label %q: %v", matcher.Values[0], matcher.Label, err)
+			}
+			op := ">"
+			if matcher.Type == MatchLessThan {
+				op = "<"
+			}
+			clause = fmt.Sprintf("%s %s %s", col, op, bind(value))
+		default:
+			return "", nil, fmt.Errorf("operator %q on label %q cannot be expressed in SQL", matcher.Type, matcher.Label)
+		}
+		clauses = append(clauses, clause)
+	}
+
+	return strings.Join(clauses, " AND "), args, nil
+}
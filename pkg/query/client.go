@@ -0,0 +1,245 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package query fetches metric values and listings from the custom and
+// external metrics APIs, as served by adapters built with this library.
+package query
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	emv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// AllObjects is the name requesting the metric for all the objects
+// matching a label selector.
+const AllObjects = "*"
+
+var (
+	customMetricsPath   = "/apis/" + cmv1beta2.SchemeGroupVersion.String()
+	externalMetricsPath = "/apis/" + emv1beta1.SchemeGroupVersion.String()
+
+	// parameterCodec encodes the options the way the installer decodes them
+	parameterCodec = runtime.NewParameterCodec(apiserver.Scheme)
+)
+
+// CustomMetricsRequest identifies the values of a custom metric to fetch.
+type CustomMetricsRequest struct {
+	// Namespace of the described objects, empty for cluster-scoped objects.
+	Namespace string
+	// Resource of the described objects, such as pods or deployments.apps.
+	Resource schema.GroupResource
+	// Name of the described object, or AllObjects (the default) for all the
+	// objects matching Selector.
+	Name   string
+	Metric string
+	// Selector selects the described objects by label.
+	Selector string
+	// MetricSelector selects the metric values by label.
+	MetricSelector string
+	// FieldSelector filters the metric values by field.
+	FieldSelector string
+}
+
+// ExternalMetricsRequest identifies the values of an external metric to fetch.
+type ExternalMetricsRequest struct {
+	Namespace string
+	Metric    string
+	// MetricSelector selects the metric values by label.
+	MetricSelector string
+}
+
+// Client queries the custom.metrics.k8s.io/v1beta2 and
+// external.metrics.k8s.io/v1beta1 APIs.
+type Client struct {
+	client rest.Interface
+}
+
+// NewForConfig creates a Client connecting with the given configuration.
+// Unless the configuration sets the content types to accept, the client
+// prefers protobuf responses, which are smaller and cheaper to decode, and
+// falls back to JSON.
+func NewForConfig(config *rest.Config) (*Client, error) {
+	config = rest.CopyConfig(config)
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = apiserver.Codecs.WithoutConversion()
+	if config.AcceptContentTypes == "" {
+		config.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	client, err := rest.UnversionedRESTClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	return New(client), nil
+}
+
+// New creates a Client using the given REST client.
+func New(client rest.Interface) *Client {
+	return &Client{client: client}
+}
+
+// CustomMetrics fetches the values of a custom metric.
+func (c *Client) CustomMetrics(ctx context.Context, req CustomMetricsRequest) (*cmv1beta2.MetricValueList, error) {
+	name := req.Name
+	if name == "" {
+		name = AllObjects
+	}
+
+	var metricPath string
+	switch {
+	case req.Resource == schema.GroupResource{Resource: "namespaces"}:
+		// metrics describing namespaces are served under the namespace
+		metricPath = path.Join(customMetricsPath, "namespaces", name, "metrics", req.Metric)
+	case req.Namespace != "":
+		metricPath = path.Join(customMetricsPath, "namespaces", req.Namespace, req.Resource.String(), name, req.Metric)
+	default:
+		metricPath = path.Join(customMetricsPath, req.Resource.String(), name, req.Metric)
+	}
+
+	options := &cmv1beta2.MetricListOptions{
+		LabelSelector:       req.Selector,
+		MetricLabelSelector: req.MetricSelector,
+	}
+	request := c.client.Get().AbsPath(metricPath).SpecificallyVersionedParams(options, parameterCodec, cmv1beta2.SchemeGroupVersion)
+	if req.FieldSelector != "" {
+		request = request.Param("fieldSelector", req.FieldSelector)
+	}
+
+	result := &cmv1beta2.MetricValueList{}
+	if err := request.Do(ctx).Into(result); err != nil {
+		return nil, err
+	}
+	result.SetGroupVersionKind(cmv1beta2.SchemeGroupVersion.WithKind("MetricValueList"))
+	return result, nil
+}
+
+// ExternalMetrics fetches the values of an external metric.
+func (c *Client) ExternalMetrics(ctx context.Context, req ExternalMetricsRequest) (*emv1beta1.ExternalMetricValueList, error) {
+	result := &emv1beta1.ExternalMetricValueList{}
+	err := c.client.Get().
+		AbsPath(externalMetricsPath, "namespaces", req.Namespace, req.Metric).
+		SpecificallyVersionedParams(&metav1.ListOptions{LabelSelector: req.MetricSelector}, metav1.ParameterCodec, metav1.SchemeGroupVersion).
+		Do(ctx).
+		Into(result)
+	if err != nil {
+		return nil, err
+	}
+	result.SetGroupVersionKind(emv1beta1.SchemeGroupVersion.WithKind("ExternalMetricValueList"))
+	return result, nil
+}
+
+// ListCustomMetrics lists the custom metrics advertised in the discovery
+// information of the API.
+func (c *Client) ListCustomMetrics(ctx context.Context) ([]provider.CustomMetricInfo, error) {
+	infos, _, err := c.customMetrics(ctx)
+	return infos, err
+}
+
+// ListCustomMetricsMetadata lists the metadata of the custom metrics
+// advertised in the discovery information of the API, as described by the
+// categories of their resources.  Metrics without any are left out.
+func (c *Client) ListCustomMetricsMetadata(ctx context.Context) (map[provider.CustomMetricInfo]provider.MetricMetadata, error) {
+	infos, resources, err := c.customMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[provider.CustomMetricInfo]provider.MetricMetadata{}
+	for i, info := range infos {
+		if m := provider.MetricMetadataFromCategories(resources[i].Categories); m != nil {
+			metadata[info] = *m
+		}
+	}
+	return metadata, nil
+}
+
+// customMetrics lists the custom metrics advertised in the discovery
+// information of the API, along with their resources.
+func (c *Client) customMetrics(ctx context.Context) ([]provider.CustomMetricInfo, []metav1.APIResource, error) {
+	resources, err := c.apiResources(ctx, customMetricsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	infos := make([]provider.CustomMetricInfo, 0, len(resources.APIResources))
+	for _, resource := range resources.APIResources {
+		// resources are named after the group resource and the metric
+		groupResource, metric, found := strings.Cut(resource.Name, "/")
+		if !found {
+			return nil, nil, fmt.Errorf("unexpected custom metric resource %q", resource.Name)
+		}
+		infos = append(infos, provider.CustomMetricInfo{
+			GroupResource: schema.ParseGroupResource(groupResource),
+			Namespaced:    resource.Namespaced,
+			Metric:        metric,
+		})
+	}
+	return infos, resources.APIResources, nil
+}
+
+// ListExternalMetrics lists the external metrics advertised in the
+// discovery information of the API.
+func (c *Client) ListExternalMetrics(ctx context.Context) ([]provider.ExternalMetricInfo, error) {
+	resources, err := c.apiResources(ctx, externalMetricsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]provider.ExternalMetricInfo, 0, len(resources.APIResources))
+	for _, resource := range resources.APIResources {
+		infos = append(infos, provider.ExternalMetricInfo{Metric: resource.Name})
+	}
+	return infos, nil
+}
+
+// ListExternalMetricsMetadata lists the metadata of the external metrics
+// advertised in the discovery information of the API, like
+// ListCustomMetricsMetadata.
+func (c *Client) ListExternalMetricsMetadata(ctx context.Context) (map[provider.ExternalMetricInfo]provider.MetricMetadata, error) {
+	resources, err := c.apiResources(ctx, externalMetricsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[provider.ExternalMetricInfo]provider.MetricMetadata{}
+	for _, resource := range resources.APIResources {
+		if m := provider.MetricMetadataFromCategories(resource.Categories); m != nil {
+			metadata[provider.ExternalMetricInfo{Metric: resource.Name}] = *m
+		}
+	}
+	return metadata, nil
+}
+
+func (c *Client) apiResources(ctx context.Context, groupVersionPath string) (*metav1.APIResourceList, error) {
+	resources := &metav1.APIResourceList{}
+	if err := c.client.Get().AbsPath(groupVersionPath).Do(ctx).Into(resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	apiservertesting "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/testing"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+var (
+	testTimestamp = metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	podsResource  = schema.GroupResource{Resource: "pods"}
+	nodesResource = schema.GroupResource{Resource: "nodes"}
+)
+
+// testProvider serves a metric for two pods and a node, and an external metric.
+type testProvider struct {
+	pods map[string]labels.Set
+}
+
+func newTestProvider() *testProvider {
+	return &testProvider{
+		pods: map[string]labels.Set{
+			"frontend-1": {"app": "frontend"},
+			"backend-1":  {"app": "backend"},
+		},
+	}
+}
+
+func (p *testProvider) value(name types.NamespacedName, info provider.CustomMetricInfo, milli int64) custom_metrics.MetricValue {
+	kind := "Pod"
+	if info.GroupResource == nodesResource {
+		kind = "Node"
+	}
+	return custom_metrics.MetricValue{
+		DescribedObject: custom_metrics.ObjectReference{Kind: kind, Namespace: name.Namespace, Name: name.Name, APIVersion: "v1"},
+		Metric:          custom_metrics.MetricIdentifier{Name: info.Metric},
+		Timestamp:       testTimestamp,
+		Value:           *resource.NewMilliQuantity(milli, resource.DecimalSI),
+	}
+}
+
+func (p *testProvider) GetMetricByName(_ context.Context, name types.NamespacedName, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValue, error) {
+	value := p.value(name, info, 500)
+	return &value, nil
+}
+
+func (p *testProvider) GetMetricBySelector(_ context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, _ labels.Selector) (*custom_metrics.MetricValueList, error) {
+	list := &custom_metrics.MetricValueList{}
+	var milli int64
+	for _, name := range []string{"backend-1", "frontend-1"} {
+		milli += 1000
+		if selector.Matches(p.pods[name]) {
+			list.Items = append(list.Items, p.value(types.NamespacedName{Namespace: namespace, Name: name}, info, milli))
+		}
+	}
+	return list, nil
+}
+
+func (p *testProvider) ListAllMetrics() []provider.CustomMetricInfo {
+	return []provider.CustomMetricInfo{
+		{GroupResource: podsResource, Namespaced: true, Metric: "http_requests"},
+		{GroupResource: nodesResource, Metric: "temperature"},
+	}
+}
+
+func (p *testProvider) GetExternalMetric(_ context.Context, _ string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	list := &external_metrics.ExternalMetricValueList{}
+	for _, queue := range []string{"billing", "orders"} {
+		metricLabels := map[string]string{"queue": queue}
+		if metricSelector.Matches(labels.Set(metricLabels)) {
+			list.Items = append(list.Items, external_metrics.ExternalMetricValue{
+				MetricName:   info.Metric,
+				MetricLabels: metricLabels,
+				Timestamp:    testTimestamp,
+				Value:        resource.MustParse("42"),
+			})
+		}
+	}
+	return list, nil
+}
+
+func (p *testProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	return []provider.ExternalMetricInfo{{Metric: "queue_length"}}
+}
+
+func (p *testProvider) CustomMetricMetadata(info provider.CustomMetricInfo) *provider.MetricMetadata {
+	if info.Metric != "http_requests" {
+		return nil
+	}
+	return &provider.MetricMetadata{Unit: "requests/s", Kind: provider.MetricKindRate, Description: "Requests served"}
+}
+
+func (p *testProvider) ExternalMetricMetadata(provider.ExternalMetricInfo) *provider.MetricMetadata {
+	return &provider.MetricMetadata{Kind: provider.MetricKindGauge}
+}
+
+func testClient(t *testing.T) *Client {
+	prov := newTestProvider()
+	server := apiservertesting.StartTestServer(t, prov, prov)
+	client, err := NewForConfig(server.ClientConfig)
+	require.NoError(t, err)
+	return client
+}
+
+func TestCustomMetrics(t *testing.T) {
+	client := testClient(t)
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		req      CustomMetricsRequest
+		expected map[string]string
+	}{
+		"selected objects": {
+			req:      CustomMetricsRequest{Namespace: "default", Resource: podsResource, Metric: "http_requests", Selector: "app=frontend"},
+			expected: map[string]string{"default/frontend-1": "2"},
+		},
+		"all objects": {
+			req:      CustomMetricsRequest{Namespace: "default", Resource: podsResource, Metric: "http_requests"},
+			expected: map[string]string{"default/backend-1": "1", "default/frontend-1": "2"},
+		},
+		"filtered objects": {
+			req:      CustomMetricsRequest{Namespace: "default", Resource: podsResource, Metric: "http_requests", FieldSelector: "value=1"},
+			expected: map[string]string{"default/backend-1": "1"},
+		},
+		"single object": {
+			req:      CustomMetricsRequest{Namespace: "default", Resource: podsResource, Name: "backend-1", Metric: "http_requests"},
+			expected: map[string]string{"default/backend-1": "500m"},
+		},
+		"cluster-scoped object": {
+			req:      CustomMetricsRequest{Resource: nodesResource, Name: "node-1", Metric: "temperature"},
+			expected: map[string]string{"/node-1": "500m"},
+		},
+		"namespace": {
+			req:      CustomMetricsRequest{Resource: schema.GroupResource{Resource: "namespaces"}, Name: "default", Metric: "http_requests"},
+			expected: map[string]string{"/default": "500m"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			list, err := client.CustomMetrics(ctx, tc.req)
+			require.NoError(t, err)
+			assert.Equal(t, "custom.metrics.k8s.io/v1beta2", list.APIVersion)
+			actual := map[string]string{}
+			for _, value := range list.Items {
+				assert.Equal(t, tc.req.Metric, value.Metric.Name)
+				actual[value.DescribedObject.Namespace+"/"+value.DescribedObject.Name] = value.Value.String()
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestExternalMetrics(t *testing.T) {
+	client := testClient(t)
+
+	list, err := client.ExternalMetrics(context.Background(), ExternalMetricsRequest{Namespace: "default", Metric: "queue_length", MetricSelector: "queue=orders"})
+	require.NoError(t, err)
+	assert.Equal(t, "external.metrics.k8s.io/v1beta1", list.APIVersion)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, map[string]string{"queue": "orders"}, list.Items[0].MetricLabels)
+	assert.Equal(t, "42", list.Items[0].Value.String())
+}
+
+func TestListMetrics(t *testing.T) {
+	client := testClient(t)
+	ctx := context.Background()
+
+	customMetrics, err := client.ListCustomMetrics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, newTestProvider().ListAllMetrics(), customMetrics)
+
+	externalMetrics, err := client.ListExternalMetrics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, newTestProvider().ListAllExternalMetrics(), externalMetrics)
+}
+
+func TestListMetricsMetadata(t *testing.T) {
+	client := testClient(t)
+	ctx := context.Background()
+
+	// the kinds and units of the metrics are discovered as categories
+	customMetadata, err := client.ListCustomMetricsMetadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[provider.CustomMetricInfo]provider.MetricMetadata{
+		{GroupResource: podsResource, Namespaced: true, Metric: "http_requests"}: {Unit: "requests/s", Kind: provider.MetricKindRate},
+	}, customMetadata)
+
+	externalMetadata, err := client.ListExternalMetricsMetadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[provider.ExternalMetricInfo]provider.MetricMetadata{
+		{Metric: "queue_length"}: {Kind: provider.MetricKindGauge},
+	}, externalMetadata)
+}
+
+// contentTypes records the content types of the responses.
+type contentTypes struct {
+	transport http.RoundTripper
+	lock      sync.Mutex
+	types     sets.Set[string]
+}
+
+func (c *contentTypes) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.transport.RoundTrip(req)
+	if err == nil {
+		c.lock.Lock()
+		c.types.Insert(resp.Header.Get("Content-Type"))
+		c.lock.Unlock()
+	}
+	return resp, err
+}
+
+func TestContentTypes(t *testing.T) {
+	prov := newTestProvider()
+	server := apiservertesting.StartTestServer(t, prov, prov)
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		accept   string
+		expected string
+	}{
+		"default":         {expected: runtime.ContentTypeProtobuf},
+		"configured JSON": {accept: runtime.ContentTypeJSON, expected: runtime.ContentTypeJSON},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := &contentTypes{types: sets.New[string]()}
+			config := rest.CopyConfig(server.ClientConfig)
+			config.AcceptContentTypes = tc.accept
+			config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+				recorder.transport = rt
+				return recorder
+			}
+			client, err := NewForConfig(config)
+			require.NoError(t, err)
+
+			list, err := client.CustomMetrics(ctx, CustomMetricsRequest{Namespace: "default", Resource: podsResource, Metric: "http_requests"})
+			require.NoError(t, err)
+			assert.Len(t, list.Items, 2)
+			externalList, err := client.ExternalMetrics(ctx, ExternalMetricsRequest{Namespace: "default", Metric: "queue_length"})
+			require.NoError(t, err)
+			assert.NotEmpty(t, externalList.Items)
+			customMetrics, err := client.ListCustomMetrics(ctx)
+			require.NoError(t, err)
+			assert.NotEmpty(t, customMetrics)
+
+			assert.Equal(t, []string{tc.expected}, sets.List(recorder.types))
+		})
+	}
+}
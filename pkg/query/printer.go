@@ -0,0 +1,184 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	emv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// Format is an output format of a Printer.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatYAML  Format = "yaml"
+)
+
+// Formats lists the supported output formats.
+var Formats = []Format{FormatTable, FormatJSON, FormatYAML}
+
+// Printer writes query results in a given format.  Tables only get a
+// header the first time something is printed, so that repeated queries
+// read as a single table.
+type Printer struct {
+	out           io.Writer
+	format        Format
+	printedHeader bool
+}
+
+// NewPrinter creates a Printer writing to out in the given format.
+func NewPrinter(out io.Writer, format Format) (*Printer, error) {
+	for _, supported := range Formats {
+		if format == supported {
+			return &Printer{out: out, format: format}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported output format %q, expected one of %v", format, Formats)
+}
+
+// PrintCustomMetrics prints custom metric values.
+func (p *Printer) PrintCustomMetrics(list *cmv1beta2.MetricValueList) error {
+	if p.format != FormatTable {
+		return p.printObject(list)
+	}
+
+	return p.printTable([]string{"NAMESPACE", "OBJECT", "METRIC", "LABELS", "VALUE", "TIMESTAMP", "WINDOW"}, func(w io.Writer) {
+		for _, value := range list.Items {
+			object := value.DescribedObject
+			fmt.Fprintf(w, "%s\t%s/%s\t%s\t%s\t%s\t%s\t%s\n",
+				orNone(object.Namespace), strings.ToLower(object.Kind), object.Name, value.Metric.Name,
+				selectorString(value.Metric.Selector), value.Value.String(), timestampString(value.Timestamp), windowString(value.WindowSeconds))
+		}
+	})
+}
+
+// PrintExternalMetrics prints external metric values.
+func (p *Printer) PrintExternalMetrics(list *emv1beta1.ExternalMetricValueList) error {
+	if p.format != FormatTable {
+		return p.printObject(list)
+	}
+
+	return p.printTable([]string{"METRIC", "LABELS", "VALUE", "TIMESTAMP", "WINDOW"}, func(w io.Writer) {
+		for _, value := range list.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				value.MetricName, orNone(labels.Set(value.MetricLabels).String()), value.Value.String(),
+				timestampString(value.Timestamp), windowString(value.WindowSeconds))
+		}
+	})
+}
+
+// MetricsListing lists the metrics served by an adapter.
+type MetricsListing struct {
+	CustomMetrics   []provider.CustomMetricInfo   `json:"customMetrics,omitempty"`
+	ExternalMetrics []provider.ExternalMetricInfo `json:"externalMetrics,omitempty"`
+}
+
+// PrintMetricsListing prints the metrics served by an adapter.
+func (p *Printer) PrintMetricsListing(listing *MetricsListing) error {
+	if p.format != FormatTable {
+		return p.printObject(listing)
+	}
+
+	customMetrics := append([]provider.CustomMetricInfo(nil), listing.CustomMetrics...)
+	sort.Slice(customMetrics, func(i, j int) bool {
+		return customMetrics[i].String() < customMetrics[j].String()
+	})
+	externalMetrics := append([]provider.ExternalMetricInfo(nil), listing.ExternalMetrics...)
+	sort.Slice(externalMetrics, func(i, j int) bool {
+		return externalMetrics[i].Metric < externalMetrics[j].Metric
+	})
+
+	return p.printTable([]string{"API", "RESOURCE", "METRIC", "NAMESPACED"}, func(w io.Writer) {
+		for _, info := range customMetrics {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", cmv1beta2.SchemeGroupVersion.Group, info.GroupResource.String(), info.Metric, info.Namespaced)
+		}
+		for _, info := range externalMetrics {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", emv1beta1.SchemeGroupVersion.Group, "<none>", info.Metric, true)
+		}
+	})
+}
+
+func (p *Printer) printTable(header []string, rows func(w io.Writer)) error {
+	w := tabwriter.NewWriter(p.out, 0, 8, 3, ' ', 0)
+	if !p.printedHeader {
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		p.printedHeader = true
+	}
+	rows(w)
+	return w.Flush()
+}
+
+func (p *Printer) printObject(obj interface{}) error {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	if p.format == FormatYAML {
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			return err
+		}
+		// separate the documents of repeated queries
+		data = append([]byte("---\n"), data...)
+	} else {
+		data = append(data, '\n')
+	}
+	_, err = p.out.Write(data)
+	return err
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+func selectorString(selector *metav1.LabelSelector) string {
+	if selector == nil {
+		return "<none>"
+	}
+	return orNone(metav1.FormatLabelSelector(selector))
+}
+
+func timestampString(timestamp metav1.Time) string {
+	if timestamp.IsZero() {
+		return "<none>"
+	}
+	return timestamp.UTC().Format(time.RFC3339)
+}
+
+func windowString(windowSeconds *int64) string {
+	if windowSeconds == nil {
+		return "<none>"
+	}
+	return (time.Duration(*windowSeconds) * time.Second).String()
+}
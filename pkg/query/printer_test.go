@@ -0,0 +1,91 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	emv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+)
+
+func TestPrinterTable(t *testing.T) {
+	out := &bytes.Buffer{}
+	printer, err := NewPrinter(out, FormatTable)
+	require.NoError(t, err)
+
+	window := int64(60)
+	list := &cmv1beta2.MetricValueList{Items: []cmv1beta2.MetricValue{{
+		DescribedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "frontend-1", APIVersion: "v1"},
+		Metric:          cmv1beta2.MetricIdentifier{Name: "http_requests", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"verb": "GET"}}},
+		Timestamp:       testTimestamp,
+		WindowSeconds:   &window,
+		Value:           resource.MustParse("1500m"),
+	}}}
+	require.NoError(t, printer.PrintCustomMetrics(list))
+	// repeated queries don't repeat the header
+	require.NoError(t, printer.PrintCustomMetrics(list))
+
+	assert.Equal(t, ""+
+		"NAMESPACE   OBJECT           METRIC          LABELS     VALUE   TIMESTAMP              WINDOW\n"+
+		"default     pod/frontend-1   http_requests   verb=GET   1500m   2026-01-02T03:04:05Z   1m0s\n"+
+		"default   pod/frontend-1   http_requests   verb=GET   1500m   2026-01-02T03:04:05Z   1m0s\n",
+		out.String())
+
+	out.Reset()
+	printer, err = NewPrinter(out, FormatTable)
+	require.NoError(t, err)
+	require.NoError(t, printer.PrintExternalMetrics(&emv1beta1.ExternalMetricValueList{Items: []emv1beta1.ExternalMetricValue{{
+		MetricName: "queue_length",
+		Timestamp:  testTimestamp,
+		Value:      resource.MustParse("42"),
+	}}}))
+	assert.Equal(t, ""+
+		"METRIC         LABELS   VALUE   TIMESTAMP              WINDOW\n"+
+		"queue_length   <none>   42      2026-01-02T03:04:05Z   <none>\n",
+		out.String())
+}
+
+func TestPrinterObjects(t *testing.T) {
+	list := &emv1beta1.ExternalMetricValueList{Items: []emv1beta1.ExternalMetricValue{{
+		MetricName: "queue_length",
+		Timestamp:  testTimestamp,
+		Value:      resource.MustParse("42"),
+	}}}
+
+	out := &bytes.Buffer{}
+	printer, err := NewPrinter(out, FormatJSON)
+	require.NoError(t, err)
+	require.NoError(t, printer.PrintExternalMetrics(list))
+	assert.JSONEq(t, `{"metadata":{},"items":[{"metricName":"queue_length","metricLabels":null,"timestamp":"2026-01-02T03:04:05Z","value":"42"}]}`, out.String())
+
+	out.Reset()
+	printer, err = NewPrinter(out, FormatYAML)
+	require.NoError(t, err)
+	require.NoError(t, printer.PrintExternalMetrics(list))
+	assert.Contains(t, out.String(), "---\nitems:\n- metricLabels: null\n  metricName: queue_length\n")
+
+	_, err = NewPrinter(out, Format("xml"))
+	assert.Error(t, err)
+}
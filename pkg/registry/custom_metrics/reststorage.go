@@ -19,24 +19,33 @@ package apiserver
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/component-base/tracing"
 	"k8s.io/metrics/pkg/apis/custom_metrics"
 
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
 	cm_rest "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/registry/rest"
+	cm_tracing "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/tracing"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/registry/staleness"
 )
 
 type REST struct {
 	cmProvider        provider.CustomMetricsProvider
 	freshnessObserver metrics.FreshnessObserver
+	stalenessObserver metrics.StalenessObserver
+	providerObserver  metrics.ProviderObserver
+	stalenessPolicy   *staleness.Policy
 }
 
 var _ rest.Storage = &REST{}
@@ -47,9 +56,25 @@ func NewREST(cmProvider provider.CustomMetricsProvider) *REST {
 	return &REST{
 		cmProvider:        cmProvider,
 		freshnessObserver: freshnessObserver,
+		stalenessObserver: metrics.NewStalenessObserver(custom_metrics.GroupName),
+		providerObserver:  metrics.NewProviderObserver(custom_metrics.GroupName, nil),
 	}
 }
 
+// WithProviderObserver sets the observer recording per-metric instrumentation
+// of the calls made to the provider.
+func (r *REST) WithProviderObserver(observer metrics.ProviderObserver) *REST {
+	r.providerObserver = observer
+	return r
+}
+
+// WithStalenessPolicy sets the policy used to drop or reject metric values
+// which are too old.  A nil policy serves every value.
+func (r *REST) WithStalenessPolicy(policy *staleness.Policy) *REST {
+	r.stalenessPolicy = policy
+	return r
+}
+
 // Implement Storage
 
 func (r *REST) New() runtime.Object {
@@ -100,6 +125,18 @@ func (r *REST) List(ctx context.Context, options *metainternalversion.ListOption
 		}
 	}
 
+	// any other fields are used to filter the returned values, and are
+	// passed on to the provider in case it can make use of them
+	var fieldFilter *provider.MetricValueFilter
+	if options != nil {
+		filter, err := provider.NewMetricValueFilter(options.FieldSelector)
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+		fieldFilter = filter
+		ctx = provider.WithMetricValueFilter(ctx, fieldFilter)
+	}
+
 	namespace := request.NamespaceValue(ctx)
 
 	requestInfo, ok := request.RequestInfoFrom(ctx)
@@ -112,29 +149,63 @@ func (r *REST) List(ctx context.Context, options *metainternalversion.ListOption
 
 	groupResource := schema.ParseGroupResource(resourceRaw)
 
+	ctx, span := tracing.Start(ctx, "CustomMetrics.List",
+		cm_tracing.Metric(metricName),
+		cm_tracing.GroupResource(groupResource),
+		cm_tracing.Namespace(namespace),
+	)
+	defer span.End(500 * time.Millisecond)
+
 	var res *custom_metrics.MetricValueList
 	var err error
 
 	// handle namespaced and root metrics
+	start := time.Now()
 	if name == "*" {
 		res, err = r.handleWildcardOp(ctx, namespace, groupResource, selector, metricName, metricLabelSelector)
 	} else {
 		res, err = r.handleIndividualOp(ctx, namespace, groupResource, name, metricName, metricLabelSelector)
 	}
 
+	var count int
+	if res != nil {
+		count = len(res.Items)
+	}
+	r.providerObserver.ObserveRequest(groupResource, metricName, time.Since(start), count, err)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
+	res = fieldFilter.Filter(res)
+
 	for _, m := range res.Items {
 		r.freshnessObserver.Observe(m.Timestamp)
+		r.providerObserver.ObserveFreshness(groupResource, metricName, m.Timestamp)
+	}
+
+	items, stale, err := staleness.Enforce(r.stalenessPolicy, metricName, res.Items, func(m *custom_metrics.MetricValue) metav1.Time {
+		return m.Timestamp
+	})
+	r.stalenessObserver.ObserveStale(stale)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
 	}
+	res.Items = items
+	span.AddEvent("Filtered metric values", cm_tracing.ItemCount(len(res.Items)))
 
 	return res, nil
 }
 
 func (r *REST) handleIndividualOp(ctx context.Context, namespace string, groupResource schema.GroupResource, name string, metricName string, metricLabelSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
-	singleRes, err := r.cmProvider.GetMetricByName(ctx, types.NamespacedName{Namespace: namespace, Name: name}, provider.CustomMetricInfo{
+	ctx, span := tracing.Start(ctx, "CustomMetrics.IndividualOp",
+		cm_tracing.Name(name),
+		cm_tracing.MetricSelector(metricLabelSelector),
+	)
+	defer span.End(500 * time.Millisecond)
+
+	singleRes, err := r.getMetricByName(ctx, types.NamespacedName{Namespace: namespace, Name: name}, provider.CustomMetricInfo{
 		GroupResource: groupResource,
 		Metric:        metricName,
 		Namespaced:    namespace != "",
@@ -149,9 +220,47 @@ func (r *REST) handleIndividualOp(ctx context.Context, namespace string, groupRe
 }
 
 func (r *REST) handleWildcardOp(ctx context.Context, namespace string, groupResource schema.GroupResource, selector labels.Selector, metricName string, metricLabelSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
-	return r.cmProvider.GetMetricBySelector(ctx, namespace, selector, provider.CustomMetricInfo{
+	ctx, span := tracing.Start(ctx, "CustomMetrics.WildcardOp",
+		cm_tracing.Selector(selector),
+		cm_tracing.MetricSelector(metricLabelSelector),
+	)
+	defer span.End(500 * time.Millisecond)
+
+	return r.getMetricBySelector(ctx, namespace, selector, provider.CustomMetricInfo{
 		GroupResource: groupResource,
 		Metric:        metricName,
 		Namespaced:    namespace != "",
 	}, metricLabelSelector)
 }
+
+// getMetricByName calls the provider within its own span, so that the time
+// spent in the provider can be told apart from the time spent serving.
+func (r *REST) getMetricByName(ctx context.Context, name types.NamespacedName, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValue, error) {
+	ctx, span := tracing.Start(ctx, "provider.GetMetricByName")
+	defer span.End(500 * time.Millisecond)
+
+	res, err := r.cmProvider.GetMetricByName(ctx, name, info, metricSelector)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.AddEvent("Provider call done", cm_tracing.ItemCount(1))
+	return res, nil
+}
+
+// getMetricBySelector calls the provider within its own span, so that the time
+// spent in the provider can be told apart from the time spent serving.
+func (r *REST) getMetricBySelector(ctx context.Context, namespace string, selector labels.Selector, info provider.CustomMetricInfo, metricSelector labels.Selector) (*custom_metrics.MetricValueList, error) {
+	ctx, span := tracing.Start(ctx, "provider.GetMetricBySelector")
+	defer span.End(500 * time.Millisecond)
+
+	res, err := r.cmProvider.GetMetricBySelector(ctx, namespace, selector, info, metricSelector)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if res != nil {
+		span.AddEvent("Provider call done", cm_tracing.ItemCount(len(res.Items)))
+	}
+	return res, nil
+}
@@ -19,16 +19,22 @@ package apiserver
 import (
 	"context"
 	"fmt"
+	"time"
 
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/component-base/tracing"
 	"k8s.io/metrics/pkg/apis/external_metrics"
 
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
+	cm_tracing "sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/tracing"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/registry/staleness"
 )
 
 // REST is a wrapper for CustomMetricsProvider that provides implementation for Storage and Lister
@@ -36,6 +42,9 @@ import (
 type REST struct {
 	emProvider        provider.ExternalMetricsProvider
 	freshnessObserver metrics.FreshnessObserver
+	stalenessObserver metrics.StalenessObserver
+	providerObserver  metrics.ProviderObserver
+	stalenessPolicy   *staleness.Policy
 	rest.TableConvertor
 }
 
@@ -48,9 +57,25 @@ func NewREST(emProvider provider.ExternalMetricsProvider) *REST {
 	return &REST{
 		emProvider:        emProvider,
 		freshnessObserver: freshnessObserver,
+		stalenessObserver: metrics.NewStalenessObserver(external_metrics.GroupName),
+		providerObserver:  metrics.NewProviderObserver(external_metrics.GroupName, nil),
 	}
 }
 
+// WithProviderObserver sets the observer recording per-metric instrumentation
+// of the calls made to the provider.
+func (r *REST) WithProviderObserver(observer metrics.ProviderObserver) *REST {
+	r.providerObserver = observer
+	return r
+}
+
+// WithStalenessPolicy sets the policy used to drop or reject metric values
+// which are too old.  A nil policy serves every value.
+func (r *REST) WithStalenessPolicy(policy *staleness.Policy) *REST {
+	r.stalenessPolicy = policy
+	return r
+}
+
 // Implement Storage
 
 // New returns empty MetricValue.
@@ -84,14 +109,58 @@ func (r *REST) List(ctx context.Context, options *metainternalversion.ListOption
 	}
 	metricName := requestInfo.Resource
 
-	res, err := r.emProvider.GetExternalMetric(ctx, namespace, metricSelector, provider.ExternalMetricInfo{Metric: metricName})
+	ctx, span := tracing.Start(ctx, "ExternalMetrics.List",
+		cm_tracing.Metric(metricName),
+		cm_tracing.Namespace(namespace),
+		cm_tracing.MetricSelector(metricSelector),
+	)
+	defer span.End(500 * time.Millisecond)
+
+	start := time.Now()
+	res, err := r.getExternalMetric(ctx, namespace, metricSelector, provider.ExternalMetricInfo{Metric: metricName})
+	var count int
+	if res != nil {
+		count = len(res.Items)
+	}
+	// external metrics aren't associated with a resource
+	r.providerObserver.ObserveRequest(schema.GroupResource{}, metricName, time.Since(start), count, err)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
 	for _, m := range res.Items {
 		r.freshnessObserver.Observe(m.Timestamp)
+		r.providerObserver.ObserveFreshness(schema.GroupResource{}, metricName, m.Timestamp)
+	}
+
+	items, stale, err := staleness.Enforce(r.stalenessPolicy, metricName, res.Items, func(m *external_metrics.ExternalMetricValue) metav1.Time {
+		return m.Timestamp
+	})
+	r.stalenessObserver.ObserveStale(stale)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
 	}
+	res.Items = items
+	span.AddEvent("Filtered metric values", cm_tracing.ItemCount(len(res.Items)))
 
 	return res, nil
 }
+
+// getExternalMetric calls the provider within its own span, so that the time
+// spent in the provider can be told apart from the time spent serving.
+func (r *REST) getExternalMetric(ctx context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	ctx, span := tracing.Start(ctx, "provider.GetExternalMetric")
+	defer span.End(500 * time.Millisecond)
+
+	res, err := r.emProvider.GetExternalMetric(ctx, namespace, metricSelector, info)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if res != nil {
+		span.AddEvent("Provider call done", cm_tracing.ItemCount(len(res.Items)))
+	}
+	return res, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package staleness enforces a maximum age on the metric values served by
+// the metrics API registries.
+package staleness
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+)
+
+// Action describes what happens to a response containing stale values.
+type Action string
+
+const (
+	// ActionDrop removes stale values from the response, which may leave it empty.
+	ActionDrop Action = "Drop"
+	// ActionReject fails the whole request if any of the values is stale.
+	ActionReject Action = "Reject"
+)
+
+// Policy describes the maximum age of the metric values served to clients.
+// A nil Policy, or one with no maximum age, accepts every value.
+type Policy struct {
+	// MaxAge is the maximum age of values for metrics without a more
+	// specific limit.  Zero means no limit.
+	MaxAge time.Duration
+	// MaxAgePerMetric overrides MaxAge for the given metric names.
+	// Zero means no limit for that metric.
+	MaxAgePerMetric map[string]time.Duration
+	// Action is performed when stale values are found.  It defaults to ActionDrop.
+	Action Action
+
+	// Clock is used to compute the age of values.  It defaults to the real clock.
+	Clock clock.PassiveClock
+}
+
+// MaxAgeFor returns the maximum age of values of the given metric, or zero
+// if they are not limited.
+func (p *Policy) MaxAgeFor(metric string) time.Duration {
+	if p == nil {
+		return 0
+	}
+	if maxAge, ok := p.MaxAgePerMetric[metric]; ok {
+		return maxAge
+	}
+	return p.MaxAge
+}
+
+func (p *Policy) clock() clock.PassiveClock {
+	if p.Clock == nil {
+		return clock.RealClock{}
+	}
+	return p.Clock
+}
+
+// Enforce applies the policy to the values of the given metric, using timestampOf to
+// fetch the timestamp of each value.  It returns the values which may be served along
+// with the number of stale values found, or an error if the policy rejects stale values.
+func Enforce[T any](p *Policy, metric string, items []T, timestampOf func(*T) metav1.Time) ([]T, int, error) {
+	maxAge := p.MaxAgeFor(metric)
+	if maxAge <= 0 {
+		return items, 0, nil
+	}
+
+	now := p.clock().Now()
+	fresh := make([]T, 0, len(items))
+	for i := range items {
+		if now.Sub(timestampOf(&items[i]).Time) <= maxAge {
+			fresh = append(fresh, items[i])
+		}
+	}
+
+	stale := len(items) - len(fresh)
+	if stale > 0 && p.Action == ActionReject {
+		return nil, stale, NewStaleMetricError(metric, maxAge)
+	}
+	return fresh, stale, nil
+}
+
+// NewStaleMetricError returns a StatusError indicating that the values of the given
+// metric are older than the maximum allowed age.
+func NewStaleMetricError(metric string, maxAge time.Duration) *apierr.StatusError {
+	return &apierr.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    int32(http.StatusServiceUnavailable),
+		Reason:  metav1.StatusReasonServiceUnavailable,
+		Message: fmt.Sprintf("the server has values for the metric %s older than the maximum age of %s", metric, maxAge),
+	}}
+}
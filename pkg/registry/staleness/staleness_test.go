@@ -0,0 +1,80 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package staleness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func timestampOf(m *external_metrics.ExternalMetricValue) metav1.Time {
+	return m.Timestamp
+}
+
+func TestEnforce(t *testing.T) {
+	now := time.Now()
+	items := []external_metrics.ExternalMetricValue{
+		{MetricName: "fresh", Timestamp: metav1.NewTime(now.Add(-10 * time.Second))},
+		{MetricName: "old", Timestamp: metav1.NewTime(now.Add(-2 * time.Minute))},
+	}
+
+	t.Run("nil policy", func(t *testing.T) {
+		res, stale, err := Enforce(nil, "foo", items, timestampOf)
+		require.NoError(t, err)
+		assert.Equal(t, 0, stale)
+		assert.Len(t, res, 2)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		policy := &Policy{MaxAge: time.Minute, Action: ActionDrop, Clock: clocktesting.NewFakePassiveClock(now)}
+		res, stale, err := Enforce(policy, "foo", items, timestampOf)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stale)
+		require.Len(t, res, 1)
+		assert.Equal(t, "fresh", res[0].MetricName)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		policy := &Policy{MaxAge: time.Minute, Action: ActionReject, Clock: clocktesting.NewFakePassiveClock(now)}
+		_, stale, err := Enforce(policy, "foo", items, timestampOf)
+		assert.Equal(t, 1, stale)
+		assert.True(t, apierr.IsServiceUnavailable(err), "expected a service unavailable error, got %v", err)
+	})
+
+	t.Run("per metric", func(t *testing.T) {
+		policy := &Policy{
+			MaxAge:          time.Minute,
+			MaxAgePerMetric: map[string]time.Duration{"slow": 5 * time.Minute, "unlimited": 0, "fast": time.Second},
+			Action:          ActionDrop,
+			Clock:           clocktesting.NewFakePassiveClock(now),
+		}
+
+		for metric, expected := range map[string]int{"foo": 1, "slow": 2, "unlimited": 2, "fast": 0} {
+			res, _, err := Enforce(policy, metric, items, timestampOf)
+			require.NoError(t, err)
+			assert.Len(t, res, expected, "unexpected number of values for metric %s", metric)
+		}
+	})
+}
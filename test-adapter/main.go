@@ -17,12 +17,11 @@ limitations under the License.
 package main
 
 import (
-	"context"
-	"net/http"
 	"os"
 	"time"
 
 	"github.com/emicklei/go-restful/v3"
+	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/component-base/logs"
 	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
@@ -30,6 +29,8 @@ import (
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/apiserver/metrics"
 	basecmd "sigs.k8s.io/custom-metrics-apiserver/pkg/cmd"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/faults"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/recording"
 	fakeprov "sigs.k8s.io/custom-metrics-apiserver/test-adapter/provider"
 )
 
@@ -38,6 +39,19 @@ type SampleAdapter struct {
 
 	// Message is printed on successful startup
 	Message string
+	// FixturesFile is the file of the metrics written at startup.
+	FixturesFile string
+	// SnapshotFile is the file the metrics are saved to, and restored from
+	// at startup.
+	SnapshotFile string
+	// SnapshotInterval is the interval of the snapshots, which are only
+	// taken on shutdown if it is zero.
+	SnapshotInterval time.Duration
+	// RecordFile is the file the provider calls are recorded to.
+	RecordFile string
+	// EnableFaultInjection injects the faults configured at runtime into the
+	// metrics served.
+	EnableFaultInjection bool
 }
 
 func (a *SampleAdapter) makeProviderOrDie() (provider.MetricsProvider, *restful.WebService) {
@@ -54,6 +68,53 @@ func (a *SampleAdapter) makeProviderOrDie() (provider.MetricsProvider, *restful.
 	return fakeprov.NewFakeProvider(client, mapper)
 }
 
+// restoreMetricsOrDie writes the fixtures, then restores the snapshot and
+// keeps saving it, if configured.
+func (a *SampleAdapter) restoreMetricsOrDie(testProvider provider.MetricsProvider) {
+	if a.FixturesFile != "" {
+		if err := fakeprov.LoadFixtures(testProvider, a.FixturesFile); err != nil {
+			klog.Fatalf("unable to load fixtures: %v", err)
+		}
+	}
+	if a.SnapshotFile == "" {
+		return
+	}
+	snapshotter, err := fakeprov.NewSnapshotter(testProvider, a.SnapshotFile, a.SnapshotInterval)
+	if err != nil {
+		klog.Fatalf("unable to construct snapshotter: %v", err)
+	}
+	if err := snapshotter.Restore(); err != nil {
+		klog.Fatalf("unable to restore snapshot: %v", err)
+	}
+	a.WithRunnable(snapshotter)
+}
+
+// recordOrDie wraps the provider to record its calls, if configured.
+func (a *SampleAdapter) recordOrDie(testProvider provider.MetricsProvider) provider.MetricsProvider {
+	if a.RecordFile == "" {
+		return testProvider
+	}
+	recorder, err := recording.NewFileRecorder(testProvider, recording.FileOptions{Path: a.RecordFile})
+	if err != nil {
+		klog.Fatalf("unable to construct recorder: %v", err)
+	}
+	return recorder
+}
+
+// injectFaultsOrDie wraps the provider to inject faults, if enabled, and
+// serves their configuration to authorized users.
+func (a *SampleAdapter) injectFaultsOrDie(testProvider provider.MetricsProvider) provider.MetricsProvider {
+	if !a.EnableFaultInjection {
+		return testProvider
+	}
+	injector, err := faults.NewInjector(testProvider, faults.Config{})
+	if err != nil {
+		klog.Fatalf("unable to construct fault injector: %v", err)
+	}
+	a.WithNonGoRestfulHandler(faults.Path, injector)
+	return injector
+}
+
 func main() {
 	logs.InitLogs()
 	defer logs.FlushLogs()
@@ -62,31 +123,31 @@ func main() {
 	cmd.Name = "test-adapter"
 
 	cmd.Flags().StringVar(&cmd.Message, "msg", "starting adapter...", "startup message")
+	cmd.Flags().StringVar(&cmd.FixturesFile, "fixtures", "", "YAML file of metrics to write at startup, in the format of the /write-metrics/store dump")
+	cmd.Flags().StringVar(&cmd.SnapshotFile, "snapshot-file", "", "file to save the metrics to, and to restore them from at startup, replacing the fixtures")
+	cmd.Flags().DurationVar(&cmd.SnapshotInterval, "snapshot-interval", 0, "interval of the snapshots of the metrics, which are only taken on shutdown if zero")
+	cmd.Flags().StringVar(&cmd.RecordFile, "record-file", "", "file to record the calls to the metrics provider to, rotated every 100 megabytes")
+	cmd.Flags().BoolVar(&cmd.EnableFaultInjection, "enable-fault-injection", false, "inject the faults configured at "+faults.Path+" into the metrics served")
 	logs.AddFlags(cmd.Flags())
 	if err := cmd.Flags().Parse(os.Args); err != nil {
 		klog.Fatalf("unable to parse flags: %v", err)
 	}
 
 	testProvider, webService := cmd.makeProviderOrDie()
-	cmd.WithCustomMetrics(testProvider)
-	cmd.WithExternalMetrics(testProvider)
+	cmd.restoreMetricsOrDie(testProvider)
+	servedProvider := cmd.recordOrDie(cmd.injectFaultsOrDie(testProvider))
+	cmd.WithCustomMetrics(servedProvider)
+	cmd.WithExternalMetrics(servedProvider)
 
 	if err := metrics.RegisterMetrics(legacyregistry.Register); err != nil {
 		klog.Fatalf("unable to register metrics: %v", err)
 	}
 
 	klog.Infof("%s", cmd.Message)
-	// Set up POST endpoint for writing fake metric values
-	restful.DefaultContainer.Add(webService)
-	go func() {
-		// Open port for POSTing fake metrics
-		server := &http.Server{
-			Addr:              ":8080",
-			ReadHeaderTimeout: 3 * time.Second,
-		}
-		klog.Fatal(server.ListenAndServe())
-	}()
-	if err := cmd.Run(context.Background()); err != nil {
+	// Set up POST endpoint for writing fake metric values.  The test adapter
+	// is not meant for production, so anyone may write metric values.
+	cmd.WithWebService(webService, basecmd.WithRouteAccess(basecmd.RoutePublic))
+	if err := cmd.Run(genericapiserver.SetupSignalContext()); err != nil {
 		klog.Fatalf("unable to run custom metrics adapter: %v", err)
 	}
 }
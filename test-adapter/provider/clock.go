@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// Clock is a clock running along with another clock, which can be advanced
+// so that tests can fast-forward the generated metrics and the expiration of
+// the written ones.  The provider returned by NewFakeProvider uses a Clock
+// running along with the wall clock, advanced with the /write-metrics/clock
+// route.
+type Clock struct {
+	clock clock.PassiveClock
+
+	lock   sync.RWMutex
+	offset time.Duration
+}
+
+var _ clock.PassiveClock = &Clock{}
+
+// NewClock returns a Clock running along with the given clock.
+func NewClock(base clock.PassiveClock) *Clock {
+	return &Clock{clock: base}
+}
+
+// Now returns the current time, ahead of the base clock by the durations
+// the clock was advanced by.
+func (c *Clock) Now() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.clock.Now().Add(c.offset)
+}
+
+// Since returns the time elapsed since t.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Step advances the clock by the given duration.
+func (c *Clock) Step(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.offset += d
+}
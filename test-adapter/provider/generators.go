@@ -0,0 +1,245 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GeneratorType is the waveform of the values of a generated metric.
+type GeneratorType string
+
+const (
+	// ConstantGenerator generates Value.
+	ConstantGenerator GeneratorType = "constant"
+	// RampGenerator generates values going linearly from From to To over
+	// Duration, then To.
+	RampGenerator GeneratorType = "ramp"
+	// SineGenerator generates values oscillating around Value by
+	// Amplitude, every Period.
+	SineGenerator GeneratorType = "sine"
+	// StepGenerator generates From, then To once Duration elapsed.
+	StepGenerator GeneratorType = "step"
+	// RandomWalkGenerator generates values starting at Value, and changing
+	// every Interval by a random amount of at most Step, within Min and Max.
+	RandomWalkGenerator GeneratorType = "randomWalk"
+	// ReplayGenerator generates the values of the CSV series, repeating it
+	// every Period if set.
+	ReplayGenerator GeneratorType = "replay"
+)
+
+// Generator describes the values of a generated metric, computed from the
+// time elapsed since the generator was written.
+type Generator struct {
+	Type GeneratorType `json:"type"`
+
+	Value     float64         `json:"value,omitempty"`
+	From      float64         `json:"from,omitempty"`
+	To        float64         `json:"to,omitempty"`
+	Duration  metav1.Duration `json:"duration,omitzero"`
+	Amplitude float64         `json:"amplitude,omitempty"`
+	Period    metav1.Duration `json:"period,omitzero"`
+
+	Step     float64         `json:"step,omitempty"`
+	Interval metav1.Duration `json:"interval,omitzero"`
+	Min      *float64        `json:"min,omitempty"`
+	Max      *float64        `json:"max,omitempty"`
+	Seed     uint64          `json:"seed,omitempty"`
+
+	// CSV is the replayed series, with a point per line made of the offset
+	// of the point, either in seconds or as a duration, and its value.
+	CSV string `json:"csv,omitempty"`
+}
+
+// replayPoint is a point of a replayed series.
+type replayPoint struct {
+	offset time.Duration
+	value  float64
+}
+
+// generator computes the values of a generated metric.
+type generator struct {
+	spec  Generator
+	start time.Time
+
+	series []replayPoint
+
+	// walkLock guards the state of random walks: the number of steps last
+	// queried and the value they reached, from which the walk advances on
+	// the next query.
+	walkLock  sync.Mutex
+	walkSteps int64
+	walkValue float64
+	walkRand  *rand.Rand
+}
+
+// newGenerator validates a generator, starting at the given time.
+func newGenerator(spec Generator, start time.Time) (*generator, error) {
+	g := &generator{spec: spec, start: start}
+	switch spec.Type {
+	case ConstantGenerator, StepGenerator:
+	case RampGenerator:
+		if spec.Duration.Duration <= 0 {
+			return nil, errors.New("ramp generators require a positive duration")
+		}
+	case SineGenerator:
+		if spec.Period.Duration <= 0 {
+			return nil, errors.New("sine generators require a positive period")
+		}
+	case RandomWalkGenerator:
+		if spec.Interval.Duration <= 0 {
+			return nil, errors.New("random walk generators require a positive interval")
+		}
+		if spec.Min != nil && spec.Max != nil && *spec.Min > *spec.Max {
+			return nil, errors.New("the minimum of random walk generators can't exceed their maximum")
+		}
+		g.resetWalk()
+	case ReplayGenerator:
+		series, err := parseSeries(spec.CSV)
+		if err != nil {
+			return nil, err
+		}
+		g.series = series
+	default:
+		return nil, fmt.Errorf("unknown generator type %q", spec.Type)
+	}
+	return g, nil
+}
+
+// parseSeries parses the CSV series of a replay generator.
+func parseSeries(data string) ([]replayPoint, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var series []replayPoint
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid series: %v", err)
+		}
+		offset, err := parseOffset(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset %q: %v", record[0], err)
+		}
+		if len(series) > 0 && offset < series[len(series)-1].offset {
+			return nil, fmt.Errorf("offset %q precedes the previous one", record[0])
+		}
+		value, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %v", record[1], err)
+		}
+		series = append(series, replayPoint{offset: offset, value: value})
+	}
+	if len(series) == 0 {
+		return nil, errors.New("replay generators require a series of at least one point")
+	}
+	return series, nil
+}
+
+func parseOffset(offset string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(offset, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(offset)
+}
+
+// valueAt computes the value of the generator at the given time.
+func (g *generator) valueAt(now time.Time) resource.Quantity {
+	elapsed := max(now.Sub(g.start), 0)
+	var value float64
+	switch g.spec.Type {
+	case ConstantGenerator:
+		value = g.spec.Value
+	case RampGenerator:
+		progress := min(float64(elapsed)/float64(g.spec.Duration.Duration), 1)
+		value = g.spec.From + (g.spec.To-g.spec.From)*progress
+	case SineGenerator:
+		value = g.spec.Value + g.spec.Amplitude*math.Sin(2*math.Pi*float64(elapsed)/float64(g.spec.Period.Duration))
+	case StepGenerator:
+		value = g.spec.From
+		if elapsed >= g.spec.Duration.Duration {
+			value = g.spec.To
+		}
+	case RandomWalkGenerator:
+		value = g.walk(int64(elapsed / g.spec.Interval.Duration))
+	case ReplayGenerator:
+		value = g.replay(elapsed)
+	}
+	return *resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+}
+
+func (g *generator) resetWalk() {
+	g.walkSteps = 0
+	g.walkValue = g.spec.Value
+	g.walkRand = rand.New(rand.NewPCG(g.spec.Seed, g.spec.Seed))
+}
+
+// walk returns the value of a random walk after the given number of steps.
+// Walks advance from the steps last queried, so that a query only costs the
+// steps since the previous one, and restart when queried for earlier steps,
+// so that a given seed always yields the same values.
+func (g *generator) walk(steps int64) float64 {
+	g.walkLock.Lock()
+	defer g.walkLock.Unlock()
+
+	if steps < g.walkSteps {
+		g.resetWalk()
+	}
+	for ; g.walkSteps < steps; g.walkSteps++ {
+		g.walkValue += (2*g.walkRand.Float64() - 1) * g.spec.Step
+		if g.spec.Min != nil {
+			g.walkValue = max(g.walkValue, *g.spec.Min)
+		}
+		if g.spec.Max != nil {
+			g.walkValue = min(g.walkValue, *g.spec.Max)
+		}
+	}
+	return g.walkValue
+}
+
+// replay returns the value of the last point of the series at the given
+// offset, or the first one before the series starts.
+func (g *generator) replay(elapsed time.Duration) float64 {
+	if g.spec.Period.Duration > 0 {
+		elapsed %= g.spec.Period.Duration
+	}
+	value := g.series[0].value
+	for _, point := range g.series {
+		if point.offset > elapsed {
+			break
+		}
+		value = point.value
+	}
+	return value
+}
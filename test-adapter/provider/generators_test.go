@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestGenerators(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		spec     Generator
+		expected map[time.Duration]string
+	}{
+		"constant": {
+			spec:     Generator{Type: ConstantGenerator, Value: 2.5},
+			expected: map[time.Duration]string{0: "2500m", time.Hour: "2500m"},
+		},
+		"ramp": {
+			spec:     Generator{Type: RampGenerator, From: 10, To: 20, Duration: metav1.Duration{Duration: 10 * time.Minute}},
+			expected: map[time.Duration]string{-time.Minute: "10", 0: "10", 5 * time.Minute: "15", 10 * time.Minute: "20", time.Hour: "20"},
+		},
+		"sine": {
+			spec:     Generator{Type: SineGenerator, Value: 100, Amplitude: 50, Period: metav1.Duration{Duration: 4 * time.Minute}},
+			expected: map[time.Duration]string{0: "100", time.Minute: "150", 2 * time.Minute: "100", 3 * time.Minute: "50", 5 * time.Minute: "150"},
+		},
+		"step": {
+			spec:     Generator{Type: StepGenerator, From: 1, To: 8, Duration: metav1.Duration{Duration: time.Minute}},
+			expected: map[time.Duration]string{0: "1", 59 * time.Second: "1", time.Minute: "8"},
+		},
+		"random walk within bounds": {
+			spec:     Generator{Type: RandomWalkGenerator, Value: 5, Step: 100, Min: ptr.To(0.0), Max: ptr.To(10.0), Interval: metav1.Duration{Duration: time.Second}},
+			expected: map[time.Duration]string{0: "5", 30 * time.Second: "0"},
+		},
+		"replay": {
+			spec: Generator{Type: ReplayGenerator, CSV: "# offset,value\n0,1\n30s,2\n60,3.5\n"},
+			expected: map[time.Duration]string{
+				0: "1", 29 * time.Second: "1", 30 * time.Second: "2", time.Minute: "3500m", time.Hour: "3500m",
+			},
+		},
+		"looping replay": {
+			spec:     Generator{Type: ReplayGenerator, CSV: "0,1\n30s,2\n", Period: metav1.Duration{Duration: time.Minute}},
+			expected: map[time.Duration]string{45 * time.Second: "2", 75 * time.Second: "1", 90 * time.Second: "2"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gen, err := newGenerator(tc.spec, start)
+			require.NoError(t, err)
+			for offset, expected := range tc.expected {
+				value, expectedValue := gen.valueAt(start.Add(offset)), resource.MustParse(expected)
+				assert.Zero(t, expectedValue.Cmp(value), "at %v: expected %s, got %s", offset, expected, value.String())
+			}
+		})
+	}
+}
+
+func TestRandomWalkIsDeterministic(t *testing.T) {
+	start := time.Now()
+	spec := Generator{Type: RandomWalkGenerator, Value: 50, Step: 5, Seed: 42, Interval: metav1.Duration{Duration: time.Second}}
+
+	walk := func(gen *generator, offsets ...time.Duration) []string {
+		var values []string
+		for _, offset := range offsets {
+			value := gen.valueAt(start.Add(offset))
+			values = append(values, value.String())
+		}
+		return values
+	}
+	gen, err := newGenerator(spec, start)
+	require.NoError(t, err)
+	other, err := newGenerator(spec, start)
+	require.NoError(t, err)
+
+	values := walk(gen, time.Second, time.Minute, 10*time.Second)
+	assert.Equal(t, values, walk(other, time.Second, time.Minute, 10*time.Second))
+	assert.NotEqual(t, values[0], values[1])
+	// walks restart for earlier times
+	assert.Equal(t, walk(gen, 10*time.Second), walk(other, 10*time.Second))
+
+	spec.Seed = 43
+	reseeded, err := newGenerator(spec, start)
+	require.NoError(t, err)
+	assert.NotEqual(t, values, walk(reseeded, time.Second, time.Minute, 10*time.Second))
+
+	// walks advance from the last query, and keep changing
+	far := walk(gen, 200000*time.Second, 200100*time.Second)
+	fresh, err := newGenerator(gen.spec, start)
+	require.NoError(t, err)
+	assert.Equal(t, far[1], walk(fresh, 200100*time.Second)[0])
+	assert.NotEqual(t, far[0], far[1])
+}
+
+func TestInvalidGenerators(t *testing.T) {
+	cases := map[string]Generator{
+		"unknown type":           {Type: "square"},
+		"ramp without duration":  {Type: RampGenerator, To: 1},
+		"sine without period":    {Type: SineGenerator, Amplitude: 1},
+		"walk without interval":  {Type: RandomWalkGenerator, Step: 1},
+		"walk with empty bounds": {Type: RandomWalkGenerator, Step: 1, Interval: metav1.Duration{Duration: time.Second}, Min: ptr.To(2.0), Max: ptr.To(1.0)},
+		"empty replay":           {Type: ReplayGenerator},
+		"unordered replay":       {Type: ReplayGenerator, CSV: "10,1\n5,2\n"},
+		"invalid replay offset":  {Type: ReplayGenerator, CSV: "soon,1\n"},
+		"invalid replay value":   {Type: ReplayGenerator, CSV: "0,high\n"},
+		"invalid replay columns": {Type: ReplayGenerator, CSV: "0,1,2\n"},
+	}
+
+	for name, spec := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newGenerator(spec, time.Now())
+			assert.Error(t, err)
+		})
+	}
+}
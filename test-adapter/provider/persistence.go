@@ -0,0 +1,173 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// LoadFixtures writes the metrics of a YAML or JSON file, in the format of
+// Store, to a provider returned by NewFakeProvider.  The TTLs of the metrics
+// start when they are loaded.
+func LoadFixtures(prov provider.MetricsProvider, path string) error {
+	p, err := testingProviderFor(prov)
+	if err != nil {
+		return err
+	}
+	store, err := readStore(path)
+	if err != nil {
+		return err
+	}
+	return p.restore(store, false)
+}
+
+// Snapshotter saves the metrics of a provider returned by NewFakeProvider to
+// a file, and restores them from it, so that they outlive the adapter.
+type Snapshotter struct {
+	provider *testingProvider
+	path     string
+	interval time.Duration
+}
+
+// NewSnapshotter returns a Snapshotter saving the metrics of the provider to
+// the file at path every interval, if positive, and when it stops.
+func NewSnapshotter(prov provider.MetricsProvider, path string, interval time.Duration) (*Snapshotter, error) {
+	p, err := testingProviderFor(prov)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshotter{provider: p, path: path, interval: interval}, nil
+}
+
+// Restore replaces the metrics of the provider with the ones of the
+// snapshot, if there is one.
+func (s *Snapshotter) Restore() error {
+	store, err := readStore(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.provider.restore(store, true)
+}
+
+// Save snapshots the metrics of the provider.  The snapshot is replaced
+// atomically, so that a failed save doesn't lose the previous one.
+func (s *Snapshotter) Save() error {
+	data, err := yaml.Marshal(s.provider.dump())
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+"-")
+	if err != nil {
+		return fmt.Errorf("unable to save the snapshot: %v", err)
+	}
+	// the temporary file is gone once renamed
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("unable to save the snapshot: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to save the snapshot: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("unable to save the snapshot: %v", err)
+	}
+	return nil
+}
+
+// Start saves the metrics every interval until the context is done, and
+// once more then.
+func (s *Snapshotter) Start(ctx context.Context) error {
+	if s.interval > 0 {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for done := false; !done; {
+			select {
+			case <-ctx.Done():
+				done = true
+			case <-ticker.C:
+				if err := s.Save(); err != nil {
+					klog.ErrorS(err, "Unable to snapshot the metrics", "path", s.path)
+				}
+			}
+		}
+	} else {
+		<-ctx.Done()
+	}
+	return s.Save()
+}
+
+func testingProviderFor(prov provider.MetricsProvider) (*testingProvider, error) {
+	p, ok := prov.(*testingProvider)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a provider of the test adapter", prov)
+	}
+	return p, nil
+}
+
+func readStore(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	store := &Store{}
+	if err := yaml.UnmarshalStrict(data, store); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %v", path, err)
+	}
+	return store, nil
+}
+
+// restore stores the metrics of a store, keeping their timestamps, the start
+// times of their generators and their expiration times, if set.
+func (p *testingProvider) restore(store *Store, replace bool) error {
+	now := p.clock.Now()
+	entries := make([]entry, 0, len(store.Metrics))
+	for i, metric := range store.Metrics {
+		e, err := p.entryFor(metric.MetricWrite, now)
+		if err != nil {
+			return fmt.Errorf("invalid metric %d: %v", i, err)
+		}
+		if e.value.generator != nil && metric.StartTime != nil {
+			e.value.generator.start = metric.StartTime.Time
+		}
+		if e.value.generator == nil && !metric.Timestamp.IsZero() {
+			e.value.timestamp = metric.Timestamp
+		}
+		if metric.ExpirationTime != nil {
+			e.value.expires = metric.ExpirationTime.Time
+		}
+		if !e.value.expired(now) {
+			entries = append(entries, e)
+		}
+	}
+	p.storeEntries(now, replace, entries)
+	return nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.yaml")
+	fakeClock := clocktesting.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	prov, handler := newTestProvider(t)
+	prov.clock = fakeClock
+	batch := `{"metrics": [
+		{"resource": "services", "namespace": "default", "name": "kubernetes", "metric": "requests", "labels": {"verb": "get"}, "value": "300m"},
+		{"resource": "nodes", "name": "node-1", "metric": "temperature", "generator": {"type": "ramp", "from": 0, "to": 60, "duration": "1m"}},
+		{"namespace": "default", "metric": "queue_length", "value": "12", "ttl": "1m"},
+		{"namespace": "default", "metric": "lag", "value": "1", "ttl": "10m"}
+	]}`
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/batch", batch))
+	snapshotter, err := NewSnapshotter(prov, path, 0)
+	require.NoError(t, err)
+	fakeClock.Step(20 * time.Second)
+	require.NoError(t, snapshotter.Save())
+	expected := dumpStore(t, handler)
+
+	// restore in a provider holding other metrics, which are replaced
+	restored, handler := newTestProvider(t)
+	restored.clock = fakeClock
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/other", `"1"`))
+	snapshotter, err = NewSnapshotter(restored, path, 0)
+	require.NoError(t, err)
+	require.NoError(t, snapshotter.Restore())
+	assert.Equal(t, expected, dumpStore(t, handler))
+
+	// generators and expiration times carry on
+	fakeClock.Step(40 * time.Second)
+	value, err := restored.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, nodesTemperature, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, "60", value.Value.String())
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "lag"}}, restored.ListAllExternalMetrics())
+	snapshot := dumpStore(t, handler)
+	require.Len(t, snapshot.Metrics, 3)
+	assert.Equal(t, expected.Metrics[3], snapshot.Metrics[2], "the timestamps of the values should be kept")
+
+	// expired metrics aren't restored
+	fakeClock.Step(10 * time.Minute)
+	require.NoError(t, snapshotter.Restore())
+	assert.Empty(t, restored.ListAllExternalMetrics())
+	assert.Len(t, restored.ListAllMetrics(), 2)
+}
+
+func TestRestoreWithoutSnapshot(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag", `"1"`))
+
+	snapshotter, err := NewSnapshotter(prov, filepath.Join(t.TempDir(), "snapshot.yaml"), 0)
+	require.NoError(t, err)
+	require.NoError(t, snapshotter.Restore())
+	assert.Len(t, prov.ListAllExternalMetrics(), 1)
+
+	_, err = NewSnapshotter(nil, "snapshot.yaml", 0)
+	assert.Error(t, err)
+}
+
+func TestSnapshotterStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.yaml")
+	prov, handler := newTestProvider(t)
+	snapshotter, err := NewSnapshotter(prov, path, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- snapshotter.Start(ctx)
+	}()
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag", `"1"`))
+	assert.Eventually(t, func() bool {
+		store, err := readStore(path)
+		return err == nil && len(store.Metrics) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/queue_length", `"1"`))
+	cancel()
+	require.NoError(t, <-done)
+	store, err := readStore(path)
+	require.NoError(t, err)
+	assert.Len(t, store.Metrics, 2, "the metrics should be saved on shutdown")
+}
+
+func TestLoadFixtures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	fixtures := `metrics:
+- resource: services
+  namespace: default
+  name: kubernetes
+  metric: requests
+  value: 300m
+- resource: nodes
+  name: node-1
+  metric: temperature
+  generator:
+    type: sine
+    value: 40
+    amplitude: 10
+    period: 10m
+- namespace: default
+  metric: queue_length
+  labels:
+    queue: orders
+  value: "12"
+  ttl: 1m
+`
+	require.NoError(t, os.WriteFile(path, []byte(fixtures), 0o600))
+
+	prov, handler := newTestProvider(t)
+	fakeClock := clocktesting.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	prov.clock = fakeClock
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag", `"1"`))
+	require.NoError(t, LoadFixtures(prov, path))
+
+	assert.ElementsMatch(t, []provider.CustomMetricInfo{servicesRequests, nodesTemperature}, prov.ListAllMetrics())
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "lag"}, {Metric: "queue_length"}}, prov.ListAllExternalMetrics())
+	fakeClock.Step(150 * time.Second)
+	value, err := prov.GetMetricByName(context.Background(), types.NamespacedName{Name: "node-1"}, nodesTemperature, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, "50", value.Value.String())
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "lag"}}, prov.ListAllExternalMetrics())
+
+	require.NoError(t, os.WriteFile(path, []byte("metrics:\n- metric: lag\n  value: 1\n  unknown: true\n"), 0o600))
+	assert.Error(t, LoadFixtures(prov, path))
+	require.NoError(t, os.WriteFile(path, []byte("metrics:\n- resource: nodes\n  metric: temperature\n  value: 1\n"), 0o600))
+	assert.Error(t, LoadFixtures(prov, path))
+}
@@ -18,8 +18,9 @@ package provider
 
 import (
 	"context"
-	"net/http"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
@@ -27,15 +28,14 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/klog/v2"
 	"k8s.io/metrics/pkg/apis/custom_metrics"
 	"k8s.io/metrics/pkg/apis/external_metrics"
+	"k8s.io/utils/clock"
 
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
-	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/defaults"
 	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider/helpers"
 )
 
@@ -46,169 +46,120 @@ type CustomMetricResource struct {
 	types.NamespacedName
 }
 
-// externalMetric provides examples for metrics which would otherwise be reported from an external source
-// TODO (damemi): add dynamic external metrics instead of just hardcoded examples
+// externalMetric identifies the series of an external metric, whose labels
+// are stored in their string form to be comparable.
 type externalMetric struct {
-	info   provider.ExternalMetricInfo
-	labels map[string]string
-	value  external_metrics.ExternalMetricValue
+	namespace string
+	name      string
+	labels    string
 }
 
-var (
-	testingExternalMetrics = []externalMetric{
-		{
-			info: provider.ExternalMetricInfo{
-				Metric: "my-external-metric",
-			},
-			labels: map[string]string{"foo": "bar"},
-			value: external_metrics.ExternalMetricValue{
-				MetricName: "my-external-metric",
-				MetricLabels: map[string]string{
-					"foo": "bar",
-				},
-				Value: *resource.NewQuantity(42, resource.DecimalSI),
-			},
-		},
-		{
-			info: provider.ExternalMetricInfo{
-				Metric: "my-external-metric",
-			},
-			labels: map[string]string{"foo": "baz"},
-			value: external_metrics.ExternalMetricValue{
-				MetricName: "my-external-metric",
-				MetricLabels: map[string]string{
-					"foo": "baz",
-				},
-				Value: *resource.NewQuantity(43, resource.DecimalSI),
-			},
-		},
-		{
-			info: provider.ExternalMetricInfo{
-				Metric: "other-external-metric",
-			},
-			labels: map[string]string{},
-			value: external_metrics.ExternalMetricValue{
-				MetricName:   "other-external-metric",
-				MetricLabels: map[string]string{},
-				Value:        *resource.NewQuantity(44, resource.DecimalSI),
-			},
-		},
-	}
-)
-
 type metricValue struct {
 	labels    labels.Set
 	value     resource.Quantity
 	timestamp metav1.Time
+	// generator computes the value at query time, if set
+	generator *generator
+	// expires is the time the value expires at, if set
+	expires time.Time
+}
+
+// expired returns whether the value expired by the given time
+func (v metricValue) expired(now time.Time) bool {
+	return !v.expires.IsZero() && !now.Before(v.expires)
 }
 
-var _ provider.MetricsProvider = &testingProvider{}
+var (
+	_ provider.MetricsProvider        = &testingProvider{}
+	_ provider.MetricsChangeNotifier  = &testingProvider{}
+	_ provider.MetricMetadataProvider = &testingProvider{}
+)
 
 // testingProvider is a sample implementation of provider.MetricsProvider which stores a map of fake metrics
 type testingProvider struct {
-	defaults.DefaultCustomMetricsProvider
-	defaults.DefaultExternalMetricsProvider
 	client dynamic.Interface
 	mapper apimeta.RESTMapper
+	// clock is the time of the written values and of the generators
+	clock clock.PassiveClock
+
+	valuesLock     sync.RWMutex
+	values         map[CustomMetricResource]metricValue
+	externalValues map[externalMetric]metricValue
+	// customMetadata and externalMetadata describe the metrics written with
+	// metadata
+	customMetadata   map[provider.CustomMetricInfo]provider.MetricMetadata
+	externalMetadata map[string]provider.MetricMetadata
+
+	// listed are the metrics listed when the listeners were last notified, guarded by the values lock
+	listed listedMetrics
+
+	// listeners are notified when the listed metrics change
+	listenersLock sync.Mutex
+	listeners     []func()
+}
+
+// listedMetrics are the metrics listed by the provider
+type listedMetrics struct {
+	custom   sets.Set[provider.CustomMetricInfo]
+	external sets.Set[string]
+}
 
-	valuesLock      sync.RWMutex
-	values          map[CustomMetricResource]metricValue
-	externalMetrics []externalMetric
+func (l listedMetrics) equal(other listedMetrics) bool {
+	return l.custom.Equal(other.custom) && l.external.Equal(other.external)
 }
 
 // NewFakeProvider returns an instance of testingProvider, along with its restful.WebService that opens endpoints to post new fake metrics
+// Its clock runs along with the wall clock, and can be advanced through the web service.
 func NewFakeProvider(client dynamic.Interface, mapper apimeta.RESTMapper) (provider.MetricsProvider, *restful.WebService) {
+	return NewFakeProviderWithClock(client, mapper, NewClock(clock.RealClock{}))
+}
+
+// NewFakeProviderWithClock is like NewFakeProvider, with the given clock timing the written values and the generators.
+// The clock can be advanced through the web service if it has a Step method, like Clock and the fake clocks of k8s.io/utils/clock/testing.
+func NewFakeProviderWithClock(client dynamic.Interface, mapper apimeta.RESTMapper, clock clock.PassiveClock) (provider.MetricsProvider, *restful.WebService) {
 	provider := &testingProvider{
-		client:          client,
-		mapper:          mapper,
-		values:          make(map[CustomMetricResource]metricValue),
-		externalMetrics: testingExternalMetrics,
+		client:           client,
+		mapper:           mapper,
+		clock:            clock,
+		values:           make(map[CustomMetricResource]metricValue),
+		externalValues:   make(map[externalMetric]metricValue),
+		customMetadata:   make(map[provider.CustomMetricInfo]provider.MetricMetadata),
+		externalMetadata: make(map[string]provider.MetricMetadata),
 	}
 	return provider, provider.webService()
 }
 
-// webService creates a restful.WebService with routes set up for receiving fake metrics
-// These writing routes have been set up to be identical to the format of routes which metrics are read from.
-// There are 3 metric types available: namespaced, root-scoped, and namespaces.
-// (Note: Namespaces, we're assuming, are themselves namespaced resources, but for consistency with how metrics are retreived they have a separate route)
-func (p *testingProvider) webService() *restful.WebService {
-	ws := new(restful.WebService)
-
-	ws.Path("/write-metrics")
-
-	// Namespaced resources
-	ws.Route(ws.POST("/namespaces/{namespace}/{resourceType}/{name}/{metric}").To(p.updateMetric).
-		Param(ws.BodyParameter("value", "value to set metric").DataType("integer").DefaultValue("0")))
-
-	// Root-scoped resources
-	ws.Route(ws.POST("/{resourceType}/{name}/{metric}").To(p.updateMetric).
-		Param(ws.BodyParameter("value", "value to set metric").DataType("integer").DefaultValue("0")))
-
-	// Namespaces, where {resourceType} == "namespaces" to match API
-	ws.Route(ws.POST("/{resourceType}/{name}/metrics/{metric}").To(p.updateMetric).
-		Param(ws.BodyParameter("value", "value to set metric").DataType("integer").DefaultValue("0")))
-	return ws
+// OnMetricsChange registers notify to be called when the listed metrics, or their metadata, change
+func (p *testingProvider) OnMetricsChange(notify func()) {
+	p.listenersLock.Lock()
+	defer p.listenersLock.Unlock()
+	p.listeners = append(p.listeners, notify)
 }
 
-// updateMetric writes the metric provided by a restful request and stores it in memory
-func (p *testingProvider) updateMetric(request *restful.Request, response *restful.Response) {
-	p.valuesLock.Lock()
-	defer p.valuesLock.Unlock()
-
-	namespace := request.PathParameter("namespace")
-	resourceType := request.PathParameter("resourceType")
-	namespaced := len(namespace) > 0 || resourceType == "namespaces"
-
-	name := request.PathParameter("name")
-	metricName := request.PathParameter("metric")
-
-	value := new(resource.Quantity)
-	err := request.ReadEntity(value)
-	if err != nil {
-		if err := response.WriteErrorString(http.StatusBadRequest, err.Error()); err != nil {
-			klog.Errorf("Error writing error: %s", err)
-		}
+// metricsChanged notifies the listeners if the metrics listed as of now changed since they were last notified, or if
+// their metadata changed, with the values lock held
+func (p *testingProvider) metricsChanged(now time.Time, metadataChanged bool) {
+	listed := p.listedLocked(now)
+	if listed.equal(p.listed) && !metadataChanged {
 		return
 	}
+	p.listed = listed
 
-	groupResource := schema.ParseGroupResource(resourceType)
-
-	metricLabels := labels.Set{}
-	sel := request.QueryParameter("labels")
-	if len(sel) > 0 {
-		metricLabels, err = labels.ConvertSelectorToLabelsMap(sel)
-		if err != nil {
-			if err := response.WriteErrorString(http.StatusBadRequest, err.Error()); err != nil {
-				klog.Errorf("Error writing error: %s", err)
-			}
-			return
-		}
-	}
-
-	info := provider.CustomMetricInfo{
-		GroupResource: groupResource,
-		Metric:        metricName,
-		Namespaced:    namespaced,
+	p.listenersLock.Lock()
+	defer p.listenersLock.Unlock()
+	for _, notify := range p.listeners {
+		notify()
 	}
+}
 
-	info, _, err = info.Normalized(p.mapper)
-	if err != nil {
-		klog.Errorf("Error normalizing info: %s", err)
-	}
-	namespacedName := types.NamespacedName{
-		Name:      name,
-		Namespace: namespace,
-	}
-
-	metricInfo := CustomMetricResource{
-		CustomMetricInfo: info,
-		NamespacedName:   namespacedName,
-	}
-	p.values[metricInfo] = metricValue{
-		labels:    metricLabels,
-		value:     *value,
-		timestamp: metav1.Now(),
+// current returns a value as of now, computing it if it is generated
+func (p *testingProvider) current(value metricValue) metricValue {
+	if value.generator != nil {
+		now := p.clock.Now()
+		value.value = value.generator.valueAt(now)
+		value.timestamp = metav1.NewTime(now)
 	}
+	return value
 }
 
 // valueFor is a helper function to get just the value of a specific metric
@@ -223,7 +174,7 @@ func (p *testingProvider) valueFor(info provider.CustomMetricInfo, name types.Na
 	}
 
 	value, found := p.values[metricInfo]
-	if !found {
+	if !found || value.expired(p.clock.Now()) {
 		return metricValue{}, provider.NewMetricNotFoundForError(info.GroupResource, info.Metric, name.Name)
 	}
 
@@ -231,7 +182,7 @@ func (p *testingProvider) valueFor(info provider.CustomMetricInfo, name types.Na
 		return metricValue{}, provider.NewMetricNotFoundForSelectorError(info.GroupResource, info.Metric, name.Name, metricSelector)
 	}
 
-	return value, nil
+	return p.current(value), nil
 }
 
 // metricFor is a helper function which formats a value, metric, and object info into a MetricValue which can be returned by the metrics API
@@ -309,20 +260,85 @@ func (p *testingProvider) GetMetricBySelector(_ context.Context, namespace strin
 	return p.metricsFor(namespace, selector, info, metricSelector)
 }
 
-func (p *testingProvider) GetExternalMetric(_ context.Context, _ string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+func (p *testingProvider) ListAllMetrics() []provider.CustomMetricInfo {
+	p.valuesLock.RLock()
+	defer p.valuesLock.RUnlock()
+
+	return p.listedLocked(p.clock.Now()).custom.UnsortedList()
+}
+
+// CustomMetricMetadata returns the metadata written with a custom metric
+func (p *testingProvider) CustomMetricMetadata(info provider.CustomMetricInfo) *provider.MetricMetadata {
 	p.valuesLock.RLock()
 	defer p.valuesLock.RUnlock()
 
+	return metadataOf(p.customMetadata, info)
+}
+
+// ExternalMetricMetadata returns the metadata written with an external metric
+func (p *testingProvider) ExternalMetricMetadata(info provider.ExternalMetricInfo) *provider.MetricMetadata {
+	p.valuesLock.RLock()
+	defer p.valuesLock.RUnlock()
+
+	return metadataOf(p.externalMetadata, info.Metric)
+}
+
+// metadataOf returns a copy of the metadata of a metric, or nil if it has none
+func metadataOf[K comparable](metadata map[K]provider.MetricMetadata, key K) *provider.MetricMetadata {
+	m, found := metadata[key]
+	if !found {
+		return nil
+	}
+	m.SelectableLabels = slices.Clone(m.SelectableLabels)
+	return &m
+}
+
+func (p *testingProvider) GetExternalMetric(_ context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	p.valuesLock.RLock()
+	defer p.valuesLock.RUnlock()
+
+	now := p.clock.Now()
 	matchingMetrics := []external_metrics.ExternalMetricValue{}
-	for _, metric := range p.externalMetrics {
-		if metric.info.Metric == info.Metric &&
-			metricSelector.Matches(labels.Set(metric.labels)) {
-			metricValue := metric.value
-			metricValue.Timestamp = metav1.Now()
-			matchingMetrics = append(matchingMetrics, metricValue)
+	for key, value := range p.externalValues {
+		if key.namespace == namespace && key.name == info.Metric && metricSelector.Matches(value.labels) && !value.expired(now) {
+			value := p.current(value)
+			matchingMetrics = append(matchingMetrics, external_metrics.ExternalMetricValue{
+				MetricName:   info.Metric,
+				MetricLabels: value.labels,
+				Timestamp:    value.timestamp,
+				Value:        value.value,
+			})
 		}
 	}
 	return &external_metrics.ExternalMetricValueList{
 		Items: matchingMetrics,
 	}, nil
 }
+
+func (p *testingProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	p.valuesLock.RLock()
+	defer p.valuesLock.RUnlock()
+
+	names := p.listedLocked(p.clock.Now()).external
+	infos := make([]provider.ExternalMetricInfo, 0, names.Len())
+	for _, name := range sets.List(names) {
+		infos = append(infos, provider.ExternalMetricInfo{Metric: name})
+	}
+	return infos
+}
+
+// listedLocked returns the metrics listed as of now, with the values lock held
+func (p *testingProvider) listedLocked(now time.Time) listedMetrics {
+	listed := listedMetrics{custom: sets.New[provider.CustomMetricInfo](), external: sets.New[string]()}
+	for metricInfo, value := range p.values {
+		if !value.expired(now) {
+			listed.custom.Insert(metricInfo.CustomMetricInfo)
+		}
+	}
+	for key, value := range p.externalValues {
+		if !value.expired(now) {
+			listed.external.Insert(key.name)
+		}
+	}
+	return listed
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+func newTestProvider(t *testing.T) (*testingProvider, http.Handler) {
+	t.Helper()
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), apimeta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Node"), apimeta.RESTScopeRoot)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), apimeta.RESTScopeRoot)
+
+	prov, ws := NewFakeProvider(nil, mapper)
+	// route as the generic API server does
+	container := restful.NewContainer()
+	container.Router(restful.CurlyRouter{})
+	container.Add(ws)
+	return prov.(*testingProvider), container
+}
+
+// write sends a request to the write service, and returns the status of its
+// response.
+func write(t *testing.T, handler http.Handler, method, path, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestExternalMetricsWrites(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	ctx := context.Background()
+	queueLength := provider.ExternalMetricInfo{Metric: "queue_length"}
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/queue_length?labels=queue%3Dorders", `"12"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/queue_length?labels=queue%3Dpayments", `"30"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/other/queue_length", `"7"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag", `"1"`))
+	// overwrite the value of a series
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/queue_length?labels=queue%3Dorders", `"15"`))
+
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "lag"}, {Metric: "queue_length"}}, prov.ListAllExternalMetrics())
+
+	list, err := prov.GetExternalMetric(ctx, "default", labels.SelectorFromSet(labels.Set{"queue": "orders"}), queueLength)
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "queue_length", list.Items[0].MetricName)
+	assert.Equal(t, map[string]string{"queue": "orders"}, list.Items[0].MetricLabels)
+	assert.Equal(t, resource.MustParse("15"), list.Items[0].Value)
+
+	list, err = prov.GetExternalMetric(ctx, "default", labels.Everything(), queueLength)
+	require.NoError(t, err)
+	assert.Len(t, list.Items, 2)
+
+	list, err = prov.GetExternalMetric(ctx, "other", labels.Everything(), queueLength)
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, resource.MustParse("7"), list.Items[0].Value)
+
+	// delete a single series, then all of the remaining ones
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/external/default/queue_length?labels=queue%3Dorders", ""))
+	list, err = prov.GetExternalMetric(ctx, "default", labels.Everything(), queueLength)
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, map[string]string{"queue": "payments"}, list.Items[0].MetricLabels)
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/external/default/queue_length", ""))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/external/other/queue_length", ""))
+	assert.Equal(t, http.StatusNotFound, write(t, handler, http.MethodDelete, "/write-metrics/external/other/queue_length", ""))
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "lag"}}, prov.ListAllExternalMetrics())
+
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag?labels=queue%21%3Dorders", `"1"`))
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag", `"not a quantity"`))
+}
+
+func TestListAllMetrics(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	assert.Empty(t, prov.ListAllMetrics())
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/namespaces/default/services/kubernetes/requests", `"300m"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/namespaces/other/services/kubernetes/requests", `"1"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature", `"40"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/namespaces/default/metrics/jobs", `"3"`))
+
+	assert.ElementsMatch(t, []provider.CustomMetricInfo{
+		{GroupResource: schema.GroupResource{Resource: "services"}, Namespaced: true, Metric: "requests"},
+		{GroupResource: schema.GroupResource{Resource: "nodes"}, Metric: "temperature"},
+		{GroupResource: schema.GroupResource{Resource: "namespaces"}, Namespaced: true, Metric: "jobs"},
+	}, prov.ListAllMetrics())
+
+	value, err := prov.GetMetricByName(context.Background(), types.NamespacedName{Name: "node-1"}, provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "nodes"}, Metric: "temperature"}, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, resource.MustParse("40"), value.Value)
+}
+
+func TestGeneratorWrites(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	fakeClock := clocktesting.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	prov.clock = fakeClock
+	ctx := context.Background()
+	ramp := `{"type": "ramp", "from": 0, "to": 100, "duration": "10m"}`
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/namespaces/default/services/kubernetes/requests/generator", ramp))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature/generator", `{"type": "step", "from": 40, "to": 80, "duration": "1m"}`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/namespaces/default/metrics/jobs/generator", `{"type": "constant", "value": 3}`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/queue_length/generator?labels=queue%3Dorders", ramp))
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature/generator", `{"type": "square"}`))
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature/generator", `"40"`))
+
+	services := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "services"}, Namespaced: true, Metric: "requests"}
+	nodes := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "nodes"}, Metric: "temperature"}
+	namespaces := provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "namespaces"}, Namespaced: true, Metric: "jobs"}
+	assert.ElementsMatch(t, []provider.CustomMetricInfo{services, nodes, namespaces}, prov.ListAllMetrics())
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_length"}}, prov.ListAllExternalMetrics())
+
+	values := func() []string {
+		service, err := prov.GetMetricByName(ctx, types.NamespacedName{Namespace: "default", Name: "kubernetes"}, services, labels.Everything())
+		require.NoError(t, err)
+		node, err := prov.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, nodes, labels.Everything())
+		require.NoError(t, err)
+		namespace, err := prov.GetMetricByName(ctx, types.NamespacedName{Name: "default"}, namespaces, labels.Everything())
+		require.NoError(t, err)
+		external, err := prov.GetExternalMetric(ctx, "default", labels.Everything(), provider.ExternalMetricInfo{Metric: "queue_length"})
+		require.NoError(t, err)
+		require.Len(t, external.Items, 1)
+		assert.Equal(t, fakeClock.Now(), node.Timestamp.Time)
+		return []string{service.Value.String(), node.Value.String(), namespace.Value.String(), external.Items[0].Value.String()}
+	}
+
+	assert.Equal(t, []string{"0", "40", "3", "0"}, values())
+	fakeClock.Step(5 * time.Minute)
+	assert.Equal(t, []string{"50", "80", "3", "50"}, values())
+	fakeClock.Step(time.Hour)
+	assert.Equal(t, []string{"100", "80", "3", "100"}, values())
+
+	// static values replace generators
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature", `"20"`))
+	assert.Equal(t, []string{"100", "20", "3", "100"}, values())
+}
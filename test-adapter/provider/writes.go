@@ -0,0 +1,548 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// MetricWrite is a write of the value of a metric, or of its generator.
+type MetricWrite struct {
+	// Resource is the group resource of the object described by a custom
+	// metric, such as pods or deployments.apps.  Writes without a resource
+	// are of external metrics.
+	Resource string `json:"resource,omitempty"`
+	// Namespace is the namespace of the described object, or of the
+	// external metric.
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the described object.
+	Name   string            `json:"name,omitempty"`
+	Metric string            `json:"metric"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Exactly one of Value and Generator must be set.
+	Value     *resource.Quantity `json:"value,omitempty"`
+	Generator *Generator         `json:"generator,omitempty"`
+	// TTL is the time after which the metric expires, if set.
+	TTL metav1.Duration `json:"ttl,omitzero"`
+	// Metadata describes the metric, if set.  It applies to all the
+	// objects or series of the metric, and is kept until the store is
+	// cleared.
+	Metadata *provider.MetricMetadata `json:"metadata,omitempty"`
+}
+
+// Batch is a list of writes, applied at once.
+type Batch struct {
+	Metrics []MetricWrite `json:"metrics"`
+}
+
+// StoredMetric is a metric of the store.
+type StoredMetric struct {
+	MetricWrite
+	// CurrentValue is the value of generated metrics, computed when the
+	// store was dumped.  It is ignored when the metric is written back.
+	CurrentValue   *resource.Quantity `json:"currentValue,omitempty"`
+	Timestamp      metav1.Time        `json:"timestamp"`
+	ExpirationTime *metav1.Time       `json:"expirationTime,omitempty"`
+	// StartTime is the time generated metrics started at.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+}
+
+// Store lists the metrics of the store, which can be written back as a
+// Batch.
+type Store struct {
+	Metrics []StoredMetric `json:"metrics"`
+}
+
+// ClockStep advances the clock of the provider.
+type ClockStep struct {
+	// Step is the duration to advance the clock by.
+	Step metav1.Duration `json:"step"`
+}
+
+// ClockTime is the current time of the clock of the provider.
+type ClockTime struct {
+	Time metav1.Time `json:"time"`
+}
+
+// webService creates a restful.WebService with routes set up for receiving fake metrics
+// These writing routes have been set up to be identical to the format of routes which metrics are read from.
+// There are 3 custom metric types available: namespaced, root-scoped, and namespaces.
+// (Note: Namespaces, we're assuming, are themselves namespaced resources, but for consistency with how metrics are retreived they have a separate route)
+// External metrics are written per namespace, with the labels of the series given as a selector in the labels query parameter.
+// Each write route has a generator counterpart, at the /generator subpath, which registers a Generator computing values at query time,
+// and a DELETE counterpart.  Writes expire after the duration of their ttl query parameter, if set.
+// The /batch route applies several writes at once, and the /store route dumps or clears all the metrics.
+// The /clock route returns the time of the provider, or advances it.
+func (p *testingProvider) webService() *restful.WebService {
+	ws := new(restful.WebService)
+
+	ws.Path("/write-metrics")
+
+	ttl := ws.QueryParameter("ttl", "duration after which the metric expires").DataType("string")
+
+	// Batches and the whole store
+	ws.Route(ws.POST("/batch").To(p.writeBatch).Reads(Batch{}))
+	ws.Route(ws.GET("/store").To(p.dumpStore).Writes(Store{}))
+	ws.Route(ws.DELETE("/store").To(p.clearStore))
+	ws.Route(ws.GET("/clock").To(p.getClock).Writes(ClockTime{}))
+	ws.Route(ws.POST("/clock").To(p.stepClock).Reads(ClockStep{}).Writes(ClockTime{}))
+
+	// External metrics
+	ws.Route(ws.POST("/external/{namespace}/{metric}").To(p.updateMetric).
+		Param(ws.BodyParameter("value", "value to set metric").DataType("integer").DefaultValue("0")).
+		Param(ws.QueryParameter("labels", "labels of the series, as a selector of equalities")).Param(ttl))
+	ws.Route(ws.POST("/external/{namespace}/{metric}/generator").To(p.updateMetricGenerator).
+		Param(ws.BodyParameter("generator", "generator of the metric values").DataType("Generator")).
+		Param(ws.QueryParameter("labels", "labels of the series, as a selector of equalities")).Param(ttl))
+	ws.Route(ws.DELETE("/external/{namespace}/{metric}").To(p.deleteExternalMetric).
+		Param(ws.QueryParameter("labels", "labels of the series to delete, all the series of the metric by default")))
+
+	// Namespaced resources
+	ws.Route(ws.POST("/namespaces/{namespace}/{resourceType}/{name}/{metric}").To(p.updateMetric).
+		Param(ws.BodyParameter("value", "value to set metric").DataType("integer").DefaultValue("0")).Param(ttl))
+	ws.Route(ws.POST("/namespaces/{namespace}/{resourceType}/{name}/{metric}/generator").To(p.updateMetricGenerator).
+		Param(ws.BodyParameter("generator", "generator of the metric values").DataType("Generator")).Param(ttl))
+	ws.Route(ws.DELETE("/namespaces/{namespace}/{resourceType}/{name}/{metric}").To(p.deleteMetric))
+
+	// Root-scoped resources
+	ws.Route(ws.POST("/{resourceType}/{name}/{metric}").To(p.updateMetric).
+		Param(ws.BodyParameter("value", "value to set metric").DataType("integer").DefaultValue("0")).Param(ttl))
+	ws.Route(ws.POST("/{resourceType}/{name}/{metric}/generator").To(p.updateMetricGenerator).
+		Param(ws.BodyParameter("generator", "generator of the metric values").DataType("Generator")).Param(ttl))
+	ws.Route(ws.DELETE("/{resourceType}/{name}/{metric}").To(p.deleteMetric))
+
+	// Namespaces, where {resourceType} == "namespaces" to match API
+	ws.Route(ws.POST("/{resourceType}/{name}/metrics/{metric}").To(p.updateMetric).
+		Param(ws.BodyParameter("value", "value to set metric").DataType("integer").DefaultValue("0")).Param(ttl))
+	ws.Route(ws.POST("/{resourceType}/{name}/metrics/{metric}/generator").To(p.updateMetricGenerator).
+		Param(ws.BodyParameter("generator", "generator of the metric values").DataType("Generator")).Param(ttl))
+	ws.Route(ws.DELETE("/{resourceType}/{name}/metrics/{metric}").To(p.deleteMetric))
+	return ws
+}
+
+// updateMetric writes the metric provided by a restful request and stores it in memory
+func (p *testingProvider) updateMetric(request *restful.Request, response *restful.Response) {
+	write, err := writeFor(request)
+	if err != nil {
+		writeError(response, http.StatusBadRequest, err)
+		return
+	}
+	write.Value = new(resource.Quantity)
+	if err := request.ReadEntity(write.Value); err != nil {
+		writeError(response, http.StatusBadRequest, err)
+		return
+	}
+	if err := p.store(write); err != nil {
+		writeError(response, http.StatusBadRequest, err)
+	}
+}
+
+// updateMetricGenerator writes the generator of the metric provided by a restful request and stores it in memory
+func (p *testingProvider) updateMetricGenerator(request *restful.Request, response *restful.Response) {
+	write, err := writeFor(request)
+	if err != nil {
+		writeError(response, http.StatusBadRequest, err)
+		return
+	}
+	write.Generator = new(Generator)
+	if err := request.ReadEntity(write.Generator); err != nil {
+		writeError(response, http.StatusBadRequest, err)
+		return
+	}
+	if err := p.store(write); err != nil {
+		writeError(response, http.StatusBadRequest, err)
+	}
+}
+
+// writeBatch stores the metrics of the batch provided by a restful request, if they are all valid
+func (p *testingProvider) writeBatch(request *restful.Request, response *restful.Response) {
+	batch := Batch{}
+	if err := request.ReadEntity(&batch); err != nil {
+		writeError(response, http.StatusBadRequest, err)
+		return
+	}
+	if err := p.store(batch.Metrics...); err != nil {
+		writeError(response, http.StatusBadRequest, err)
+	}
+}
+
+// deleteMetric deletes the custom metric provided by a restful request
+func (p *testingProvider) deleteMetric(request *restful.Request, response *restful.Response) {
+	write, err := writeFor(request)
+	if err != nil {
+		writeError(response, http.StatusBadRequest, err)
+		return
+	}
+	key := p.customMetricFor(write)
+
+	p.valuesLock.Lock()
+	defer p.valuesLock.Unlock()
+	p.purgeExpired(p.clock.Now())
+
+	if _, found := p.values[key]; !found {
+		writeError(response, http.StatusNotFound, provider.NewMetricNotFoundForError(key.GroupResource, key.Metric, key.Name))
+		return
+	}
+	delete(p.values, key)
+	p.metricsChanged(p.clock.Now(), false)
+}
+
+// deleteExternalMetric deletes the series of the external metric provided by a restful request: the one with the given
+// labels, or all of them if no labels are given
+func (p *testingProvider) deleteExternalMetric(request *restful.Request, response *restful.Response) {
+	metricLabels, err := labelsFor(request)
+	if err != nil {
+		writeError(response, http.StatusBadRequest, err)
+		return
+	}
+
+	p.valuesLock.Lock()
+	defer p.valuesLock.Unlock()
+	p.purgeExpired(p.clock.Now())
+
+	namespace := request.PathParameter("namespace")
+	metricName := request.PathParameter("metric")
+	deleted := 0
+	for key := range p.externalValues {
+		if key.namespace != namespace || key.name != metricName {
+			continue
+		}
+		if request.QueryParameter("labels") != "" && key.labels != metricLabels.String() {
+			continue
+		}
+		delete(p.externalValues, key)
+		deleted++
+	}
+	if deleted == 0 {
+		writeError(response, http.StatusNotFound, fmt.Errorf("external metric %s not found in namespace %s", metricName, namespace))
+		return
+	}
+	p.metricsChanged(p.clock.Now(), false)
+}
+
+// dumpStore writes the metrics of the store as the response to a restful request
+func (p *testingProvider) dumpStore(_ *restful.Request, response *restful.Response) {
+	if err := response.WriteHeaderAndJson(http.StatusOK, p.dump(), restful.MIME_JSON); err != nil {
+		klog.Errorf("Error writing the store: %s", err)
+	}
+}
+
+// clearStore deletes all the metrics
+func (p *testingProvider) clearStore(_ *restful.Request, _ *restful.Response) {
+	p.valuesLock.Lock()
+	defer p.valuesLock.Unlock()
+	metadataChanged := len(p.customMetadata) > 0 || len(p.externalMetadata) > 0
+	clear(p.values)
+	clear(p.externalValues)
+	clear(p.customMetadata)
+	clear(p.externalMetadata)
+	p.metricsChanged(p.clock.Now(), metadataChanged)
+}
+
+// getClock writes the time of the provider as the response to a restful request
+func (p *testingProvider) getClock(_ *restful.Request, response *restful.Response) {
+	if err := response.WriteHeaderAndJson(http.StatusOK, ClockTime{Time: metav1.NewTime(p.clock.Now())}, restful.MIME_JSON); err != nil {
+		klog.Errorf("Error writing the time: %s", err)
+	}
+}
+
+// stepClock advances the clock of the provider by the step provided by a restful request
+func (p *testingProvider) stepClock(request *restful.Request, response *restful.Response) {
+	stepper, ok := p.clock.(interface{ Step(time.Duration) })
+	if !ok {
+		writeError(response, http.StatusMethodNotAllowed, errors.New("the clock of the provider can't be advanced"))
+		return
+	}
+	step := ClockStep{}
+	if err := request.ReadEntity(&step); err != nil {
+		writeError(response, http.StatusBadRequest, err)
+		return
+	}
+	if step.Step.Duration < 0 {
+		writeError(response, http.StatusBadRequest, errors.New("the clock can't go backwards"))
+		return
+	}
+	stepper.Step(step.Step.Duration)
+
+	// notify the metrics expired by the step
+	p.valuesLock.Lock()
+	p.purgeExpired(p.clock.Now())
+	p.valuesLock.Unlock()
+	p.getClock(request, response)
+}
+
+// writeFor returns the write of the metric identified by the path and the query parameters of a restful request
+func writeFor(request *restful.Request) (MetricWrite, error) {
+	metricLabels, err := labelsFor(request)
+	if err != nil {
+		return MetricWrite{}, err
+	}
+	write := MetricWrite{
+		Resource:  request.PathParameter("resourceType"),
+		Namespace: request.PathParameter("namespace"),
+		Name:      request.PathParameter("name"),
+		Metric:    request.PathParameter("metric"),
+		Labels:    metricLabels,
+	}
+	if ttl := request.QueryParameter("ttl"); ttl != "" {
+		if write.TTL.Duration, err = time.ParseDuration(ttl); err != nil {
+			return MetricWrite{}, fmt.Errorf("invalid ttl: %v", err)
+		}
+	}
+	return write, nil
+}
+
+// labelsFor parses the labels query parameter of a restful request
+func labelsFor(request *restful.Request) (labels.Set, error) {
+	sel := request.QueryParameter("labels")
+	if len(sel) == 0 {
+		return labels.Set{}, nil
+	}
+	return labels.ConvertSelectorToLabelsMap(sel)
+}
+
+// writeError writes an error as the response to a restful request
+func writeError(response *restful.Response, status int, err error) {
+	if err := response.WriteErrorString(status, err.Error()); err != nil {
+		klog.Errorf("Error writing error: %s", err)
+	}
+}
+
+// entry is a value to store, along with the write it comes from
+type entry struct {
+	write        MetricWrite
+	customMetric CustomMetricResource
+	value        metricValue
+}
+
+// entryFor validates a write, and returns the entry it stores
+func (p *testingProvider) entryFor(write MetricWrite, now time.Time) (entry, error) {
+	value, err := valueOf(write, now)
+	if err != nil {
+		return entry{}, err
+	}
+	e := entry{write: write, value: value}
+	if write.Resource != "" {
+		e.customMetric = p.customMetricFor(write)
+	}
+	return e, nil
+}
+
+// store stores the given writes, unless any of them is invalid
+func (p *testingProvider) store(writes ...MetricWrite) error {
+	now := p.clock.Now()
+	entries := make([]entry, 0, len(writes))
+	for i, write := range writes {
+		e, err := p.entryFor(write, now)
+		if err != nil {
+			if len(writes) > 1 {
+				return fmt.Errorf("invalid metric %d: %v", i, err)
+			}
+			return err
+		}
+		entries = append(entries, e)
+	}
+	p.storeEntries(now, false, entries)
+	return nil
+}
+
+// storeEntries stores entries, replacing all the stored metrics if asked to
+func (p *testingProvider) storeEntries(now time.Time, replace bool, entries []entry) {
+	p.valuesLock.Lock()
+	defer p.valuesLock.Unlock()
+	metadataChanged := false
+	if replace {
+		metadataChanged = len(p.customMetadata) > 0 || len(p.externalMetadata) > 0
+		clear(p.values)
+		clear(p.externalValues)
+		clear(p.customMetadata)
+		clear(p.externalMetadata)
+	}
+	p.purgeExpired(now)
+
+	for _, e := range entries {
+		if e.write.Resource == "" {
+			p.externalValues[externalMetricFor(e.write)] = e.value
+			if e.write.Metadata != nil && setMetadata(p.externalMetadata, e.write.Metric, *e.write.Metadata) {
+				metadataChanged = true
+			}
+		} else {
+			p.values[e.customMetric] = e.value
+			if e.write.Metadata != nil && setMetadata(p.customMetadata, e.customMetric.CustomMetricInfo, *e.write.Metadata) {
+				metadataChanged = true
+			}
+		}
+	}
+	p.metricsChanged(now, metadataChanged)
+}
+
+// setMetadata sets the metadata of a metric, and returns whether it changed
+func setMetadata[K comparable](metadata map[K]provider.MetricMetadata, key K, m provider.MetricMetadata) bool {
+	previous, found := metadata[key]
+	metadata[key] = m
+	return !found || !equality.Semantic.DeepEqual(previous, m)
+}
+
+// valueOf validates a write, and returns the value it stores
+func valueOf(write MetricWrite, now time.Time) (metricValue, error) {
+	switch {
+	case write.Metric == "":
+		return metricValue{}, errors.New("the metric name is required")
+	case write.Resource != "" && write.Name == "":
+		return metricValue{}, errors.New("the name of the described object is required")
+	case (write.Value == nil) == (write.Generator == nil):
+		return metricValue{}, errors.New("exactly one of a value and a generator is required")
+	case write.TTL.Duration < 0:
+		return metricValue{}, errors.New("the ttl can't be negative")
+	}
+	if write.Metadata != nil {
+		if err := write.Metadata.Validate(); err != nil {
+			return metricValue{}, err
+		}
+	}
+
+	value := metricValue{
+		labels:    labels.Set{},
+		timestamp: metav1.NewTime(now),
+	}
+	for k, v := range write.Labels {
+		value.labels[k] = v
+	}
+	if write.Value != nil {
+		value.value = *write.Value
+	} else {
+		gen, err := newGenerator(*write.Generator, now)
+		if err != nil {
+			return metricValue{}, err
+		}
+		value.generator = gen
+	}
+	if write.TTL.Duration > 0 {
+		value.expires = now.Add(write.TTL.Duration)
+	}
+	return value, nil
+}
+
+// customMetricFor returns the key of the custom metric of a write
+func (p *testingProvider) customMetricFor(write MetricWrite) CustomMetricResource {
+	info := provider.CustomMetricInfo{
+		GroupResource: schema.ParseGroupResource(write.Resource),
+		Metric:        write.Metric,
+		Namespaced:    len(write.Namespace) > 0 || write.Resource == "namespaces",
+	}
+
+	info, _, err := info.Normalized(p.mapper)
+	if err != nil {
+		klog.Errorf("Error normalizing info: %s", err)
+	}
+	return CustomMetricResource{
+		CustomMetricInfo: info,
+		NamespacedName: types.NamespacedName{
+			Name:      write.Name,
+			Namespace: write.Namespace,
+		},
+	}
+}
+
+// externalMetricFor returns the key of the external metric series of a write
+func externalMetricFor(write MetricWrite) externalMetric {
+	return externalMetric{
+		namespace: write.Namespace,
+		name:      write.Metric,
+		labels:    labels.Set(write.Labels).String(),
+	}
+}
+
+// purgeExpired deletes the expired metrics, and notifies the listeners if the listed metrics changed since, with the
+// values lock held
+func (p *testingProvider) purgeExpired(now time.Time) {
+	maps.DeleteFunc(p.values, func(_ CustomMetricResource, value metricValue) bool {
+		return value.expired(now)
+	})
+	maps.DeleteFunc(p.externalValues, func(_ externalMetric, value metricValue) bool {
+		return value.expired(now)
+	})
+	p.metricsChanged(now, false)
+}
+
+// dump lists the metrics of the store
+func (p *testingProvider) dump() *Store {
+	p.valuesLock.RLock()
+	defer p.valuesLock.RUnlock()
+
+	now := p.clock.Now()
+	store := &Store{Metrics: []StoredMetric{}}
+	add := func(write MetricWrite, value metricValue) {
+		if value.expired(now) {
+			return
+		}
+		value = p.current(value)
+		write.Labels = value.labels
+		metric := StoredMetric{MetricWrite: write, Timestamp: value.timestamp}
+		if value.generator != nil {
+			metric.Generator = &value.generator.spec
+			metric.CurrentValue = &value.value
+			metric.StartTime = &metav1.Time{Time: value.generator.start}
+		} else {
+			metric.Value = &value.value
+		}
+		if !value.expires.IsZero() {
+			metric.ExpirationTime = &metav1.Time{Time: value.expires}
+		}
+		store.Metrics = append(store.Metrics, metric)
+	}
+	for key, value := range p.values {
+		add(MetricWrite{
+			Resource:  key.GroupResource.String(),
+			Namespace: key.Namespace,
+			Name:      key.Name,
+			Metric:    key.Metric,
+			Metadata:  metadataOf(p.customMetadata, key.CustomMetricInfo),
+		}, value)
+	}
+	for key, value := range p.externalValues {
+		add(MetricWrite{Namespace: key.namespace, Metric: key.name, Metadata: metadataOf(p.externalMetadata, key.name)}, value)
+	}
+
+	slices.SortFunc(store.Metrics, func(a, b StoredMetric) int {
+		return cmp.Or(
+			cmp.Compare(a.Resource, b.Resource),
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Metric, b.Metric),
+			cmp.Compare(labels.Set(a.Labels).String(), labels.Set(b.Labels).String()),
+		)
+	})
+	return store
+}
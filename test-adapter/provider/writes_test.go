@@ -0,0 +1,292 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+var (
+	servicesRequests = provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "services"}, Namespaced: true, Metric: "requests"}
+	nodesTemperature = provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "nodes"}, Metric: "temperature"}
+	namespacesJobs   = provider.CustomMetricInfo{GroupResource: schema.GroupResource{Resource: "namespaces"}, Namespaced: true, Metric: "jobs"}
+)
+
+// dumpStore returns the store dumped by the write service.
+func dumpStore(t *testing.T, handler http.Handler) Store {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/write-metrics/store", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	store := Store{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &store))
+	return store
+}
+
+func TestDeletes(t *testing.T) {
+	prov, handler := newTestProvider(t)
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/namespaces/default/services/kubernetes/requests", `"1"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature", `"40"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/namespaces/default/metrics/jobs", `"3"`))
+	require.Len(t, prov.ListAllMetrics(), 3)
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/namespaces/default/services/kubernetes/requests", ""))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/nodes/node-1/temperature", ""))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/namespaces/default/metrics/jobs", ""))
+	assert.Empty(t, prov.ListAllMetrics())
+	assert.Equal(t, http.StatusNotFound, write(t, handler, http.MethodDelete, "/write-metrics/nodes/node-1/temperature", ""))
+
+	_, err := prov.GetMetricByName(context.Background(), types.NamespacedName{Name: "node-1"}, nodesTemperature, labels.Everything())
+	assert.Error(t, err)
+}
+
+func TestMetricsChangeNotifications(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	notifications := 0
+	prov.OnMetricsChange(func() { notifications++ })
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature", `"40"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag", `"1"`))
+	assert.Equal(t, 2, notifications, "writes should be notified")
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature", `"41"`))
+	assert.Equal(t, 2, notifications, "values written to listed metrics should not be notified")
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/batch", `{"metrics": [{"resource": "nodes", "name": "node-1", "metric": "temperature", "value": "41", "metadata": {"unit": "Cel"}}]}`))
+	assert.Equal(t, 3, notifications, "metadata changes should be notified")
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/nodes/node-1/temperature", ""))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/external/default/lag", ""))
+	assert.Equal(t, 5, notifications, "deletes should be notified")
+
+	require.Equal(t, http.StatusNotFound, write(t, handler, http.MethodDelete, "/write-metrics/external/default/lag", ""))
+	assert.Equal(t, 5, notifications, "failed deletes should not be notified")
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag?ttl=1m", `"1"`))
+	assert.Equal(t, 6, notifications)
+	assert.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/clock", `{"step": "1m"}`))
+	assert.Equal(t, 7, notifications, "expirations should be notified")
+}
+
+func TestTTL(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	fakeClock := clocktesting.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	prov.clock = fakeClock
+	ctx := context.Background()
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature?ttl=1m", `"40"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/namespaces/default/services/kubernetes/requests/generator?ttl=2m", `{"type": "constant", "value": 1}`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/queue_length?ttl=1m", `"12"`))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag", `"1"`))
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag?ttl=soon", `"1"`))
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag?ttl=-1m", `"1"`))
+
+	store := dumpStore(t, handler)
+	require.Len(t, store.Metrics, 4)
+	assert.Equal(t, "queue_length", store.Metrics[1].Metric)
+	assert.WithinDuration(t, fakeClock.Now().Add(time.Minute), store.Metrics[1].ExpirationTime.Time, 0)
+
+	fakeClock.Step(time.Minute)
+	_, err := prov.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, nodesTemperature, labels.Everything())
+	assert.Error(t, err)
+	value, err := prov.GetMetricByName(ctx, types.NamespacedName{Namespace: "default", Name: "kubernetes"}, servicesRequests, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, "1", value.Value.String())
+	assert.Equal(t, []provider.CustomMetricInfo{servicesRequests}, prov.ListAllMetrics())
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "lag"}}, prov.ListAllExternalMetrics())
+	external, err := prov.GetExternalMetric(ctx, "default", labels.Everything(), provider.ExternalMetricInfo{Metric: "queue_length"})
+	require.NoError(t, err)
+	assert.Empty(t, external.Items)
+	assert.Equal(t, http.StatusNotFound, write(t, handler, http.MethodDelete, "/write-metrics/nodes/node-1/temperature", ""))
+
+	fakeClock.Step(time.Minute)
+	assert.Empty(t, prov.ListAllMetrics())
+	assert.Len(t, dumpStore(t, handler).Metrics, 1)
+
+	// expired metrics are purged on writes
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/lag", `"2"`))
+	prov.valuesLock.RLock()
+	defer prov.valuesLock.RUnlock()
+	assert.Empty(t, prov.values)
+	assert.Len(t, prov.externalValues, 1)
+}
+
+func TestBatchAndStore(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	fakeClock := clocktesting.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	prov.clock = fakeClock
+	ctx := context.Background()
+
+	batch := `{"metrics": [
+		{"resource": "services", "namespace": "default", "name": "kubernetes", "metric": "requests", "labels": {"verb": "get"}, "value": "300m"},
+		{"resource": "nodes", "name": "node-1", "metric": "temperature", "generator": {"type": "ramp", "from": 0, "to": 60, "duration": "1m"}, "ttl": "5m"},
+		{"resource": "namespaces", "name": "default", "metric": "jobs", "value": "3"},
+		{"namespace": "default", "metric": "queue_length", "labels": {"queue": "orders"}, "value": "12"}
+	]}`
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/batch", batch))
+	assert.ElementsMatch(t, []provider.CustomMetricInfo{servicesRequests, nodesTemperature, namespacesJobs}, prov.ListAllMetrics())
+
+	fakeClock.Step(30 * time.Second)
+	value, err := prov.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, nodesTemperature, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, "30", value.Value.String())
+	external, err := prov.GetExternalMetric(ctx, "default", labels.SelectorFromSet(labels.Set{"queue": "orders"}), provider.ExternalMetricInfo{Metric: "queue_length"})
+	require.NoError(t, err)
+	assert.Len(t, external.Items, 1)
+
+	store := dumpStore(t, handler)
+	require.Len(t, store.Metrics, 4)
+	assert.Equal(t, MetricWrite{Namespace: "default", Metric: "queue_length", Labels: map[string]string{"queue": "orders"}, Value: ptrTo(resource.MustParse("12"))}, store.Metrics[0].MetricWrite)
+	assert.Equal(t, MetricWrite{Resource: "namespaces", Name: "default", Metric: "jobs", Value: ptrTo(resource.MustParse("3"))}, store.Metrics[1].MetricWrite)
+	assert.Equal(t, "nodes", store.Metrics[2].Resource)
+	assert.Nil(t, store.Metrics[2].Value)
+	assert.Equal(t, "30", store.Metrics[2].CurrentValue.String())
+	assert.Equal(t, RampGenerator, store.Metrics[2].Generator.Type)
+	assert.WithinDuration(t, fakeClock.Now(), store.Metrics[2].Timestamp.Time, 0)
+	assert.WithinDuration(t, fakeClock.Now().Add(4*time.Minute+30*time.Second), store.Metrics[2].ExpirationTime.Time, 0)
+	assert.Equal(t, map[string]string{"verb": "get"}, store.Metrics[3].Labels)
+
+	// batches are applied only if all their writes are valid
+	invalid := `{"metrics": [
+		{"namespace": "default", "metric": "lag", "value": "1"},
+		{"resource": "nodes", "metric": "temperature", "value": "1"}
+	]}`
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/batch", invalid))
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/batch", `{"metrics": [{"metric": "lag"}]}`))
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/batch", `{"metrics": [{"metric": "lag", "value": "1", "generator": {"type": "constant"}}]}`))
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_length"}}, prov.ListAllExternalMetrics())
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/store", ""))
+	assert.Empty(t, prov.ListAllMetrics())
+	assert.Empty(t, prov.ListAllExternalMetrics())
+	assert.Empty(t, dumpStore(t, handler).Metrics)
+}
+
+func TestMetadata(t *testing.T) {
+	prov, handler := newTestProvider(t)
+
+	batch := `{"metrics": [
+		{"resource": "services", "namespace": "default", "name": "kubernetes", "metric": "requests", "value": "300m",
+		 "metadata": {"unit": "requests/s", "kind": "rate", "description": "Requests served", "selectableLabels": ["verb"]}},
+		{"resource": "services", "namespace": "default", "name": "metrics", "metric": "requests", "value": "1"},
+		{"namespace": "default", "metric": "queue_length", "value": "12", "metadata": {"kind": "gauge"}}
+	]}`
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/batch", batch))
+	requests := provider.MetricMetadata{Unit: "requests/s", Kind: provider.MetricKindRate, Description: "Requests served", SelectableLabels: []string{"verb"}}
+	assert.Equal(t, []provider.CustomMetricInfo{servicesRequests}, prov.ListAllMetrics())
+	assert.Equal(t, &requests, prov.CustomMetricMetadata(servicesRequests))
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_length"}}, prov.ListAllExternalMetrics())
+	assert.Equal(t, &provider.MetricMetadata{Kind: provider.MetricKindGauge}, prov.ExternalMetricMetadata(provider.ExternalMetricInfo{Metric: "queue_length"}))
+	assert.Nil(t, prov.ExternalMetricMetadata(provider.ExternalMetricInfo{Metric: "lag"}))
+
+	// the returned metadata is a copy
+	prov.CustomMetricMetadata(servicesRequests).SelectableLabels[0] = "code"
+	assert.Equal(t, []string{"verb"}, prov.CustomMetricMetadata(servicesRequests).SelectableLabels)
+
+	store := dumpStore(t, handler)
+	require.Len(t, store.Metrics, 3)
+	for _, metric := range store.Metrics {
+		require.NotNil(t, metric.Metadata, "metric %s", metric.Metric)
+	}
+	assert.Equal(t, requests, *store.Metrics[1].Metadata)
+
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/batch", `{"metrics": [{"metric": "lag", "value": "1", "metadata": {"kind": "histogram"}}]}`))
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodDelete, "/write-metrics/store", ""))
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/external/default/queue_length", `"1"`))
+	assert.Equal(t, []provider.ExternalMetricInfo{{Metric: "queue_length"}}, prov.ListAllExternalMetrics(), "metadata should be cleared with the store")
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	fakeClock := clocktesting.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	prov.clock = fakeClock
+
+	batch := `{"metrics": [
+		{"resource": "nodes", "name": "node-1", "metric": "temperature", "generator": {"type": "ramp", "from": 0, "to": 60, "duration": "1m"}},
+		{"namespace": "default", "metric": "queue_length", "labels": {"queue": "orders"}, "value": "12"}
+	]}`
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/batch", batch))
+	fakeClock.Step(30 * time.Second)
+	store := dumpStore(t, handler)
+
+	// the dump is written back as a batch to a new provider
+	other, otherHandler := newTestProvider(t)
+	other.clock = fakeClock
+	dump, err := json.Marshal(store)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, write(t, otherHandler, http.MethodPost, "/write-metrics/batch", string(dump)))
+	assert.ElementsMatch(t, prov.ListAllMetrics(), other.ListAllMetrics())
+	assert.ElementsMatch(t, prov.ListAllExternalMetrics(), other.ListAllExternalMetrics())
+
+	// generators restart when written back
+	value, err := other.GetMetricByName(context.Background(), types.NamespacedName{Name: "node-1"}, nodesTemperature, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, "0", value.Value.String())
+}
+
+func TestClock(t *testing.T) {
+	prov, handler := newTestProvider(t)
+	ctx := context.Background()
+
+	require.Equal(t, http.StatusOK, write(t, handler, http.MethodPost, "/write-metrics/nodes/node-1/temperature/generator", `{"type": "step", "from": 20, "to": 40, "duration": "1h"}`))
+	value, err := prov.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, nodesTemperature, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, "20", value.Value.String())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/write-metrics/clock", strings.NewReader(`{"step": "1h"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	now := ClockTime{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &now))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), now.Time.Time, time.Minute)
+
+	value, err = prov.GetMetricByName(ctx, types.NamespacedName{Name: "node-1"}, nodesTemperature, labels.Everything())
+	require.NoError(t, err)
+	assert.Equal(t, "40", value.Value.String(), "generators should follow the clock")
+	assert.Equal(t, http.StatusBadRequest, write(t, handler, http.MethodPost, "/write-metrics/clock", `{"step": "-1h"}`))
+
+	fakeClock := clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, ws := NewFakeProviderWithClock(nil, nil, fakeClock)
+	container := restful.NewContainer()
+	container.Add(ws)
+	assert.Equal(t, http.StatusMethodNotAllowed, write(t, container, http.MethodPost, "/write-metrics/clock", `{"step": "1h"}`),
+		"clocks which can't be advanced should be reported")
+}